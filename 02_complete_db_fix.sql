@@ -168,6 +168,35 @@ CREATE TABLE IF NOT EXISTS chat_messages (
 
 CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_messages_session_index ON chat_messages(session_id, message_index);
 
+-- 9. MCP_USAGE TABLE - One row per direct MCP tool call (usage metering)
+CREATE TABLE IF NOT EXISTS mcp_usage (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mcp_id TEXT NOT NULL,
+    mcp_name TEXT NOT NULL,
+    tool TEXT NOT NULL,
+    user_id TEXT,
+    org_id TEXT,
+    latency_ms DOUBLE PRECISION NOT NULL,
+    success BOOLEAN NOT NULL,
+    request_bytes INTEGER NOT NULL DEFAULT 0,
+    response_bytes INTEGER NOT NULL DEFAULT 0,
+    error_code TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 10. MCP_HEALTH TABLE - Latest health check per MCP configuration
+CREATE TABLE IF NOT EXISTS mcp_health (
+    mcp_id TEXT PRIMARY KEY,
+    name TEXT,
+    status TEXT NOT NULL DEFAULT 'unknown',
+    last_checked_at TIMESTAMP WITH TIME ZONE,
+    last_success_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    last_error_at TIMESTAMP WITH TIME ZONE,
+    latency_ms DOUBLE PRECISION,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0
+);
+
 -- ============================================================================
 -- INDEXES FOR PERFORMANCE
 -- ============================================================================
@@ -205,12 +234,21 @@ CREATE INDEX IF NOT EXISTS idx_audit_logs_org_id ON audit_logs(org_id);
 CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp ON audit_logs(timestamp);
 CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action);
 CREATE INDEX IF NOT EXISTS idx_audit_logs_resource_type ON audit_logs(resource_type);
+-- One row per MCP configuration version; concurrent writers retry on conflict.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_mcp_config_version
+    ON audit_logs(resource_id, (details->>'version'))
+    WHERE resource_type = 'mcp_configuration' AND details ? 'version';
 
 -- Chat Sessions indexes
 CREATE INDEX IF NOT EXISTS idx_chat_sessions_user_id ON chat_sessions(user_id);
 CREATE INDEX IF NOT EXISTS idx_chat_sessions_org_id ON chat_sessions(org_id);
 CREATE INDEX IF NOT EXISTS idx_chat_sessions_last_message ON chat_sessions(last_message_at DESC NULLS LAST);
 
+-- MCP Usage indexes
+CREATE INDEX IF NOT EXISTS idx_mcp_usage_created_at ON mcp_usage(created_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_usage_org_created_at ON mcp_usage(org_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_usage_mcp_created_at ON mcp_usage(mcp_id, created_at);
+
 -- ============================================================================
 -- ENABLE ROW LEVEL SECURITY (RLS)
 -- ============================================================================
@@ -224,6 +262,8 @@ ALTER TABLE mcp_configurations ENABLE ROW LEVEL SECURITY;
 ALTER TABLE agents ENABLE ROW LEVEL SECURITY;
 ALTER TABLE audit_logs ENABLE ROW LEVEL SECURITY;
 ALTER TABLE chat_sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE mcp_usage ENABLE ROW LEVEL SECURITY;
+ALTER TABLE mcp_health ENABLE ROW LEVEL SECURITY;
 
 -- ============================================================================
 -- RLS POLICIES
@@ -299,6 +339,12 @@ CREATE POLICY "Service role full access to user_settings" ON user_settings
 CREATE POLICY "Service role full access to audit_logs" ON audit_logs
     FOR ALL USING (auth.role() = 'service_role');
 
+CREATE POLICY "Service role full access to mcp_usage" ON mcp_usage
+    FOR ALL USING (auth.role() = 'service_role');
+
+CREATE POLICY "Service role full access to mcp_health" ON mcp_health
+    FOR ALL USING (auth.role() = 'service_role');
+
 -- ============================================================================
 -- VERIFICATION
 -- ============================================================================
@@ -0,0 +1,16 @@
+"""Embedded operator UI served under ``/admin``.
+
+The UI is plain static HTML/JS that calls the existing platform, MCP and health
+APIs, so operators can inspect a deployment without the separate frontend. It is
+off by default (``enable_admin_ui``). The static pages themselves are public;
+the platform APIs they call require the ``platform_admin_token``, which the
+operator enters in the page and which is kept in ``sessionStorage``.
+"""
+
+from __future__ import annotations
+
+import pathlib
+
+STATIC_DIR = pathlib.Path(__file__).resolve().parent / "static"
+
+__all__ = ["STATIC_DIR"]
@@ -0,0 +1,155 @@
+"use strict";
+
+const AUDIT_PAGE_SIZE = 50;
+const TOKEN_KEY = "atomsAgent.adminToken";
+let auditOffset = 0;
+
+async function fetchJSON(path) {
+  const headers = { Accept: "application/json" };
+  const token = sessionStorage.getItem(TOKEN_KEY);
+  if (token) {
+    headers.Authorization = `Bearer ${token}`;
+  }
+  const response = await fetch(path, { headers });
+  if (!response.ok) {
+    throw new Error(`${path}: HTTP ${response.status}`);
+  }
+  return response.json();
+}
+
+function cell(value) {
+  const td = document.createElement("td");
+  if (value !== null && typeof value === "object") {
+    const pre = document.createElement("pre");
+    pre.textContent = JSON.stringify(value, null, 2);
+    td.appendChild(pre);
+  } else {
+    td.textContent = value === null || value === undefined ? "" : String(value);
+  }
+  return td;
+}
+
+function renderTable(containerId, rows, columns) {
+  const container = document.getElementById(containerId);
+  container.replaceChildren();
+  if (!rows.length) {
+    container.textContent = "Nothing to show.";
+    return;
+  }
+  const table = document.createElement("table");
+  const head = table.createTHead().insertRow();
+  for (const column of columns) {
+    const th = document.createElement("th");
+    th.textContent = column;
+    head.appendChild(th);
+  }
+  const body = table.createTBody();
+  for (const row of rows) {
+    const tr = body.insertRow();
+    for (const column of columns) {
+      tr.appendChild(cell(row[column]));
+    }
+  }
+  container.appendChild(table);
+}
+
+const loaders = {
+  async health() {
+    const [health, stats] = await Promise.all([
+      fetchJSON("/health"),
+      fetchJSON("/api/v1/platform/stats"),
+    ]);
+    renderTable("health-body", [{ ...health, ...stats }], Object.keys({ ...health, ...stats }));
+  },
+  async sessions() {
+    const data = await fetchJSON("/api/v1/platform/sessions");
+    renderTable("sessions-body", data.sessions, [
+      "session_id",
+      "organization_id",
+      "user_id",
+      "model",
+      "state",
+      "last_used_at",
+      "mcp_servers",
+    ]);
+  },
+  async mcp() {
+    const form = document.getElementById("mcp-form");
+    const orgId = form.elements.organization_id.value.trim();
+    if (!orgId) {
+      return;
+    }
+    const params = new URLSearchParams({ organization_id: orgId, include_platform: "true" });
+    const data = await fetchJSON(`/atoms/mcp?${params}`);
+    renderTable("mcp-body", data.items, [
+      "id",
+      "name",
+      "endpoint",
+      "auth_type",
+      "enabled",
+      "is_default",
+      "scope",
+    ]);
+  },
+  async caches() {
+    const data = await fetchJSON("/api/v1/platform/caches");
+    renderTable("caches-body", data.caches, [
+      "name",
+      "entries",
+      "current_bytes",
+      "max_bytes",
+      "hits",
+      "misses",
+      "evictions",
+    ]);
+  },
+  async audit() {
+    const params = new URLSearchParams({ limit: AUDIT_PAGE_SIZE, offset: auditOffset });
+    const data = await fetchJSON(`/api/v1/platform/audit?${params}`);
+    const entries = data.entries;
+    renderTable("audit-body", entries, entries.length ? Object.keys(entries[0]) : []);
+    document.getElementById("audit-prev").disabled = auditOffset === 0;
+    document.getElementById("audit-next").disabled = auditOffset + entries.length >= data.count;
+  },
+};
+
+async function show(tab) {
+  document.querySelectorAll("nav button").forEach((button) => {
+    button.classList.toggle("active", button.dataset.tab === tab);
+  });
+  document.querySelectorAll(".tab").forEach((section) => {
+    section.classList.toggle("active", section.id === tab);
+  });
+  const error = document.getElementById("error");
+  error.textContent = "";
+  try {
+    await loaders[tab]();
+  } catch (exc) {
+    error.textContent = exc.message;
+  }
+}
+
+document.querySelectorAll("nav button").forEach((button) => {
+  button.addEventListener("click", () => show(button.dataset.tab));
+});
+const tokenForm = document.getElementById("token-form");
+tokenForm.elements.token.value = sessionStorage.getItem(TOKEN_KEY) || "";
+tokenForm.addEventListener("submit", (event) => {
+  event.preventDefault();
+  sessionStorage.setItem(TOKEN_KEY, tokenForm.elements.token.value.trim());
+  show(document.querySelector("nav button.active").dataset.tab);
+});
+document.getElementById("mcp-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  show("mcp");
+});
+document.getElementById("audit-prev").addEventListener("click", () => {
+  auditOffset = Math.max(0, auditOffset - AUDIT_PAGE_SIZE);
+  show("audit");
+});
+document.getElementById("audit-next").addEventListener("click", () => {
+  auditOffset += AUDIT_PAGE_SIZE;
+  show("audit");
+});
+
+show("health");
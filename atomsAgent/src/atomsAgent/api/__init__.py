@@ -1,18 +1,42 @@
 from fastapi import FastAPI
 
-from atomsAgent.api.routes import chat, mcp, openai, platform
+from atomsAgent.api.routes import chat, mcp, openai, platform, scim, sessions
+from atomsAgent.config import settings
+from atomsAgent.dependencies import (
+    get_error_budget,
+    get_mcp_breakers,
+    get_mcp_health_monitor,
+)
 # from atomsAgent.api.routes import oauth  # Temporarily disabled - needs oauth_manager implementation
-from atomsAgent.schemas.platform import SystemHealth
+from atomsAgent.schemas.platform import ErrorBudgetStatus, SystemHealth
 
 
 def register_routes(app: FastAPI) -> None:
     """Attach all routers to the FastAPI application."""
     app.include_router(openai.router, prefix="/v1", tags=["openai"])
+    app.include_router(sessions.router, prefix="/v1/sessions", tags=["sessions"])
     app.include_router(chat.router, prefix="/atoms/chat", tags=["chat"])
     app.include_router(mcp.router, prefix="/atoms/mcp", tags=["mcp"])
     # app.include_router(oauth.router, prefix="/atoms/oauth", tags=["oauth"])  # Temporarily disabled
     app.include_router(platform.router, prefix="/api/v1/platform", tags=["platform"])
+    app.include_router(scim.router, prefix="/scim/v2", tags=["scim"])
 
     @app.get("/health", tags=["health"])
     async def health_check() -> SystemHealth:
-        return SystemHealth(status="healthy")
+        mcp_servers = None
+        if settings.mcp_health_check_interval_seconds:
+            mcp_servers = get_mcp_health_monitor().summary()
+        mcp_breakers = get_mcp_breakers().summary()
+        error_budgets = {
+            operation: ErrorBudgetStatus.model_validate(budget, from_attributes=True)
+            for operation, budget in get_error_budget().summary().items()
+        }
+        return SystemHealth(
+            circuit_breaker_status="degraded" if mcp_breakers["open"] else "healthy",
+            error_budget_status="degraded"
+            if any(budget.exhausted for budget in error_budgets.values())
+            else "healthy",
+            mcp_servers=mcp_servers,
+            mcp_breakers=mcp_breakers,
+            error_budgets=error_budgets,
+        )
@@ -0,0 +1,43 @@
+"""Shared-secret bearer authentication for operator endpoints.
+
+This service has no user authentication of its own, so operator-only actions
+are gated on secrets from ``secrets.yml`` presented as ``Authorization: Bearer``.
+"""
+
+from __future__ import annotations
+
+import hmac
+
+from fastapi import Header, HTTPException, status
+
+from atomsAgent.config import settings
+
+# Audit actor for actions authorized by ``platform_admin``: the shared token is
+# the only identity the caller proves.
+PLATFORM_ADMIN_ACTOR = "platform_admin"
+
+
+def bearer_matches(authorization: str | None, expected: str) -> bool:
+    """Return whether ``authorization`` is ``Bearer <expected>``, compared in constant time."""
+    scheme, _, token = (authorization or "").partition(" ")
+    return scheme.lower() == "bearer" and hmac.compare_digest(
+        token.strip().encode("utf-8"), expected.encode("utf-8")
+    )
+
+
+async def platform_admin(authorization: str | None = Header(None)) -> bool:
+    """Whether the caller presented the ``platform_admin_token`` secret.
+
+    Callers without it are treated as ordinary organization callers; the
+    service decides which operations need a platform admin.
+    """
+    expected = getattr(settings, "platform_admin_token", None)
+    return bool(expected) and bearer_matches(authorization, expected)
+
+
+def ensure_platform_admin(is_platform_admin: bool) -> None:
+    """Reject callers without the platform admin token with a 403."""
+    if not is_platform_admin:
+        raise HTTPException(
+            status_code=status.HTTP_403_FORBIDDEN, detail="Platform admin token required"
+        )
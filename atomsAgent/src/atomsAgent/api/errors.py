@@ -0,0 +1,190 @@
+from __future__ import annotations
+
+import re
+from collections.abc import Callable, Mapping
+from dataclasses import dataclass
+from http import HTTPStatus
+from typing import Any
+
+from fastapi import FastAPI, Request, status
+from fastapi.encoders import jsonable_encoder
+from fastapi.exception_handlers import http_exception_handler
+from fastapi.exceptions import RequestValidationError
+from fastapi.responses import JSONResponse, Response
+from starlette.exceptions import HTTPException as StarletteHTTPException
+
+from atomsAgent.config import settings
+from atomsAgent.utils.log_redaction import DEFAULT_REDACT_KEYS, RedactionFilter
+
+PROBLEM_MEDIA_TYPE = "application/problem+json"
+
+
+class FieldValidationError(StarletteHTTPException):
+    """A 400 that names the offending fields so frontends can highlight them.
+
+    Rendered as ``{"detail": ..., "field_errors": {field: message}}``, or with
+    ``field_errors`` as a problem-details extension member.
+    """
+
+    def __init__(
+        self, field_errors: Mapping[str, str], detail: str = "Request validation failed"
+    ) -> None:
+        super().__init__(status_code=status.HTTP_400_BAD_REQUEST, detail=detail)
+        self.field_errors = dict(field_errors)
+
+
+@dataclass(frozen=True, slots=True)
+class ErrorClass:
+    """How an upstream failure is reported: HTTP status and OpenAI-style error type."""
+
+    status_code: int
+    error_type: str
+
+
+ErrorClassifier = Callable[[BaseException], ErrorClass | None]
+
+_classifiers: dict[str, list[ErrorClassifier]] = {}
+
+
+def register_error_classifier(provider: str, classifier: ErrorClassifier) -> None:
+    """Add a classifier for ``provider``'s errors; earlier registrations win."""
+    _classifiers.setdefault(provider, []).append(classifier)
+
+
+def register_error_pattern(
+    provider: str, pattern: str, *, status_code: int, error_type: str
+) -> None:
+    """Classify errors whose message matches ``pattern`` (case-insensitive)."""
+    regex = re.compile(pattern, re.IGNORECASE)
+    error_class = ErrorClass(status_code, error_type)
+    register_error_classifier(
+        provider, lambda exc: error_class if regex.search(str(exc)) else None
+    )
+
+
+def unregister_error_classifiers(provider: str) -> None:
+    _classifiers.pop(provider, None)
+
+
+def classify_error(exc: BaseException) -> ErrorClass | None:
+    """Map a model-provider failure to a response, or ``None`` for a plain 500.
+
+    Registered provider classifiers are consulted first. Otherwise our own
+    ``ValueError``s are the caller's fault (400) and ``RuntimeError``s mean the
+    backend is unavailable (503).
+    """
+    for classifiers in list(_classifiers.values()):
+        for classifier in classifiers:
+            if (error_class := classifier(exc)) is not None:
+                return error_class
+    if isinstance(exc, ValueError):
+        return ErrorClass(status.HTTP_400_BAD_REQUEST, "invalid_request_error")
+    if isinstance(exc, RuntimeError):
+        return ErrorClass(status.HTTP_503_SERVICE_UNAVAILABLE, "server_error")
+    return None
+
+
+# Anthropic API error types, as they appear in Claude SDK/CLI error messages.
+_ANTHROPIC_ERROR_PATTERNS = (
+    (r"\brate_limit_error\b", status.HTTP_429_TOO_MANY_REQUESTS, "rate_limit_error"),
+    (r"\boverloaded_error\b", status.HTTP_503_SERVICE_UNAVAILABLE, "server_error"),
+    (
+        r"\brequest_too_large\b|\bprompt is too long\b",
+        status.HTTP_413_REQUEST_ENTITY_TOO_LARGE,
+        "invalid_request_error",
+    ),
+    # Our credentials were rejected upstream; not the caller's to fix.
+    (r"\bauthentication_error\b|\bpermission_error\b", status.HTTP_502_BAD_GATEWAY, "server_error"),
+)
+
+for pattern, status_code, error_type in _ANTHROPIC_ERROR_PATTERNS:
+    register_error_pattern("anthropic", pattern, status_code=status_code, error_type=error_type)
+register_error_pattern(
+    "vertex",
+    r"\bRESOURCE_EXHAUSTED\b",
+    status_code=status.HTTP_429_TOO_MANY_REQUESTS,
+    error_type="rate_limit_error",
+)
+
+
+def register_error_handlers(app: FastAPI) -> None:
+    """Install handlers that honour the ``error_response_format`` setting."""
+    app.add_exception_handler(StarletteHTTPException, _handle_http_exception)
+    app.add_exception_handler(RequestValidationError, _handle_validation_error)
+
+
+def problem_response(
+    instance: str,
+    status_code: int,
+    detail: Any,
+    *,
+    headers: dict[str, str] | None = None,
+    **extensions: Any,
+) -> JSONResponse:
+    """Build an RFC 7807 Problem Details response."""
+    base_uri = getattr(settings, "problem_type_base_uri", None)
+    try:
+        title = HTTPStatus(status_code).phrase
+    except ValueError:
+        title = "Error"
+    body: dict[str, Any] = {
+        "type": f"{base_uri.rstrip('/')}/{status_code}" if base_uri else "about:blank",
+        "title": title,
+        "status": status_code,
+        "instance": instance,
+        **extensions,
+    }
+    if detail is not None:
+        body["detail"] = detail
+    return JSONResponse(
+        body, status_code=status_code, headers=headers, media_type=PROBLEM_MEDIA_TYPE
+    )
+
+
+def problem_details_enabled() -> bool:
+    return getattr(settings, "error_response_format", "default") == "problem"
+
+
+def redact_error_detail(detail: Any) -> Any:
+    """Scrub credentials from an error detail before it is sent to the caller.
+
+    Uses the log redaction rules (``log_redact_keys``, ``log_redact_patterns``)
+    so upstream messages echoing tokens or credentialed URLs never reach a
+    response; emails are left alone since callers often need to see them.
+    """
+    return RedactionFilter(
+        (*DEFAULT_REDACT_KEYS, *getattr(settings, "log_redact_keys", ())),
+        mask_emails=False,
+        patterns=getattr(settings, "log_redact_patterns", ()),
+    ).redact(detail)
+
+
+async def _handle_http_exception(request: Request, exc: StarletteHTTPException) -> Response:
+    detail = redact_error_detail(exc.detail)
+    extensions: dict[str, Any] = {}
+    if isinstance(exc, FieldValidationError):
+        extensions["field_errors"] = redact_error_detail(exc.field_errors)
+    if problem_details_enabled():
+        return problem_response(
+            request.url.path, exc.status_code, detail, headers=exc.headers, **extensions
+        )
+    if extensions:
+        return JSONResponse(
+            {"detail": detail, **extensions}, status_code=exc.status_code, headers=exc.headers
+        )
+    return await http_exception_handler(
+        request, StarletteHTTPException(exc.status_code, detail=detail, headers=exc.headers)
+    )
+
+
+async def _handle_validation_error(request: Request, exc: RequestValidationError) -> Response:
+    # The errors echo the offending input, which may be a credential.
+    errors = redact_error_detail(jsonable_encoder(exc.errors()))
+    if not problem_details_enabled():
+        return JSONResponse({"detail": errors}, status_code=status.HTTP_422_UNPROCESSABLE_ENTITY)
+    return problem_response(
+        request.url.path,
+        status.HTTP_422_UNPROCESSABLE_ENTITY,
+        "Request validation failed",
+        errors=errors,
+    )
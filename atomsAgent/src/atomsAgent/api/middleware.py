@@ -0,0 +1,156 @@
+from __future__ import annotations
+
+import logging
+import time
+import traceback
+import uuid
+from urllib.parse import parse_qs
+
+from fastapi.responses import JSONResponse
+from starlette.datastructures import MutableHeaders
+from starlette.types import ASGIApp, Message, Receive, Scope, Send
+
+from atomsAgent.api.errors import problem_details_enabled, problem_response, redact_error_detail
+from atomsAgent.config import settings
+from atomsAgent.utils.log_context import bind_log_context, get_log_context, reset_log_context
+
+REQUEST_ID_HEADER = "x-request-id"
+
+logger = logging.getLogger(__name__)
+access_logger = logging.getLogger("atomsAgent.access")
+
+
+class LogContextMiddleware:
+    """Bind request_id (and org/user/session query params) to the log context.
+
+    Implemented as plain ASGI rather than ``BaseHTTPMiddleware`` so the route
+    runs in the same context and anything it binds is visible to later logs.
+    The request ID is taken from ``X-Request-ID`` when supplied and echoed back.
+    """
+
+    def __init__(self, app: ASGIApp) -> None:
+        self.app = app
+
+    async def __call__(self, scope: Scope, receive: Receive, send: Send) -> None:
+        if scope["type"] != "http":
+            await self.app(scope, receive, send)
+            return
+
+        headers = dict(scope.get("headers") or [])
+        request_id = (
+            headers.get(REQUEST_ID_HEADER.encode(), b"").decode("latin-1") or uuid.uuid4().hex
+        )
+        query = parse_qs(scope.get("query_string", b"").decode("latin-1"))
+        token = bind_log_context(
+            request_id=request_id,
+            org_id=_first(query, "organization_id"),
+            user_id=_first(query, "user_id"),
+            session_id=_first(query, "session_id"),
+        )
+
+        async def send_with_request_id(message: Message) -> None:
+            if message["type"] == "http.response.start":
+                MutableHeaders(scope=message)[REQUEST_ID_HEADER] = request_id
+            await send(message)
+
+        try:
+            await self.app(scope, receive, send_with_request_id)
+        finally:
+            reset_log_context(token)
+
+
+class AccessLogMiddleware:
+    """Emit one ``atomsAgent.access`` record per HTTP request.
+
+    The record's ``http`` attribute holds method, route template, status,
+    latency and response size; request/org/user IDs come from the log context,
+    so this must sit inside ``LogContextMiddleware`` and outside
+    ``ErrorRecoveryMiddleware`` to see the final status of failed requests.
+    """
+
+    def __init__(self, app: ASGIApp) -> None:
+        self.app = app
+
+    async def __call__(self, scope: Scope, receive: Receive, send: Send) -> None:
+        if scope["type"] != "http":
+            await self.app(scope, receive, send)
+            return
+
+        started = time.perf_counter()
+        status_code = 500
+        response_bytes = 0
+
+        async def recording_send(message: Message) -> None:
+            nonlocal status_code, response_bytes
+            if message["type"] == "http.response.start":
+                status_code = message["status"]
+            elif message["type"] == "http.response.body":
+                response_bytes += len(message.get("body", b""))
+            await send(message)
+
+        try:
+            await self.app(scope, receive, recording_send)
+        finally:
+            route = scope.get("route")
+            details = {
+                "method": scope.get("method"),
+                "path": getattr(route, "path", None) or scope.get("path"),
+                "status": status_code,
+                "latency_ms": round((time.perf_counter() - started) * 1000, 2),
+                "bytes": response_bytes,
+            }
+            access_logger.info(
+                "%(method)s %(path)s %(status)s %(latency_ms)sms %(bytes)sB",
+                details,
+                extra={"http": details},
+            )
+
+
+class ErrorRecoveryMiddleware:
+    """Turn unhandled exceptions into a structured 500 that carries the request ID.
+
+    Must sit inside ``LogContextMiddleware`` so the traceback is logged with the
+    request context still bound. Errors raised after the response has started
+    (mid-stream) can only be logged; the exception is then re-raised. With
+    ``error_include_traceback`` the 500 body also carries the traceback lines.
+    """
+
+    def __init__(self, app: ASGIApp) -> None:
+        self.app = app
+
+    async def __call__(self, scope: Scope, receive: Receive, send: Send) -> None:
+        if scope["type"] != "http":
+            await self.app(scope, receive, send)
+            return
+
+        response_started = False
+
+        async def tracking_send(message: Message) -> None:
+            nonlocal response_started
+            if message["type"] == "http.response.start":
+                response_started = True
+            await send(message)
+
+        try:
+            await self.app(scope, receive, tracking_send)
+        except Exception:
+            logger.exception("Unhandled error in %s %s", scope.get("method"), scope.get("path"))
+            if response_started:
+                raise
+            extensions = {"request_id": get_log_context().get("request_id")}
+            if getattr(settings, "error_include_traceback", False):
+                extensions["traceback"] = redact_error_detail(traceback.format_exc().splitlines())
+            if problem_details_enabled():
+                response = problem_response(
+                    scope.get("path", ""), 500, "Internal server error", **extensions
+                )
+            else:
+                response = JSONResponse(
+                    {"detail": "Internal server error", **extensions}, status_code=500
+                )
+            await response(scope, receive, send)
+
+
+def _first(query: dict[str, list[str]], key: str) -> str | None:
+    values = query.get(key)
+    return values[0] if values else None
@@ -1,15 +1,63 @@
+import asyncio
+import json
+from collections.abc import AsyncIterator
+from datetime import datetime, timedelta, timezone
+from typing import Any, Literal
 from uuid import UUID
 
 from fastapi import APIRouter, Depends, HTTPException, Path, Query, status
+from fastapi.responses import StreamingResponse
 
-from atomsAgent.dependencies import get_mcp_service
+from atomsAgent.api.auth import ensure_platform_admin, platform_admin
+from atomsAgent.api.errors import FieldValidationError
+from atomsAgent.dependencies import (
+    get_mcp_breakers,
+    get_mcp_catalog,
+    get_mcp_connection_manager,
+    get_mcp_health_monitor,
+    get_mcp_service,
+    get_mcp_tool_policy_service,
+    get_mcp_tool_service,
+    get_mcp_usage_meter,
+)
 from atomsAgent.schemas.mcp import (
+    AuthTypeLiteral,
+    MCPCatalogResponse,
+    MCPCircuitBreakerInfo,
     MCPConfiguration,
+    MCPConfigVersionListResponse,
     MCPCreateRequest,
+    MCPDrainRequest,
+    MCPDrainResponse,
+    MCPHealthResponse,
     MCPListResponse,
+    MCPPromptListResponse,
+    MCPPromptRenderRequest,
+    MCPPromptRenderResponse,
+    MCPResourceListResponse,
+    MCPResourceReadResponse,
+    MCPScopeType,
+    MCPSortField,
+    MCPTemplateCreateRequest,
+    MCPToolCallRequest,
+    MCPToolCallResponse,
+    MCPToolListResponse,
+    MCPToolPolicy,
     MCPUpdateRequest,
+    MCPUsageGroupBy,
+    MCPUsageResponse,
+)
+from atomsAgent.services import (
+    MCPCatalog,
+    MCPCircuitBreakers,
+    MCPConnectionManager,
+    MCPHealthMonitor,
+    MCPRegistryService,
+    MCPToolError,
+    MCPToolPolicyService,
+    MCPToolService,
+    MCPUsageMeter,
 )
-from atomsAgent.services import MCPRegistryService
 
 router = APIRouter()
 
@@ -19,41 +67,432 @@ async def list_mcp_servers(
     organization_id: UUID = Query(..., description="Organization context"),
     user_id: UUID | None = Query(None, description="User context if applicable"),
     include_platform: bool = Query(True, description="Include platform-level MCPs"),
+    scope: MCPScopeType | None = Query(None, description="Filter by scope type"),
+    auth_type: AuthTypeLiteral | None = Query(None, description="Filter by auth type"),
+    name_prefix: str | None = Query(None, description="Case-insensitive name prefix"),
+    sort: MCPSortField | None = Query(None, description="Sort field"),
+    order: Literal["asc", "desc"] = Query("asc", description="Sort direction"),
+    limit: int = Query(100, ge=1, le=500),
+    offset: int = Query(0, ge=0),
     service: MCPRegistryService = Depends(get_mcp_service),
 ) -> MCPListResponse:
     return await service.list(
         organization_id=organization_id,
         user_id=user_id,
         include_platform=include_platform,
+        scope=scope,
+        auth_type=auth_type,
+        name_prefix=name_prefix,
+        sort=sort,
+        descending=order == "desc",
+        limit=limit,
+        offset=offset,
     )
 
 
 @router.post("", response_model=MCPConfiguration, status_code=status.HTTP_201_CREATED)
 async def create_mcp_server(
     payload: MCPCreateRequest,
+    is_platform_admin: bool = Depends(platform_admin),
     service: MCPRegistryService = Depends(get_mcp_service),
 ) -> MCPConfiguration:
     try:
-        return await service.create(payload)
+        return await service.create(payload, platform_admin=is_platform_admin)
     except ValueError as exc:  # invalid scope
         raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+    except PermissionError as exc:
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail=str(exc)) from exc
+
+
+@router.get("/catalog", response_model=MCPCatalogResponse)
+async def list_mcp_catalog(
+    catalog: MCPCatalog = Depends(get_mcp_catalog),
+) -> MCPCatalogResponse:
+    return catalog.list()
+
+
+@router.post(
+    "/catalog/{template_key}",
+    response_model=MCPConfiguration,
+    status_code=status.HTTP_201_CREATED,
+)
+async def create_mcp_server_from_template(
+    payload: MCPTemplateCreateRequest,
+    template_key: str = Path(..., description="Catalog template key"),
+    is_platform_admin: bool = Depends(platform_admin),
+    catalog: MCPCatalog = Depends(get_mcp_catalog),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> MCPConfiguration:
+    try:
+        request = catalog.build_create_request(template_key, payload)
+    except KeyError as exc:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=exc.args[0]) from exc
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+    try:
+        return await service.create(request, platform_admin=is_platform_admin)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+    except PermissionError as exc:
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail=str(exc)) from exc
+
+
+@router.get("/policy", response_model=MCPToolPolicy)
+async def get_mcp_tool_policy(
+    organization_id: UUID = Query(..., description="Organization whose policy to read"),
+    is_platform_admin: bool = Depends(platform_admin),
+    policies: MCPToolPolicyService = Depends(get_mcp_tool_policy_service),
+) -> MCPToolPolicy:
+    ensure_platform_admin(is_platform_admin)
+    return await policies.get_policy(organization_id)
+
+
+@router.put("/policy", response_model=MCPToolPolicy)
+async def update_mcp_tool_policy(
+    payload: MCPToolPolicy,
+    organization_id: UUID = Query(..., description="Organization whose policy to replace"),
+    is_platform_admin: bool = Depends(platform_admin),
+    policies: MCPToolPolicyService = Depends(get_mcp_tool_policy_service),
+) -> MCPToolPolicy:
+    ensure_platform_admin(is_platform_admin)
+    try:
+        return await policies.set_policy(organization_id, payload)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=str(exc)) from exc
+
+
+@router.delete("/policy", status_code=status.HTTP_204_NO_CONTENT)
+async def delete_mcp_tool_policy(
+    organization_id: UUID = Query(..., description="Organization whose policy to remove"),
+    is_platform_admin: bool = Depends(platform_admin),
+    policies: MCPToolPolicyService = Depends(get_mcp_tool_policy_service),
+) -> None:
+    ensure_platform_admin(is_platform_admin)
+    try:
+        await policies.clear_policy(organization_id)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=str(exc)) from exc
+
+
+@router.get("/usage", response_model=MCPUsageResponse)
+async def get_mcp_usage(
+    organization_id: UUID | None = Query(
+        None, description="Organization to report on; omit for all (platform admins only)"
+    ),
+    group_by: MCPUsageGroupBy = Query("day", description="Aggregate by day, tool or organization"),
+    since: datetime | None = Query(None, description="Range start; defaults to 30 days ago"),
+    until: datetime | None = Query(None, description="Range end (exclusive); defaults to now"),
+    mcp_id: UUID | None = Query(None, description="Only calls to this MCP configuration"),
+    is_platform_admin: bool = Depends(platform_admin),
+    usage: MCPUsageMeter = Depends(get_mcp_usage_meter),
+) -> MCPUsageResponse:
+    if organization_id is None and not is_platform_admin:
+        raise HTTPException(
+            status_code=status.HTTP_403_FORBIDDEN,
+            detail="Usage across organizations is only available to platform admins",
+        )
+    until = _as_utc(until) if until is not None else datetime.now(timezone.utc)
+    since = _as_utc(since) if since is not None else until - timedelta(days=30)
+    if since >= until:
+        raise FieldValidationError(
+            {"since": "must be before until"}, detail="since must be before until"
+        )
+    return await usage.summarize(
+        group_by=group_by,
+        since=since,
+        until=until,
+        organization_id=organization_id,
+        mcp_id=mcp_id,
+    )
 
 
 @router.put("/{mcp_id}", response_model=MCPConfiguration)
 async def update_mcp_server(
     payload: MCPUpdateRequest,
     mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    is_platform_admin: bool = Depends(platform_admin),
     service: MCPRegistryService = Depends(get_mcp_service),
 ) -> MCPConfiguration:
     try:
-        return await service.update(mcp_id, payload)
+        return await service.update(mcp_id, payload, platform_admin=is_platform_admin)
     except ValueError as exc:
         raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+    except PermissionError as exc:
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail=str(exc)) from exc
 
 
 @router.delete("/{mcp_id}", status_code=status.HTTP_204_NO_CONTENT)
 async def delete_mcp_server(
     mcp_id: UUID,
+    is_platform_admin: bool = Depends(platform_admin),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> None:
+    try:
+        await service.delete(mcp_id, platform_admin=is_platform_admin)
+    except PermissionError as exc:
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail=str(exc)) from exc
+
+
+@router.get("/{mcp_id}/versions", response_model=MCPConfigVersionListResponse)
+async def list_mcp_server_versions(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID | None = Query(None, description="Organization context"),
+    is_platform_admin: bool = Depends(platform_admin),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> MCPConfigVersionListResponse:
+    try:
+        items = await service.list_versions(
+            mcp_id, organization_id=organization_id, platform_admin=is_platform_admin
+        )
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=str(exc)) from exc
+    except PermissionError as exc:
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail=str(exc)) from exc
+    return MCPConfigVersionListResponse(items=items)
+
+
+@router.post("/{mcp_id}/versions/{version}/rollback", response_model=MCPConfiguration)
+async def rollback_mcp_server(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    version: int = Path(..., ge=1, description="Version to restore"),
+    is_platform_admin: bool = Depends(platform_admin),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> MCPConfiguration:
+    try:
+        return await service.rollback(mcp_id, version, platform_admin=is_platform_admin)
+    except KeyError as exc:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=exc.args[0]) from exc
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+    except PermissionError as exc:
+        raise HTTPException(status_code=status.HTTP_403_FORBIDDEN, detail=str(exc)) from exc
+
+
+@router.post("/{mcp_id}/drain", response_model=MCPDrainResponse)
+async def drain_mcp_server(
+    payload: MCPDrainRequest | None = None,
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    is_platform_admin: bool = Depends(platform_admin),
+    connections: MCPConnectionManager = Depends(get_mcp_connection_manager),
+) -> MCPDrainResponse:
+    ensure_platform_admin(is_platform_admin)
+    timeout_seconds = (payload or MCPDrainRequest()).timeout_seconds
+    try:
+        disconnected = await connections.drain(str(mcp_id), timeout_seconds=timeout_seconds)
+    except asyncio.TimeoutError as exc:
+        raise HTTPException(
+            status_code=status.HTTP_409_CONFLICT,
+            detail=f"In-flight MCP calls did not finish within {timeout_seconds:g}s",
+        ) from exc
+    return MCPDrainResponse(id=mcp_id, disconnected=disconnected)
+
+
+@router.post("/{mcp_id}/opt-out", status_code=status.HTTP_204_NO_CONTENT)
+async def opt_out_of_default_mcp(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    user_id: UUID = Query(..., description="User opting out of the org default"),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> None:
+    try:
+        await service.opt_out_of_default(mcp_id, user_id)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+
+
+@router.delete("/{mcp_id}/opt-out", status_code=status.HTTP_204_NO_CONTENT)
+async def opt_in_to_default_mcp(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    user_id: UUID = Query(..., description="User re-enabling the org default"),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> None:
+    try:
+        await service.opt_in_to_default(mcp_id, user_id)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+
+
+@router.post("/{mcp_id}/organization-opt-out", status_code=status.HTTP_204_NO_CONTENT)
+async def opt_out_of_platform_mcp(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID = Query(..., description="Organization opting out of the platform MCP"),
+    service: MCPRegistryService = Depends(get_mcp_service),
+) -> None:
+    try:
+        await service.opt_out_of_platform(mcp_id, organization_id)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+
+
+@router.delete("/{mcp_id}/organization-opt-out", status_code=status.HTTP_204_NO_CONTENT)
+async def opt_in_to_platform_mcp(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID = Query(..., description="Organization re-enabling the platform MCP"),
     service: MCPRegistryService = Depends(get_mcp_service),
 ) -> None:
-    await service.delete(mcp_id)
+    try:
+        await service.opt_in_to_platform(mcp_id, organization_id)
+    except ValueError as exc:
+        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
+
+
+@router.get("/{mcp_id}/tools", response_model=MCPToolListResponse)
+async def list_mcp_tools(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> MCPToolListResponse:
+    try:
+        items = await tools.list_tools(mcp_id, organization_id=organization_id)
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+    return MCPToolListResponse(items=items)
+
+
+@router.post("/{mcp_id}/tools/refresh", response_model=MCPToolListResponse)
+async def refresh_mcp_tools(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> MCPToolListResponse:
+    """Bypass the tool cache and reload tools/list from the server."""
+    try:
+        items = await tools.list_tools(mcp_id, organization_id=organization_id, refresh=True)
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+    return MCPToolListResponse(items=items)
+
+
+@router.post("/{mcp_id}/tools/{tool_name}/call", response_model=MCPToolCallResponse)
+async def call_mcp_tool(
+    payload: MCPToolCallRequest,
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    tool_name: str = Path(..., description="Tool name as reported by tools/list"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    user_id: UUID | None = Query(None, description="User making the call, for usage metering"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> StreamingResponse | MCPToolCallResponse:
+    try:
+        if not payload.stream:
+            return await tools.call_tool(
+                mcp_id,
+                tool_name,
+                payload.arguments,
+                organization_id=organization_id,
+                user_id=user_id,
+            )
+        events = await tools.stream_tool(
+            mcp_id, tool_name, payload.arguments, organization_id=organization_id, user_id=user_id
+        )
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+
+    async def event_stream() -> AsyncIterator[str]:
+        async for event, data in events:
+            yield f"event: {event}\ndata: {json.dumps(data)}\n\n"
+
+    return StreamingResponse(event_stream(), media_type="text/event-stream")
+
+
+@router.get("/{mcp_id}/resources", response_model=MCPResourceListResponse)
+async def list_mcp_resources(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> MCPResourceListResponse:
+    try:
+        items = await tools.list_resources(mcp_id, organization_id=organization_id)
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+    return MCPResourceListResponse(items=items)
+
+
+@router.get("/{mcp_id}/resources/read", response_model=MCPResourceReadResponse)
+async def read_mcp_resource(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    uri: str = Query(..., description="Resource URI as reported by resources/list"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> MCPResourceReadResponse:
+    try:
+        return await tools.read_resource(mcp_id, uri, organization_id=organization_id)
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+
+
+@router.get("/{mcp_id}/prompts", response_model=MCPPromptListResponse)
+async def list_mcp_prompts(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> MCPPromptListResponse:
+    try:
+        items = await tools.list_prompts(mcp_id, organization_id=organization_id)
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+    return MCPPromptListResponse(items=items)
+
+
+@router.post("/{mcp_id}/prompts/{prompt_name}/render", response_model=MCPPromptRenderResponse)
+async def render_mcp_prompt(
+    payload: MCPPromptRenderRequest,
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    prompt_name: str = Path(..., description="Prompt name as reported by prompts/list"),
+    organization_id: UUID = Query(..., description="Organization context"),
+    tools: MCPToolService = Depends(get_mcp_tool_service),
+) -> MCPPromptRenderResponse:
+    try:
+        return await tools.render_prompt(
+            mcp_id, prompt_name, payload.arguments, organization_id=organization_id
+        )
+    except MCPToolError as exc:
+        raise _tool_http_error(exc) from exc
+
+
+@router.get("/{mcp_id}/health", response_model=MCPHealthResponse)
+async def get_mcp_health(
+    mcp_id: UUID = Path(..., description="MCP configuration identifier"),
+    monitor: MCPHealthMonitor = Depends(get_mcp_health_monitor),
+    breakers: MCPCircuitBreakers = Depends(get_mcp_breakers),
+) -> MCPHealthResponse:
+    breaker_info = {
+        operation: MCPCircuitBreakerInfo(
+            state=breaker.state,
+            consecutive_failures=breaker.consecutive_failures,
+            total_failures=breaker.total_failures,
+            total_successes=breaker.total_successes,
+            rejected=breaker.rejected,
+            opened_at=_timestamp(breaker.opened_at),
+        )
+        for operation, breaker in breakers.for_config(str(mcp_id)).items()
+    }
+    health = await monitor.fetch(str(mcp_id))
+    if health is None:
+        return MCPHealthResponse(id=mcp_id, breakers=breaker_info)
+    return MCPHealthResponse(
+        id=mcp_id,
+        name=health.name,
+        status=health.status,
+        last_checked_at=_timestamp(health.last_checked_at),
+        last_success_at=_timestamp(health.last_success_at),
+        last_error=health.last_error,
+        last_error_at=_timestamp(health.last_error_at),
+        latency_ms=health.latency_ms,
+        consecutive_failures=health.consecutive_failures,
+        breakers=breaker_info,
+    )
+
+
+def _timestamp(value: float | None) -> datetime | None:
+    return datetime.fromtimestamp(value, tz=timezone.utc) if value is not None else None
+
+
+def _as_utc(value: datetime) -> datetime:
+    # Naive query timestamps are taken as UTC, like the stored created_at values.
+    return value if value.tzinfo is not None else value.replace(tzinfo=timezone.utc)
+
+
+def _tool_http_error(exc: MCPToolError) -> HTTPException:
+    detail: dict[str, Any] = {"code": exc.code, "message": exc.detail}
+    if exc.violations:
+        detail["violations"] = exc.violations
+    return HTTPException(status_code=exc.status, detail=detail)
@@ -8,10 +8,14 @@ from typing import Any, Optional
 
 from fastapi import APIRouter, Depends, Header, HTTPException, status
 from fastapi.responses import StreamingResponse
+from starlette.background import BackgroundTask
 
+from atomsAgent.api.errors import FieldValidationError, classify_error, redact_error_detail
 from atomsAgent.dependencies import (
     get_chat_history_service,
     get_claude_client,
+    get_concurrency_limiter,
+    get_mcp_tool_policy_service,
     get_prompt_orchestrator,
     get_vertex_model_service,
 )
@@ -27,11 +31,17 @@ from atomsAgent.schemas.openai import (
 from atomsAgent.services import (
     ClaudeAgentClient,
     CompletionChunk,
+    ConcurrencyLimiter,
+    ConcurrencyLimitExceeded,
+    MCPToolPolicyService,
     PromptOrchestrator,
     VertexModelService,
     default_session_id,
 )
 from atomsAgent.services.chat_history import ChatHistoryService
+from atomsAgent.services.concurrency import ConcurrencySlot
+from atomsAgent.services.mcp_policy import restrict_chat_servers
+from atomsAgent.utils.log_context import bind_log_context
 
 router = APIRouter()
 
@@ -43,18 +53,81 @@ async def create_chat_completion(
     claude_client: ClaudeAgentClient = Depends(get_claude_client),
     prompt_orchestrator: PromptOrchestrator = Depends(get_prompt_orchestrator),
     history_service: ChatHistoryService = Depends(get_chat_history_service),
+    concurrency_limiter: ConcurrencyLimiter = Depends(get_concurrency_limiter),
+    tool_policies: MCPToolPolicyService = Depends(get_mcp_tool_policy_service),
 ) -> StreamingResponse | ChatCompletionResponse:
+    metadata: dict[str, Any] = request.metadata or {}
+    organization_id = metadata.get("organization_id")
+    user_id = metadata.get("user_id")
+
+    field_errors: dict[str, str] = {}
+    if not request.messages:
+        field_errors["messages"] = "messages array must include at least one message"
+    if not request.model:
+        field_errors["model"] = (
+            "model field is required - fetch available models from /v1/models endpoint"
+        )
+    if field_errors:
+        raise FieldValidationError(field_errors, detail="; ".join(field_errors.values()))
+
+    # Take the slot before any I/O so a rejected request leaves no history behind.
+    try:
+        slot = concurrency_limiter.acquire(user_id=user_id, organization_id=organization_id)
+    except ConcurrencyLimitExceeded as exc:
+        raise HTTPException(
+            status_code=status.HTTP_429_TOO_MANY_REQUESTS,
+            detail=str(exc),
+            headers={"Retry-After": "1"},
+        ) from exc
+
+    try:
+        return await _complete(
+            request,
+            slot=slot,
+            authorization=authorization,
+            claude_client=claude_client,
+            prompt_orchestrator=prompt_orchestrator,
+            history_service=history_service,
+            tool_policies=tool_policies,
+        )
+    except BaseException:
+        slot.release()
+        raise
+
+
+async def _complete(
+    request: ChatCompletionRequest,
+    *,
+    slot: ConcurrencySlot,
+    authorization: str | None,
+    claude_client: ClaudeAgentClient,
+    prompt_orchestrator: PromptOrchestrator,
+    history_service: ChatHistoryService,
+    tool_policies: MCPToolPolicyService,
+) -> StreamingResponse | ChatCompletionResponse:
+    """Run a completion holding ``slot``, which is released once the response is done."""
     metadata: dict[str, Any] = request.metadata or {}
     session_id = metadata.get("session_id") or default_session_id()
     workflow = metadata.get("workflow")
     organization_id = metadata.get("organization_id")
-    user_id = metadata.get("user_id")
-    if user_id is None:
-        user_id = ""  # Provide default value to avoid type errors
+    user_id = metadata.get("user_id") or ""
     variables = metadata.get("variables")
     allowed_tools: list[str] | None = metadata.get("allowed_tools")
     setting_sources: list[str] | None = metadata.get("setting_sources")
     mcp_servers: dict[str, Any] | None = metadata.get("mcp_servers")
+    resume_token: str | None = metadata.get("resume_token")
+    bind_log_context(org_id=organization_id, user_id=user_id, session_id=session_id)
+
+    if resume_token:
+        try:
+            claude_client.check_resume_token(
+                session_id,
+                resume_token,
+                user_id=user_id or None,
+                organization_id=organization_id,
+            )
+        except ValueError as exc:
+            raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
 
     # Extract user token from Authorization header for internal MCPs
     user_token = None
@@ -67,26 +140,20 @@ async def create_chat_completion(
             user_id=user_id,
             org_id=organization_id,
             user_token=user_token,
+            session_id=session_id,
         )
 
+    disallowed_tools: list[str] = []
+    if mcp_servers and organization_id:
+        tool_policy = await tool_policies.get_policy(organization_id)
+        mcp_servers, disallowed_tools = restrict_chat_servers(tool_policy, mcp_servers)
+
     system_prompt = request.system_prompt or await prompt_orchestrator.compose_prompt(
         organization_id=organization_id,
         user_id=user_id,
         workflow=workflow,
         variables=variables,
     )
-    if not request.messages:
-        raise HTTPException(
-            status_code=status.HTTP_400_BAD_REQUEST,
-            detail="messages array must include at least one message",
-        )
-
-    if not request.model:
-        raise HTTPException(
-            status_code=status.HTTP_400_BAD_REQUEST,
-            detail="model field is required - fetch available models from /v1/models endpoint",
-        )
-
     model = request.model
     messages = [msg.model_dump() for msg in request.messages]
     temperature = request.temperature or 1.0
@@ -135,10 +202,14 @@ async def create_chat_completion(
                     system_prompt=system_prompt,
                     setting_sources=setting_sources,
                     allowed_tools=allowed_tools,
+                    disallowed_tools=disallowed_tools,
                     mcp_servers=mcp_servers,
                     user_token=user_token,
                     user_identifier=request.user,
+                    user_id=user_id or None,
+                    organization_id=organization_id,
                     top_p=request.top_p,
+                    resume_token=resume_token,
                 ):
                     for payload in _serialize_chunk(
                         chunk=chunk,
@@ -158,13 +229,14 @@ async def create_chat_completion(
                             completion_tokens=chunk.usage.completion_tokens,
                             total_tokens=chunk.usage.total_tokens,
                         )
-            except (ValueError, RuntimeError) as exc:
+            except Exception as exc:
+                error_class = classify_error(exc)
+                if error_class is None:
+                    raise
                 error_payload = {
                     "error": {
-                        "message": str(exc),
-                        "type": "invalid_request_error"
-                        if isinstance(exc, ValueError)
-                        else "server_error",
+                        "message": redact_error_detail(str(exc)),
+                        "type": error_class.error_type,
                     }
                 }
                 yield f"data: {json.dumps(error_payload)}\n\n"
@@ -185,7 +257,20 @@ async def create_chat_completion(
                     total_tokens=usage.total_tokens,
                 )
 
-        return StreamingResponse(event_stream(), media_type="text/event-stream")
+        async def release_when_done() -> AsyncGenerator[str, None]:
+            try:
+                async for payload in event_stream():
+                    yield payload
+            finally:
+                slot.release()
+
+        # The background task also runs when the client disconnects before the
+        # stream starts, in which case the generator's finally never does.
+        return StreamingResponse(
+            release_when_done(),
+            media_type="text/event-stream",
+            background=BackgroundTask(slot.release),
+        )
 
     try:
         result = await claude_client.complete(
@@ -197,17 +282,22 @@ async def create_chat_completion(
             system_prompt=system_prompt,
             setting_sources=setting_sources,
             allowed_tools=allowed_tools,
+            disallowed_tools=disallowed_tools,
             mcp_servers=mcp_servers,
             user_token=user_token,
             user_identifier=request.user,
+            user_id=user_id or None,
+            organization_id=organization_id,
             top_p=request.top_p,
+            resume_token=resume_token,
         )
-    except ValueError as exc:
-        raise HTTPException(status_code=status.HTTP_400_BAD_REQUEST, detail=str(exc)) from exc
-    except RuntimeError as exc:
-        raise HTTPException(
-            status_code=status.HTTP_503_SERVICE_UNAVAILABLE, detail=str(exc)
-        ) from exc
+    except Exception as exc:
+        error_class = classify_error(exc)
+        if error_class is None:
+            raise
+        raise HTTPException(status_code=error_class.status_code, detail=str(exc)) from exc
+    finally:
+        slot.release()
 
     if history_enabled:
         await history_service.record_assistant_message(
@@ -237,6 +327,7 @@ async def create_chat_completion(
             total_tokens=result.usage.total_tokens,
         ),
         system_fingerprint=session_id,
+        resume_token=result.resume_token,
     )
     return response
 
@@ -279,6 +370,16 @@ def _serialize_chunk(
         }
         payloads.append(f"data: {json.dumps(payload)}\n\n")
 
+    if chunk.termination_reason:
+        notice = {
+            "error": {
+                "message": f"Session terminated by administrator: {chunk.termination_reason}",
+                "type": "session_terminated",
+            },
+            "system_fingerprint": session_id,
+        }
+        payloads.append(f"data: {json.dumps(notice)}\n\n")
+
     if chunk.done:
         done_payload: dict[str, Any] = {
             "id": stream_id,
@@ -300,6 +401,8 @@ def _serialize_chunk(
                 "completion_tokens": chunk.usage.completion_tokens,
                 "total_tokens": chunk.usage.total_tokens,
             }
+        if chunk.resume_token:
+            done_payload["resume_token"] = chunk.resume_token
         payloads.append(f"data: {json.dumps(done_payload)}\n\n")
         payloads.append("data: [DONE]\n\n")
 
@@ -1,18 +1,65 @@
 from __future__ import annotations
 
+import asyncio
+import logging
+from dataclasses import asdict
+from datetime import datetime, timezone
+
 from fastapi import APIRouter, Depends, HTTPException, Path, Query, status
 
-from atomsAgent.dependencies import get_platform_service
+from atomsAgent.api.auth import PLATFORM_ADMIN_ACTOR, ensure_platform_admin, platform_admin
+from atomsAgent.api.errors import FieldValidationError
+from atomsAgent.dependencies import (
+    get_platform_service,
+    get_sandbox_manager,
+    get_session_manager,
+)
 from atomsAgent.schemas.platform import (
     AddAdminRequest,
     AdminListResponse,
     AdminResponse,
+    AgentSessionInfo,
+    AgentSessionListResponse,
     AuditLogResponse,
+    CacheStatsInfo,
+    CacheStatsResponse,
+    LogLevelInfo,
+    LogLevelListResponse,
+    LogLevelUpdateRequest,
     PlatformStats,
+    TerminateSessionRequest,
+    TerminateSessionResponse,
 )
-from atomsAgent.services import PlatformService
+from atomsAgent.services import ClaudeSessionManager, PlatformService, SandboxManager
+from atomsAgent.utils.caching import cache_stats
+from atomsAgent.utils.log_audit import log_security_event, security_extra
+from atomsAgent.utils.log_sinks import log_levels, set_log_level
 
 router = APIRouter()
+logger = logging.getLogger(__name__)
+
+
+def _timestamp(value: float) -> datetime:
+    return datetime.fromtimestamp(value, tz=timezone.utc)
+
+
+def _to_session_info(session, *, workspace_bytes: int | None = None) -> AgentSessionInfo:
+    return AgentSessionInfo(
+        session_id=session.session_id,
+        user_id=session.config.user_id,
+        organization_id=session.config.organization_id,
+        model=session.config.model,
+        state=session.state,
+        created_at=_timestamp(session.created_at),
+        last_used_at=_timestamp(session.last_used),
+        idle_expires_at=_timestamp(session.idle_expires_at),
+        tool_usage_count=session.tool_usage_count,
+        permission_requests=len(session.permission_requests),
+        prompt_tokens=session.prompt_tokens,
+        completion_tokens=session.completion_tokens,
+        workspace_bytes=workspace_bytes,
+        mcp_servers=dict(session.mcp_status),
+    )
 
 
 @router.get("/stats", response_model=PlatformStats)
@@ -22,6 +69,42 @@ async def get_platform_stats(
     return await service.get_stats()
 
 
+@router.get("/caches", response_model=CacheStatsResponse)
+async def get_cache_stats(
+    is_platform_admin: bool = Depends(platform_admin),
+) -> CacheStatsResponse:
+    ensure_platform_admin(is_platform_admin)
+    return CacheStatsResponse(caches=[CacheStatsInfo(**asdict(stats)) for stats in cache_stats()])
+
+
+@router.get("/logging", response_model=LogLevelListResponse)
+async def get_log_levels(
+    is_platform_admin: bool = Depends(platform_admin),
+) -> LogLevelListResponse:
+    ensure_platform_admin(is_platform_admin)
+    return LogLevelListResponse(
+        loggers=[LogLevelInfo(logger=name, level=level) for name, level in log_levels().items()]
+    )
+
+
+@router.put("/logging/level", response_model=LogLevelInfo)
+async def update_log_level(
+    request: LogLevelUpdateRequest,
+    is_platform_admin: bool = Depends(platform_admin),
+) -> LogLevelInfo:
+    ensure_platform_admin(is_platform_admin)
+    set_log_level(request.logger, request.level)
+    log_security_event(
+        logger,
+        logging.INFO,
+        "Log level for %s set to %s",
+        request.logger,
+        request.level,
+        extra=security_extra("logging.set_level", "logger", request.logger, level=request.level),
+    )
+    return LogLevelInfo(logger=request.logger, level=request.level)
+
+
 @router.get("/admins", response_model=AdminListResponse)
 async def list_platform_admins(
     service: PlatformService = Depends(get_platform_service),
@@ -35,10 +118,23 @@ async def create_platform_admin(
     service: PlatformService = Depends(get_platform_service),
 ) -> AdminResponse:
     if not request.email or not request.workos_id:
-        raise HTTPException(
-            status_code=status.HTTP_400_BAD_REQUEST, detail="email and workos_id required"
+        raise FieldValidationError(
+            {
+                field: "required"
+                for field in ("email", "workos_id")
+                if not getattr(request, field)
+            },
+            detail="email and workos_id required",
         )
-    return await service.add_admin(request, created_by=request.workos_id)
+    response = await service.add_admin(request, created_by=request.workos_id)
+    log_security_event(
+        logger,
+        logging.INFO,
+        "Added platform admin %s",
+        request.email,
+        extra=security_extra("platform_admin.add", "platform_admin", request.email),
+    )
+    return response
 
 
 @router.delete("/admins/{email}", response_model=AdminResponse)
@@ -46,7 +142,15 @@ async def delete_platform_admin(
     email: str = Path(..., description="Admin email"),
     service: PlatformService = Depends(get_platform_service),
 ) -> AdminResponse:
-    return await service.remove_admin(email)
+    response = await service.remove_admin(email)
+    log_security_event(
+        logger,
+        logging.INFO,
+        "Removed platform admin %s",
+        email,
+        extra=security_extra("platform_admin.remove", "platform_admin", email),
+    )
+    return response
 
 
 @router.get("/audit", response_model=AuditLogResponse)
@@ -56,3 +160,68 @@ async def get_audit_logs(
     service: PlatformService = Depends(get_platform_service),
 ) -> AuditLogResponse:
     return await service.list_audit(limit=limit, offset=offset)
+
+
+@router.get("/sessions", response_model=AgentSessionListResponse)
+async def list_agent_sessions(
+    organization_id: str | None = Query(None, description="Filter by organization"),
+    user_id: str | None = Query(None, description="Filter by user"),
+    model: str | None = Query(None, description="Filter by model"),
+    state: str | None = Query(None, description="Filter by session state"),
+    is_platform_admin: bool = Depends(platform_admin),
+    session_manager: ClaudeSessionManager = Depends(get_session_manager),
+) -> AgentSessionListResponse:
+    ensure_platform_admin(is_platform_admin)
+    sessions = session_manager.list_sessions(
+        organization_id=organization_id, user_id=user_id, model=model
+    )
+    if state is not None:
+        sessions = [session for session in sessions if session.state == state]
+    items = [_to_session_info(session) for session in sessions]
+    return AgentSessionListResponse(sessions=items, count=len(items))
+
+
+@router.get("/sessions/{session_id}", response_model=AgentSessionInfo)
+async def get_agent_session(
+    session_id: str = Path(..., description="Agent session identifier"),
+    is_platform_admin: bool = Depends(platform_admin),
+    session_manager: ClaudeSessionManager = Depends(get_session_manager),
+    sandbox_manager: SandboxManager = Depends(get_sandbox_manager),
+) -> AgentSessionInfo:
+    ensure_platform_admin(is_platform_admin)
+    session = session_manager.find_session(session_id)
+    if session is None:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail="session not found")
+    workspace_bytes = await asyncio.to_thread(
+        sandbox_manager.disk_usage, session.sandbox.sandbox_id
+    )
+    return _to_session_info(session, workspace_bytes=workspace_bytes)
+
+
+@router.post("/sessions/{session_id}/terminate", response_model=TerminateSessionResponse)
+async def terminate_agent_session(
+    request: TerminateSessionRequest,
+    session_id: str = Path(..., description="Agent session identifier"),
+    is_platform_admin: bool = Depends(platform_admin),
+    session_manager: ClaudeSessionManager = Depends(get_session_manager),
+    service: PlatformService = Depends(get_platform_service),
+) -> TerminateSessionResponse:
+    ensure_platform_admin(is_platform_admin)
+    session = await session_manager.terminate_session(session_id, reason=request.reason)
+    if session is None:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail="session not found")
+    await service.record_audit(
+        action="session.terminate",
+        resource_type="agent_session",
+        resource_id=session_id,
+        details={
+            "user_id": session.config.user_id,
+            "organization_id": session.config.organization_id,
+            "reason": request.reason,
+            "requested_by": PLATFORM_ADMIN_ACTOR,
+            "model": session.config.model,
+            "prompt_tokens": session.prompt_tokens,
+            "completion_tokens": session.completion_tokens,
+        },
+    )
+    return TerminateSessionResponse(session_id=session_id)
@@ -0,0 +1,183 @@
+"""SCIM 2.0 endpoints for identity-provider driven user and group provisioning."""
+
+from __future__ import annotations
+
+import logging
+from collections.abc import Awaitable
+from typing import Any
+
+from fastapi import APIRouter, Depends, Header, HTTPException, Query, status
+from fastapi.responses import JSONResponse, Response
+
+from atomsAgent.api.auth import bearer_matches
+from atomsAgent.config import settings
+from atomsAgent.dependencies import get_scim_service
+from atomsAgent.schemas.scim import (
+    SCIM_ERROR_SCHEMA,
+    SCIMGroup,
+    SCIMListResponse,
+    SCIMPatchRequest,
+    SCIMUser,
+)
+from atomsAgent.services.scim import SCIMError, SCIMService
+from atomsAgent.utils.log_audit import log_security_event, security_extra
+
+SCIM_MEDIA_TYPE = "application/scim+json"
+
+logger = logging.getLogger(__name__)
+
+
+async def require_scim_token(authorization: str | None = Header(None)) -> None:
+    expected = getattr(settings, "scim_bearer_token", None)
+    if not expected:
+        raise HTTPException(
+            status_code=status.HTTP_503_SERVICE_UNAVAILABLE,
+            detail="SCIM provisioning is not enabled",
+        )
+    if not bearer_matches(authorization, expected):
+        log_security_event(
+            logger,
+            logging.WARNING,
+            "Rejected SCIM request with an invalid token",
+            extra=security_extra("auth.failure", "scim"),
+        )
+        raise HTTPException(status_code=status.HTTP_401_UNAUTHORIZED, detail="invalid SCIM token")
+
+
+router = APIRouter(dependencies=[Depends(require_scim_token)])
+
+
+def _scim_error(exc: SCIMError) -> JSONResponse:
+    body: dict[str, Any] = {
+        "schemas": [SCIM_ERROR_SCHEMA],
+        "status": str(exc.status),
+        "detail": exc.detail,
+    }
+    if exc.scim_type:
+        body["scimType"] = exc.scim_type
+    return JSONResponse(body, status_code=exc.status, media_type=SCIM_MEDIA_TYPE)
+
+
+async def _respond(
+    result: Awaitable[SCIMUser | SCIMGroup | SCIMListResponse | None],
+    status_code: int = status.HTTP_200_OK,
+) -> Response:
+    try:
+        model = await result
+    except SCIMError as exc:
+        return _scim_error(exc)
+    if model is None:
+        return Response(status_code=status.HTTP_204_NO_CONTENT)
+    return JSONResponse(
+        model.model_dump(by_alias=True, exclude_none=True),
+        status_code=status_code,
+        media_type=SCIM_MEDIA_TYPE,
+    )
+
+
+@router.get("/Users")
+async def list_scim_users(
+    filter: str | None = Query(None, description='SCIM filter, e.g. userName eq "a@b.com"'),
+    start_index: int = Query(1, alias="startIndex", ge=1),
+    count: int = Query(100, ge=0, le=1000),
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(
+        service.list_users(filter_expr=filter, start_index=start_index, count=count)
+    )
+
+
+@router.get("/Users/{user_id}")
+async def get_scim_user(
+    user_id: str,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.get_user(user_id))
+
+
+@router.post("/Users")
+async def create_scim_user(
+    payload: SCIMUser,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.create_user(payload), status.HTTP_201_CREATED)
+
+
+@router.put("/Users/{user_id}")
+async def replace_scim_user(
+    user_id: str,
+    payload: SCIMUser,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.replace_user(user_id, payload))
+
+
+@router.patch("/Users/{user_id}")
+async def patch_scim_user(
+    user_id: str,
+    payload: SCIMPatchRequest,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.patch_user(user_id, payload))
+
+
+@router.delete("/Users/{user_id}")
+async def delete_scim_user(
+    user_id: str,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.delete_user(user_id))
+
+
+@router.get("/Groups")
+async def list_scim_groups(
+    filter: str | None = Query(None, description='SCIM filter, e.g. displayName eq "admins"'),
+    start_index: int = Query(1, alias="startIndex", ge=1),
+    count: int = Query(100, ge=0, le=1000),
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(
+        service.list_groups(filter_expr=filter, start_index=start_index, count=count)
+    )
+
+
+@router.get("/Groups/{group_id}")
+async def get_scim_group(
+    group_id: str,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.get_group(group_id))
+
+
+@router.post("/Groups")
+async def create_scim_group(
+    payload: SCIMGroup,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.create_group(payload), status.HTTP_201_CREATED)
+
+
+@router.put("/Groups/{group_id}")
+async def replace_scim_group(
+    group_id: str,
+    payload: SCIMGroup,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.replace_group(group_id, payload))
+
+
+@router.patch("/Groups/{group_id}")
+async def patch_scim_group(
+    group_id: str,
+    payload: SCIMPatchRequest,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.patch_group(group_id, payload))
+
+
+@router.delete("/Groups/{group_id}")
+async def delete_scim_group(
+    group_id: str,
+    service: SCIMService = Depends(get_scim_service),
+) -> Response:
+    return await _respond(service.delete_group(group_id))
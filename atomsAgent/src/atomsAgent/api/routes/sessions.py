@@ -0,0 +1,45 @@
+from __future__ import annotations
+
+from datetime import datetime, timezone
+
+from fastapi import APIRouter, Depends, HTTPException, Path, Query, status
+
+from atomsAgent.dependencies import get_session_manager
+from atomsAgent.schemas.sessions import MCPConnectionStatus, SessionHeartbeatResponse
+from atomsAgent.services import ClaudeSessionManager
+
+router = APIRouter()
+
+
+def _to_heartbeat(session) -> SessionHeartbeatResponse:
+    mcp_servers = [
+        MCPConnectionStatus(name=name, status=server_status)
+        for name, server_status in sorted(session.mcp_status.items())
+    ]
+    return SessionHeartbeatResponse(
+        session_id=session.session_id,
+        state=session.state,
+        agent_alive=session.connected and not session.interrupted,
+        mcp_healthy=all(server.status == "connected" for server in mcp_servers),
+        mcp_servers=mcp_servers,
+        last_used_at=datetime.fromtimestamp(session.last_used, tz=timezone.utc),
+        idle_expires_at=datetime.fromtimestamp(session.idle_expires_at, tz=timezone.utc),
+        idle_timeout_seconds=int(session.idle_timeout),
+    )
+
+
+@router.post("/{session_id}/heartbeat", response_model=SessionHeartbeatResponse)
+async def session_heartbeat(
+    session_id: str = Path(..., description="Agent session identifier"),
+    user_id: str | None = Query(None, description="User the session was started for"),
+    organization_id: str | None = Query(
+        None, description="Organization the session was started for"
+    ),
+    session_manager: ClaudeSessionManager = Depends(get_session_manager),
+) -> SessionHeartbeatResponse:
+    session = await session_manager.heartbeat(
+        session_id, user_id=user_id, organization_id=organization_id
+    )
+    if session is None:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail="session not found")
+    return _to_heartbeat(session)
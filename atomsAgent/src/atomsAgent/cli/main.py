@@ -9,7 +9,7 @@ import os
 import pathlib
 import subprocess
 import sys
-from collections.abc import Iterable
+from collections.abc import Callable, Iterable
 from datetime import datetime
 from typing import TYPE_CHECKING, Any, Literal, cast
 from uuid import UUID
@@ -32,6 +32,7 @@ from atomsAgent.schemas.mcp import (
     MCPScope,
     MCPUpdateRequest,
 )
+from atomsAgent.services.chat_client import ChatClient
 
 if TYPE_CHECKING:  # pragma: no cover - import only for type checking
     from atomsAgent.services.mcp_registry import MCPRegistryService
@@ -810,6 +811,98 @@ def server_run(
     uvicorn.run("atomsAgent.main:app", host=host, port=port, reload=reload, log_level=log_level)
 
 
+def _supabase_round_trip() -> str:  # pragma: no cover - runtime wiring
+    from atomsAgent.dependencies import get_supabase_client
+
+    response = _run_async(get_supabase_client().select("profiles", columns="id", limit=1))
+    return f"profiles readable ({len(response.data or [])} row sampled)"
+
+
+def _selftest_step(
+    results: list[tuple[str, bool, str]], name: str, check: Callable[[], Any]
+) -> Any:
+    try:
+        value = check()
+    except Exception as exc:
+        results.append((name, False, str(exc) or type(exc).__name__))
+        return None
+    results.append((name, True, value if isinstance(value, str) else "ok"))
+    return value
+
+
+@server_app.command("selftest")
+@command_handler
+def server_selftest(
+    base_url: str | None = typer.Option(
+        None, "--base-url", help="Server to test (defaults to agentapi_url)"
+    ),
+    models: list[str] | None = typer.Option(
+        None, "--model", "-m", help="Model to exercise; repeatable (default: all listed)"
+    ),
+    prompt: str = typer.Option("Reply with the single word OK.", "--prompt"),
+    skip_database: bool = typer.Option(False, "--skip-database", help="Skip Supabase check"),
+    timeout: float = typer.Option(60.0, "--timeout", help="Per-request timeout in seconds"),
+) -> None:
+    """Smoke-test a running server end to end; exits non-zero on any failure.
+
+    Checks /health, lists the models, runs one completion per model and reads
+    from Supabase. There is no token-minting step: the server does not verify
+    JWTs (it only forwards the caller's bearer token to internal MCP servers),
+    so there is no JWKS-backed auth to exercise. There is no Redis check either,
+    because the server does not use Redis.
+    """
+    results: list[tuple[str, bool, str]] = []
+
+    with ChatClient(base_url=base_url, timeout=timeout) as client:
+
+        def check_health() -> str:
+            response = client.client.get(
+                f"{client.base_url}/health", headers=client._get_headers()
+            )
+            response.raise_for_status()
+            status = response.json().get("status")
+            if status != "healthy":
+                raise RuntimeError(f"status is {status!r}")
+            return "healthy"
+
+        def list_model_ids() -> list[str]:
+            response = client.client.get(
+                f"{client.base_url}/v1/models", headers=client._get_headers()
+            )
+            response.raise_for_status()
+            ids = [model["id"] for model in response.json().get("data", [])]
+            if not ids:
+                raise RuntimeError("no models listed")
+            return ids
+
+        def complete(model: str) -> str:
+            response = client.chat([{"role": "user", "content": prompt}], model=model)
+            content = response["choices"][0]["message"]["content"]
+            if not content or not content.strip():
+                raise RuntimeError("empty completion")
+            return content.strip()[:60]
+
+        _selftest_step(results, "health", check_health)
+        listed = _selftest_step(results, "models", list_model_ids) or []
+        for model in models or listed:
+            _selftest_step(results, f"completion:{model}", functools.partial(complete, model))
+
+    if not skip_database:
+        _selftest_step(results, "database", _supabase_round_trip)
+
+    table = Table(title="Self-test")
+    table.add_column("Check", style="cyan")
+    table.add_column("Result")
+    table.add_column("Detail", overflow="fold")
+    for name, ok, detail in results:
+        table.add_row(name, "[green]pass[/green]" if ok else "[red]fail[/red]", detail)
+    console.print(table)
+
+    failed = [name for name, ok, _ in results if not ok]
+    if failed:
+        raise CommandError(f"Self-test failed: {', '.join(failed)}")
+
+
 @app.callback(invoke_without_command=True)
 def main(ctx: typer.Context) -> None:  # pragma: no cover - guard for bare invocation
     if ctx.invoked_subcommand is None:
@@ -1,6 +1,7 @@
 from __future__ import annotations
 
 import json
+import re
 from dataclasses import dataclass
 from typing import Any
 from uuid import UUID
@@ -8,6 +9,13 @@ from uuid import UUID
 from atomsAgent.db.models import SupabaseMcpOauthToken, SupabaseMcpOauthTransaction
 from atomsAgent.db.supabase import SupabaseClient
 
+# Profile preference key listing org-default MCP configurations a user has opted out of.
+DEFAULT_MCP_OPT_OUT_KEY = "optedOutDefaultMcpIds"
+# Organization settings key holding platform MCP IDs the organization opted out of.
+PLATFORM_MCP_OPT_OUT_KEY = "optedOutPlatformMcpIds"
+# Organization settings key holding the MCP tool allow/deny policy.
+MCP_TOOL_POLICY_KEY = "mcpToolPolicy"
+
 
 @dataclass
 class PromptRecord:
@@ -81,6 +89,21 @@ class MCPConfigRecord:
     enabled: bool
 
 
+@dataclass
+class MCPUsageRecord:
+    mcp_id: str
+    mcp_name: str
+    tool: str
+    user_id: str | None
+    org_id: str | None
+    latency_ms: float
+    success: bool
+    request_bytes: int
+    response_bytes: int
+    error_code: str | None
+    created_at: str
+
+
 class MCPRepository:
     """Data access helpers for MCP configurations."""
 
@@ -93,38 +116,59 @@ class MCPRepository:
         organization_id: UUID,
         user_id: UUID | None,
         include_platform: bool = True,
-    ) -> list[MCPConfigRecord]:
-        # Get org-specific configs first
+        scope: str | None = None,
+        auth_type: str | None = None,
+        name_prefix: str | None = None,
+        order: list[str] | None = None,
+        limit: int | None = None,
+        offset: int | None = None,
+    ) -> tuple[list[MCPConfigRecord], int]:
+        """Return one page of enabled configurations visible to the org/user, and the total.
+
+        Visible rows are the organization's own, the user's own within it and,
+        with ``include_platform``, platform rows (no org). ``scope`` narrows
+        that to one of ``organization``, ``user`` or ``platform``. Filtering,
+        ordering and paging all run in PostgREST.
+        """
         org_id_str = str(organization_id)
-        org_filters = {"enabled": "eq.true", "org_id": f"eq.{org_id_str}"}
+        visible: list[str] = []
+        if scope in (None, "organization"):
+            visible.append(f"and(org_id.eq.{org_id_str},user_id.is.null)")
+        if user_id is not None and scope in (None, "user"):
+            visible.append(f"and(org_id.eq.{org_id_str},user_id.eq.{user_id})")
+        if include_platform and scope in (None, "platform"):
+            visible.append("org_id.is.null")
+        if not visible:
+            return [], 0
+
+        filters = {"enabled": "eq.true", "or": f"({','.join(visible)})"}
+        if auth_type is not None:
+            filters["auth_type"] = f"eq.{auth_type}"
+        if name_prefix:
+            # LIKE wildcards are escaped; PostgREST reads "*" as "%", so a
+            # literal "*" can only be matched as any single character.
+            escaped = re.sub(r"([\\%_])", r"\\\1", name_prefix).replace("*", "_")
+            filters["name"] = f"ilike.{escaped}*"
         response = await self._client.select(
             "mcp_configurations",
             columns="id,org_id,user_id,name,type,endpoint,auth_type,auth_token,auth_header,config,scope,enabled,description,created_at,updated_at,created_by,updated_by",
-            filters=org_filters,
+            filters=filters,
+            order=[*(order or ["created_at.asc"]), "id.asc"],
+            limit=limit,
+            offset=offset,
+            count=True,
         )
-        configs: list[MCPConfigRecord] = []
-        user_id_str = str(user_id) if user_id else None
-        for row in response.data:
-            row_org_id = row.get("org_id")
-            row_user_id = row.get("user_id")
-            if row_org_id == org_id_str and row_user_id is None:
-                configs.append(_mcp_record_from_row(row))
-            elif user_id_str and row_org_id == org_id_str and row_user_id == user_id_str:
-                configs.append(_mcp_record_from_row(row))
-
-        # If include_platform, get platform configs separately
-        if include_platform:
-            platform_filters = {"enabled": "eq.true", "org_id": "is.null"}
-            platform_response = await self._client.select(
-                "mcp_configurations",
-                columns="id,org_id,user_id,name,type,endpoint,auth_type,auth_token,auth_header,config,scope,enabled,description,created_at,updated_at,created_by,updated_by",
-                filters=platform_filters,
-            )
-            for row in platform_response.data:
-                if row.get("org_id") is None:
-                    configs.append(_mcp_record_from_row(row))
+        records = [_mcp_record_from_row(row) for row in response.data]
+        return records, response.count if response.count is not None else len(records)
 
-        return configs
+    async def list_enabled_configs(self) -> list[MCPConfigRecord]:
+        """Every enabled configuration across all scopes (used by the health monitor)."""
+        response = await self._client.select(
+            "mcp_configurations",
+            columns="id,org_id,user_id,name,type,endpoint,auth_type,auth_token,auth_header,config,scope,enabled,description,created_at,updated_at,created_by,updated_by",
+            filters={"enabled": "eq.true"},
+        )
+        return [_mcp_record_from_row(row) for row in response.data]
 
     async def get_config(self, config_id: UUID) -> MCPConfigRecord:
         response = await self._client.select(
@@ -154,6 +198,111 @@ class MCPRepository:
             filters={"id": f"eq.{config_id}"},
         )
 
+    async def get_health(self, config_id: str) -> dict[str, Any] | None:
+        response = await self._client.select(
+            "mcp_health",
+            columns="mcp_id,name,status,last_checked_at,last_success_at,last_error,last_error_at,latency_ms,consecutive_failures",
+            filters={"mcp_id": f"eq.{config_id}"},
+        )
+        return response.data[0] if response.data else None
+
+    async def save_health(self, row: dict[str, Any]) -> None:
+        await self._client.upsert("mcp_health", row, on_conflict="mcp_id")
+
+    async def delete_health(self, config_id: str) -> None:
+        await self._client.delete("mcp_health", filters={"mcp_id": f"eq.{config_id}"})
+
+    async def insert_usage(self, rows: list[dict[str, Any]]) -> None:
+        await self._client.insert("mcp_usage", rows)
+
+    async def list_usage(
+        self,
+        *,
+        since: str,
+        until: str,
+        organization_id: UUID | None = None,
+        mcp_id: UUID | None = None,
+        limit: int = 1000,
+        offset: int = 0,
+    ) -> list[MCPUsageRecord]:
+        """Return tool call rows recorded in ``[since, until)``, oldest first."""
+        filters = {"and": f"(created_at.gte.{since},created_at.lt.{until})"}
+        if organization_id is not None:
+            filters["org_id"] = f"eq.{organization_id}"
+        if mcp_id is not None:
+            filters["mcp_id"] = f"eq.{mcp_id}"
+        response = await self._client.select(
+            "mcp_usage",
+            columns="mcp_id,mcp_name,tool,user_id,org_id,latency_ms,success,request_bytes,response_bytes,error_code,created_at",
+            filters=filters,
+            order=["created_at.asc"],
+            limit=limit,
+            offset=offset,
+        )
+        return [MCPUsageRecord(**row) for row in response.data]
+
+    async def get_default_opt_outs(self, user_id: UUID) -> list[str]:
+        preferences = await self._get_preferences(user_id)
+        return [str(value) for value in preferences.get(DEFAULT_MCP_OPT_OUT_KEY) or []]
+
+    async def set_default_opt_outs(self, user_id: UUID, config_ids: list[str]) -> None:
+        preferences = await self._get_preferences(user_id)
+        preferences[DEFAULT_MCP_OPT_OUT_KEY] = config_ids
+        await self._client.update(
+            "profiles",
+            filters={"id": f"eq.{user_id}"},
+            payload={"preferences": preferences},
+        )
+
+    async def get_platform_opt_outs(self, organization_id: UUID | str) -> list[str]:
+        settings = await self._get_org_settings(organization_id)
+        return [str(value) for value in settings.get(PLATFORM_MCP_OPT_OUT_KEY) or []]
+
+    async def set_platform_opt_outs(self, organization_id: UUID, config_ids: list[str]) -> None:
+        settings = await self._get_org_settings(organization_id)
+        settings[PLATFORM_MCP_OPT_OUT_KEY] = config_ids
+        await self._client.update(
+            "organizations",
+            filters={"id": f"eq.{organization_id}"},
+            payload={"settings": settings},
+        )
+
+    async def get_tool_policy(self, organization_id: UUID | str) -> dict[str, Any]:
+        settings = await self._get_org_settings(organization_id)
+        return dict(settings.get(MCP_TOOL_POLICY_KEY) or {})
+
+    async def set_tool_policy(self, organization_id: UUID, policy: dict[str, Any] | None) -> None:
+        settings = await self._get_org_settings(organization_id)
+        if policy is None:
+            settings.pop(MCP_TOOL_POLICY_KEY, None)
+        else:
+            settings[MCP_TOOL_POLICY_KEY] = policy
+        await self._client.update(
+            "organizations",
+            filters={"id": f"eq.{organization_id}"},
+            payload={"settings": settings},
+        )
+
+    async def _get_org_settings(self, organization_id: UUID | str) -> dict[str, Any]:
+        response = await self._client.select(
+            "organizations",
+            columns="settings",
+            filters={"id": f"eq.{organization_id}"},
+        )
+        if not response.data:
+            raise ValueError(f"Organization not found: {organization_id}")
+        return dict(response.data[0].get("settings") or {})
+
+    async def _get_preferences(self, user_id: UUID) -> dict[str, Any]:
+        response = await self._client.select(
+            "profiles",
+            columns="preferences",
+            filters={"id": f"eq.{user_id}"},
+        )
+        if not response.data:
+            raise ValueError(f"Profile not found: {user_id}")
+        return dict(response.data[0].get("preferences") or {})
+
 
 @dataclass
 class PlatformStatsRecord:
@@ -235,20 +384,177 @@ class PlatformRepository:
             limit=limit,
             offset=offset,
         )
-        records: list[AuditLogRecord] = []
-        for row in response.data:
-            details: dict[str, Any] = {}
-            raw_details = row.get("details")
-            if isinstance(raw_details, dict):
-                details = raw_details  # type: ignore[assignment]
-            elif isinstance(raw_details, str):
-                try:
-                    details = json.loads(raw_details)
-                except Exception:
-                    details = {}
-            row["details"] = details
-            records.append(AuditLogRecord(**row))
-        return records
+        return [self._audit_record(row) for row in response.data]
+
+    async def list_resource_audit_logs(
+        self,
+        resource_type: str,
+        resource_id: str,
+        *,
+        actions: tuple[str, ...] = (),
+        limit: int = 100,
+    ) -> list[AuditLogRecord]:
+        """Return audit entries for one resource, newest first."""
+        filters = {"resource_type": f"eq.{resource_type}", "resource_id": f"eq.{resource_id}"}
+        if actions:
+            filters["action"] = f"in.({','.join(actions)})"
+        response = await self._client.select(
+            "audit_logs",
+            columns="id,timestamp,action,resource_type,resource_id,details,success",
+            filters=filters,
+            order=["timestamp.desc"],
+            limit=limit,
+        )
+        return [self._audit_record(row) for row in response.data]
+
+    async def insert_audit_log(self, payload: dict[str, Any]) -> None:
+        await self._client.insert("audit_logs", payload)
+
+    @staticmethod
+    def _audit_record(row: dict[str, Any]) -> AuditLogRecord:
+        details: dict[str, Any] = {}
+        raw_details = row.get("details")
+        if isinstance(raw_details, dict):
+            details = raw_details  # type: ignore[assignment]
+        elif isinstance(raw_details, str):
+            try:
+                details = json.loads(raw_details)
+            except Exception:
+                details = {}
+        return AuditLogRecord(**{**row, "details": details})
+
+
+@dataclass
+class SCIMUserRecord:
+    id: str
+    email: str
+    full_name: str | None
+    workos_id: str | None
+    status: str | None
+    is_deleted: bool
+    created_at: str | None
+    updated_at: str | None
+
+
+_SCIM_PROFILE_COLUMNS = "id,email,full_name,workos_id,status,is_deleted,created_at,updated_at"
+
+
+class SCIMRepository:
+    """Data access helpers for SCIM user and group provisioning."""
+
+    def __init__(self, client: SupabaseClient) -> None:
+        self._client = client
+
+    async def list_users(
+        self,
+        *,
+        filters: dict[str, str] | None = None,
+        limit: int | None = None,
+        offset: int | None = None,
+    ) -> tuple[list[SCIMUserRecord], int]:
+        response = await self._client.select(
+            "profiles",
+            columns=_SCIM_PROFILE_COLUMNS,
+            filters={"is_deleted": "not.is.true", **(filters or {})},
+            order=["created_at.asc"],
+            limit=limit,
+            offset=offset,
+            count=True,
+        )
+        records = [_scim_user_from_row(row) for row in response.data]
+        return records, response.count if response.count is not None else len(records)
+
+    async def get_user(self, user_id: str) -> SCIMUserRecord | None:
+        response = await self._client.select(
+            "profiles",
+            columns=_SCIM_PROFILE_COLUMNS,
+            filters={"id": f"eq.{user_id}", "is_deleted": "not.is.true"},
+        )
+        if not response.data:
+            return None
+        return _scim_user_from_row(response.data[0])
+
+    async def find_deleted_user(self, email: str) -> SCIMUserRecord | None:
+        """Return the soft-deleted profile holding ``email``, if any."""
+        response = await self._client.select(
+            "profiles",
+            columns=_SCIM_PROFILE_COLUMNS,
+            filters={"email": f"eq.{email}", "is_deleted": "is.true"},
+        )
+        if not response.data:
+            return None
+        return _scim_user_from_row(response.data[0])
+
+    async def create_user(self, payload: dict[str, Any]) -> SCIMUserRecord:
+        response = await self._client.insert("profiles", payload)
+        return _scim_user_from_row(response.data[0])
+
+    async def update_user(self, user_id: str, payload: dict[str, Any]) -> SCIMUserRecord | None:
+        response = await self._client.update(
+            "profiles",
+            filters={"id": f"eq.{user_id}"},
+            payload=payload,
+        )
+        if not response.data:
+            return None
+        return _scim_user_from_row(response.data[0])
+
+    async def list_role_member_ids(self, organization_id: str, role: str) -> list[str]:
+        response = await self._client.select(
+            "organization_members",
+            columns="user_id",
+            filters={
+                "organization_id": f"eq.{organization_id}",
+                "role": f"eq.{role}",
+                "is_deleted": "not.is.true",
+            },
+        )
+        return [str(row["user_id"]) for row in response.data]
+
+    async def set_member_role(self, organization_id: str, user_id: str, role: str) -> None:
+        filters = {"organization_id": f"eq.{organization_id}", "user_id": f"eq.{user_id}"}
+        existing = await self._client.select(
+            "organization_members", columns="id", filters=filters
+        )
+        if existing.data:
+            await self._client.update(
+                "organization_members",
+                filters=filters,
+                payload={"role": role, "is_deleted": False, "status": "active"},
+            )
+        else:
+            await self._client.insert(
+                "organization_members",
+                {
+                    "organization_id": organization_id,
+                    "user_id": user_id,
+                    "role": role,
+                    "status": "active",
+                },
+            )
+
+    async def remove_member(self, organization_id: str, user_id: str) -> None:
+        await self._client.delete(
+            "organization_members",
+            filters={"organization_id": f"eq.{organization_id}", "user_id": f"eq.{user_id}"},
+        )
+
+    async def list_platform_admin_emails(self) -> list[str]:
+        response = await self._client.select("platform_admins", columns="email")
+        return [str(row["email"]) for row in response.data]
+
+    async def add_platform_admin(self, user: SCIMUserRecord) -> None:
+        await self._client.insert(
+            "platform_admins",
+            {
+                "email": user.email,
+                "name": user.full_name,
+                "workos_user_id": user.workos_id or user.id,
+            },
+        )
+
+    async def remove_platform_admin(self, email: str) -> None:
+        await self._client.delete("platform_admins", filters={"email": f"eq.{email}"})
 
 
 @dataclass
@@ -487,6 +793,19 @@ def _mcp_record_from_row(row: dict[str, Any]) -> MCPConfigRecord:
     )
 
 
+def _scim_user_from_row(row: dict[str, Any]) -> SCIMUserRecord:
+    return SCIMUserRecord(
+        id=str(row["id"]),
+        email=row.get("email") or "",
+        full_name=row.get("full_name"),
+        workos_id=row.get("workos_id"),
+        status=row.get("status"),
+        is_deleted=bool(row.get("is_deleted")),
+        created_at=row.get("created_at"),
+        updated_at=row.get("updated_at"),
+    )
+
+
 def _chat_session_from_row(row: dict[str, Any]) -> ChatSessionRecord:
     return ChatSessionRecord(
         id=row["id"],
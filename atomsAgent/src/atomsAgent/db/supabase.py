@@ -6,6 +6,8 @@ from typing import Any
 
 import httpx
 
+from atomsAgent.utils.retry import RetryPolicy, parse_retry_after, retry
+
 
 @dataclass(slots=True)
 class SupabaseResponse:
@@ -13,20 +15,48 @@ class SupabaseResponse:
     count: int | None = None
 
 
+# PostgreSQL error code PostgREST reports for a unique constraint violation.
+UNIQUE_VIOLATION = "23505"
+
+
 class SupabaseError(RuntimeError):
-    pass
+    def __init__(
+        self,
+        message: str,
+        *,
+        status_code: int | None = None,
+        code: str | None = None,
+        retry_after: float | None = None,
+    ) -> None:
+        super().__init__(message)
+        self.status_code = status_code
+        # PostgreSQL error code from the PostgREST error body, when present.
+        self.code = code
+        self.retry_after = retry_after
 
 
 class SupabaseClient:
-    """HTTP-based Supabase client using the REST interface."""
+    """HTTP-based Supabase client using the REST interface.
+
+    Reads that fail transiently are retried per ``read_retry``; writes are not,
+    since a retried insert or RPC may already have been applied.
+    """
 
-    def __init__(self, *, url: str, service_role_key: str, schema: str = "public") -> None:
+    def __init__(
+        self,
+        *,
+        url: str,
+        service_role_key: str,
+        schema: str = "public",
+        read_retry: RetryPolicy = RetryPolicy(),
+    ) -> None:
         if not url or not service_role_key:
             raise ValueError("Supabase URL and service role key are required")
         self.base_url = url.rstrip("/") + "/rest/v1"
         self.rpc_url = url.rstrip("/") + "/rest/v1/rpc"
         self.service_role_key = service_role_key
         self.schema = schema
+        self.read_retry = read_retry
         self._default_headers = {
             "apikey": service_role_key,
             "Authorization": f"Bearer {service_role_key}",
@@ -63,21 +93,47 @@ class SupabaseClient:
         if count:
             headers["Prefer"] = headers["Prefer"] + ",count=exact"
 
+        async def fetch() -> httpx.Response:
+            async with httpx.AsyncClient() as client:
+                response = await client.get(
+                    f"{self.base_url}/{table}",
+                    params=params,
+                    headers=headers,
+                )
+            self._raise_for_status(response)
+            return response
+
+        response = await retry(fetch, self.read_retry, operation=f"Supabase select from {table}")
+        total = self._extract_count(response) if count else None
+        return SupabaseResponse(data=response.json(), count=total)
+
+    async def insert(
+        self, table: str, payload: dict[str, Any] | list[dict[str, Any]]
+    ) -> SupabaseResponse:
         async with httpx.AsyncClient() as client:
-            response = await client.get(
+            response = await client.post(
                 f"{self.base_url}/{table}",
-                params=params,
-                headers=headers,
+                headers=self._default_headers,
+                content=json.dumps(payload),
             )
         self._raise_for_status(response)
-        total = self._extract_count(response) if count else None
-        return SupabaseResponse(data=response.json(), count=total)
+        return SupabaseResponse(data=response.json())
 
-    async def insert(self, table: str, payload: dict[str, Any]) -> SupabaseResponse:
+    async def upsert(
+        self,
+        table: str,
+        payload: dict[str, Any] | list[dict[str, Any]],
+        *,
+        on_conflict: str,
+    ) -> SupabaseResponse:
+        """Insert rows, updating those that collide on the ``on_conflict`` columns."""
+        headers = dict(self._default_headers)
+        headers["Prefer"] = headers["Prefer"] + ",resolution=merge-duplicates"
         async with httpx.AsyncClient() as client:
             response = await client.post(
                 f"{self.base_url}/{table}",
-                headers=self._default_headers,
+                params={"on_conflict": on_conflict},
+                headers=headers,
                 content=json.dumps(payload),
             )
         self._raise_for_status(response)
@@ -129,7 +185,13 @@ class SupabaseClient:
                 payload = response.json()
             except ValueError:
                 payload = response.text
-            raise SupabaseError(f"Supabase error {response.status_code}: {payload}")
+            code = payload.get("code") if isinstance(payload, dict) else None
+            raise SupabaseError(
+                f"Supabase error {response.status_code}: {payload}",
+                status_code=response.status_code,
+                code=code if isinstance(code, str) else None,
+                retry_after=parse_retry_after(response.headers.get("retry-after")),
+            )
 
     @staticmethod
     def _extract_count(response: httpx.Response) -> int | None:
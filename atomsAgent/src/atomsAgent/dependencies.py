@@ -2,26 +2,39 @@ from __future__ import annotations
 
 from functools import lru_cache
 
-from aiocache import Cache
-
 from atomsAgent.config import settings
 from atomsAgent.db.repositories import (
     ChatHistoryRepository,
     MCPRepository,
     PlatformRepository,
     PromptRepository,
+    SCIMRepository,
 )
 from atomsAgent.db.supabase import SupabaseClient
 from atomsAgent.services import (
     ClaudeAgentClient,
     ClaudeSessionManager,
+    ConcurrencyLimiter,
+    ErrorBudgetTracker,
+    MCPCatalog,
+    MCPCircuitBreakers,
+    MCPConnectionManager,
+    MCPHealthMonitor,
     MCPRegistryService,
+    MCPServerEvents,
+    MCPToolPolicyService,
+    MCPToolService,
+    MCPUsageMeter,
     PlatformService,
     PromptOrchestrator,
     SandboxManager,
     VertexModelService,
 )
 from atomsAgent.services.chat_history import ChatHistoryService
+from atomsAgent.services.scim import SCIMService
+from atomsAgent.services.secret_store import SecretStore, create_secret_store
+from atomsAgent.services.session_tokens import SessionResumeCodec
+from atomsAgent.utils.caching import AsyncCacheAdapter, SizedLRUCache
 
 
 @lru_cache
@@ -42,12 +55,24 @@ def get_supabase_client() -> SupabaseClient:
     )
 
 
+@lru_cache
+def get_session_resume_codec() -> SessionResumeCodec | None:
+    secret = getattr(settings, "token_encryption_key", None)
+    if not secret:
+        return None
+    return SessionResumeCodec(secret, ttl_seconds=settings.session_resume_token_ttl_seconds)
+
+
 @lru_cache
 def get_session_manager() -> ClaudeSessionManager:
     return ClaudeSessionManager(
         sandbox_manager=get_sandbox_manager(),
         default_allowed_tools=settings.default_allowed_tools,
         default_setting_sources=settings.default_setting_sources or None,
+        idle_timeout_seconds=settings.session_timeout,
+        reap_interval_seconds=settings.session_reap_interval_seconds,
+        resume_codec=get_session_resume_codec(),
+        tool_policies=get_mcp_tool_policy_service(),
     )
 
 
@@ -62,7 +87,9 @@ def get_prompt_orchestrator() -> PromptOrchestrator:
 
 @lru_cache
 def get_vertex_model_service() -> VertexModelService:
-    cache = Cache(Cache.MEMORY)
+    cache = AsyncCacheAdapter(
+        SizedLRUCache(settings.model_cache_max_bytes, name="vertex_models")
+    )
     return VertexModelService(
         project_id=settings.vertex_project_id,
         location=settings.vertex_location,
@@ -86,9 +113,134 @@ def get_platform_service() -> PlatformService:
     return PlatformService(repository=PlatformRepository(get_supabase_client()))
 
 
+@lru_cache
+def get_concurrency_limiter() -> ConcurrencyLimiter:
+    return ConcurrencyLimiter(
+        max_per_user=settings.max_concurrent_requests_per_user,
+        max_per_org=settings.max_concurrent_requests_per_org,
+    )
+
+
+@lru_cache
+def get_mcp_tool_cache() -> SizedLRUCache:
+    return SizedLRUCache(settings.mcp_tool_cache_max_bytes, name="mcp_tools")
+
+
+@lru_cache
+def get_secret_store() -> SecretStore:
+    return create_secret_store(settings)
+
+
 @lru_cache
 def get_mcp_service() -> MCPRegistryService:
-    return MCPRegistryService(repository=MCPRepository(get_supabase_client()))
+    return MCPRegistryService(
+        repository=MCPRepository(get_supabase_client()),
+        audit_repository=PlatformRepository(get_supabase_client()),
+        tool_cache=get_mcp_tool_cache(),
+        secret_store=get_secret_store(),
+        connections=get_mcp_connection_manager(),
+    )
+
+
+@lru_cache
+def get_mcp_catalog() -> MCPCatalog:
+    return MCPCatalog()
+
+
+@lru_cache
+def get_mcp_server_events() -> MCPServerEvents:
+    return MCPServerEvents(
+        tool_cache=get_mcp_tool_cache(),
+        policy_service=get_mcp_tool_policy_service(),
+        claude_client=get_claude_client(),
+        session_manager=get_session_manager(),
+        sampling_model=settings.mcp_sampling_model,
+        sampling_max_tokens=settings.mcp_sampling_max_tokens,
+    )
+
+
+@lru_cache
+def get_mcp_connection_manager() -> MCPConnectionManager:
+    return MCPConnectionManager(
+        # Resolved per client so building the manager does not need Supabase.
+        client_factory=lambda record: get_mcp_server_events().create_client(record),
+        idle_timeout_seconds=settings.mcp_connection_idle_timeout_seconds,
+        reap_interval_seconds=settings.mcp_connection_reap_interval_seconds,
+        max_failed_pings=settings.mcp_connection_max_failed_pings,
+        pool_size=settings.mcp_connection_pool_size,
+        on_disconnect=get_mcp_tool_cache().delete,
+        secret_store=get_secret_store(),
+    )
+
+
+@lru_cache
+def get_mcp_health_monitor() -> MCPHealthMonitor:
+    return MCPHealthMonitor(
+        MCPRepository(get_supabase_client()),
+        interval_seconds=settings.mcp_health_check_interval_seconds or 60.0,
+        timeout_seconds=settings.mcp_health_check_timeout_seconds,
+        failure_threshold=settings.mcp_health_failure_threshold,
+        secret_store=get_secret_store(),
+    )
+
+
+@lru_cache
+def get_error_budget() -> ErrorBudgetTracker:
+    return ErrorBudgetTracker(
+        window_seconds=settings.error_budget_window_seconds,
+        threshold=settings.error_budget_threshold,
+        min_events=settings.error_budget_min_events,
+    )
+
+
+@lru_cache
+def get_mcp_breakers() -> MCPCircuitBreakers:
+    return MCPCircuitBreakers(
+        failure_threshold=settings.mcp_breaker_failure_threshold,
+        reset_timeout_seconds=settings.mcp_breaker_reset_timeout_seconds,
+        max_breakers=settings.mcp_breaker_max_breakers,
+    )
+
+
+@lru_cache
+def get_mcp_tool_policy_service() -> MCPToolPolicyService:
+    return MCPToolPolicyService(
+        MCPRepository(get_supabase_client()),
+        cache=SizedLRUCache(1 << 20, name="mcp_tool_policies"),
+    )
+
+
+@lru_cache
+def get_mcp_tool_service() -> MCPToolService:
+    return MCPToolService(
+        repository=MCPRepository(get_supabase_client()),
+        connections=get_mcp_connection_manager(),
+        tool_cache=get_mcp_tool_cache(),
+        tool_cache_ttl_seconds=settings.mcp_tool_cache_ttl_seconds,
+        policy_service=get_mcp_tool_policy_service(),
+        breakers=get_mcp_breakers(),
+        usage=get_mcp_usage_meter() if settings.mcp_usage_metering else None,
+        error_budget=get_error_budget(),
+    )
+
+
+@lru_cache
+def get_mcp_usage_meter() -> MCPUsageMeter:
+    return MCPUsageMeter(
+        MCPRepository(get_supabase_client()),
+        flush_interval_seconds=settings.mcp_usage_flush_interval_seconds,
+        max_buffer=settings.mcp_usage_max_buffer,
+        query_max_rows=settings.mcp_usage_query_max_rows,
+    )
+
+
+@lru_cache
+def get_scim_service() -> SCIMService:
+    return SCIMService(
+        repository=SCIMRepository(get_supabase_client()),
+        organization_id=settings.scim_organization_id,
+        group_role_map=settings.scim_group_role_map,
+    )
 
 
 @lru_cache
@@ -1,13 +1,92 @@
+import asyncio
+import logging
+from collections.abc import AsyncIterator
+from contextlib import asynccontextmanager
+
 from fastapi import FastAPI
 from fastapi.middleware.cors import CORSMiddleware
 from fastapi.responses import ORJSONResponse
+from fastapi.staticfiles import StaticFiles
 
+from atomsAgent.admin import STATIC_DIR as ADMIN_STATIC_DIR
 from atomsAgent.api import register_routes
+from atomsAgent.api.errors import register_error_handlers
+from atomsAgent.api.middleware import (
+    AccessLogMiddleware,
+    ErrorRecoveryMiddleware,
+    LogContextMiddleware,
+)
 from atomsAgent.config import settings
+from atomsAgent.dependencies import (
+    get_mcp_connection_manager,
+    get_mcp_health_monitor,
+    get_mcp_usage_meter,
+    get_platform_service,
+    get_session_manager,
+)
+from atomsAgent.utils.log_audit import AuditLogHandler, install_audit_handler
+from atomsAgent.utils.log_redaction import DEFAULT_REDACT_KEYS, RedactionFilter
+from atomsAgent.utils.log_sinks import configure_logging, install_crash_logging
+
+
+@asynccontextmanager
+async def lifespan(_: FastAPI) -> AsyncIterator[None]:
+    # Installed per server run rather than in create_app(), so importing the
+    # app (tests, tooling) leaves the process-wide exception hooks alone.
+    uninstall_crash_logging = install_crash_logging(exit_code=settings.log_fatal_exit_code)
+    sessions = get_session_manager()
+    sessions.start_reaper()
+    connections = get_mcp_connection_manager()
+    connections.start_reaper()
+    health_monitor = None
+    if settings.mcp_health_check_interval_seconds:
+        health_monitor = get_mcp_health_monitor()
+        health_monitor.start()
+    usage_meter = None
+    if settings.mcp_usage_metering:
+        usage_meter = get_mcp_usage_meter()
+        usage_meter.start()
+    if settings.audit_log_bridge:
+        audit_handler = AuditLogHandler(
+            get_platform_service().record_audit, asyncio.get_running_loop()
+        )
+        # Audit rows must name who and what was changed (admin emails, resource
+        # ids), so only credentials are redacted from them.
+        audit_handler.addFilter(
+            RedactionFilter(
+                (*DEFAULT_REDACT_KEYS, *settings.log_redact_keys),
+                mask_emails=False,
+                patterns=settings.log_redact_patterns,
+                preserve=("audit_resource_id",),
+            )
+        )
+        install_audit_handler(audit_handler)
+    try:
+        yield
+    finally:
+        install_audit_handler(None)
+        if health_monitor is not None:
+            await health_monitor.shutdown()
+        if usage_meter is not None:
+            await usage_meter.shutdown()
+        await sessions.shutdown()
+        await connections.shutdown()
+        uninstall_crash_logging()
 
 
 def create_app() -> FastAPI:
     """Create FastAPI application instance configured for atomsAgent."""
+    configure_logging(
+        settings.log_level,
+        settings.log_sinks,
+        redact_keys=settings.log_redact_keys,
+        redact_patterns=settings.log_redact_patterns,
+        mask_emails=settings.log_mask_emails,
+        async_queue_size=settings.log_queue_size if settings.log_async else None,
+        async_policy=settings.log_queue_policy,
+        levels=settings.log_levels,
+        sampling=settings.log_sampling,
+    )
     app = FastAPI(
         title="atomsAgent API",
         version=settings.app_version,
@@ -15,6 +94,7 @@ def create_app() -> FastAPI:
         redoc_url="/redoc" if settings.enable_docs else None,
         openapi_url="/openapi.json",
         default_response_class=ORJSONResponse,
+        lifespan=lifespan,
     )
 
     if settings.cors_allow_origins:
@@ -26,8 +106,18 @@ def create_app() -> FastAPI:
             allow_headers=["*"],
         )
 
+    # Middleware added later wraps earlier ones; LogContextMiddleware must be
+    # outermost so the access log and error handler see the request ID.
+    app.add_middleware(ErrorRecoveryMiddleware)
+    app.add_middleware(AccessLogMiddleware)
+    app.add_middleware(LogContextMiddleware)
+
+    register_error_handlers(app)
     register_routes(app)
 
+    if settings.enable_admin_ui:
+        app.mount("/admin", StaticFiles(directory=ADMIN_STATIC_DIR, html=True), name="admin")
+
     return app
 
 
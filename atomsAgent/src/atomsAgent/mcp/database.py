@@ -6,11 +6,16 @@ This module provides functions for fetching MCP server configurations from Supab
 
 from __future__ import annotations
 
+import json
 import logging
+from collections.abc import Sequence
 from datetime import datetime, timezone
 from typing import Any
 
+from atomsAgent.db.repositories import DEFAULT_MCP_OPT_OUT_KEY, PLATFORM_MCP_OPT_OUT_KEY
+from atomsAgent.mcp.sandbox import StdioSandboxPolicy, sandbox_stdio_config
 from atomsAgent.mcp.supabase_client import get_supabase_client
+from atomsAgent.settings import get_config
 
 logger = logging.getLogger(__name__)
 
@@ -198,11 +203,164 @@ async def get_active_profile_servers(user_id: str) -> list[dict[str, Any]]:
         return []
 
 
+async def get_org_default_mcp_configurations(org_id: str) -> list[dict[str, Any]]:
+    """
+    Fetch MCP configurations an org admin marked as default for every session.
+
+    Args:
+        org_id: Organization ID to fetch defaults for
+
+    Returns:
+        List of mcp_configurations rows flagged as default
+    """
+    try:
+        supabase = get_supabase_client()
+
+        result = await supabase.select(
+            "mcp_configurations",
+            filters={
+                "org_id": f"eq.{org_id}",
+                "user_id": "is.null",
+                "enabled": "eq.true",
+            },
+        )
+
+        defaults = []
+        for row in result.data or []:
+            config = row.get("config")
+            if isinstance(config, str):
+                try:
+                    config = json.loads(config)
+                except json.JSONDecodeError:
+                    config = None
+            if isinstance(config, dict) and config.get("default"):
+                defaults.append(row)
+
+        logger.info(f"Found {len(defaults)} default MCP configurations for org {org_id}")
+        return defaults
+    except Exception as e:
+        logger.error(f"Error fetching default MCP configurations: {e}")
+        return []
+
+
+async def get_default_mcp_opt_outs(user_id: str) -> set[str]:
+    """
+    Fetch the org-default MCP configuration IDs a user has opted out of.
+
+    Args:
+        user_id: User ID whose profile preferences hold the opt-outs
+
+    Returns:
+        Set of mcp_configurations IDs to skip
+    """
+    try:
+        supabase = get_supabase_client()
+
+        result = await supabase.select(
+            "profiles",
+            filters={"id": f"eq.{user_id}"},
+            columns="preferences",
+        )
+        if not result.data:
+            return set()
+
+        preferences = result.data[0].get("preferences") or {}
+        return {str(value) for value in preferences.get(DEFAULT_MCP_OPT_OUT_KEY) or []}
+    except Exception as e:
+        logger.error(f"Error fetching default MCP opt-outs: {e}")
+        return set()
+
+
+async def get_platform_mcp_configurations() -> list[dict[str, Any]]:
+    """
+    Fetch platform-scoped MCP configurations offered to every organization.
+
+    Returns:
+        List of enabled mcp_configurations rows without an owning org or user
+    """
+    try:
+        supabase = get_supabase_client()
+
+        result = await supabase.select(
+            "mcp_configurations",
+            filters={
+                "org_id": "is.null",
+                "user_id": "is.null",
+                "enabled": "eq.true",
+            },
+        )
+        logger.info(f"Found {len(result.data or [])} platform MCP configurations")
+        return list(result.data or [])
+    except Exception as e:
+        logger.error(f"Error fetching platform MCP configurations: {e}")
+        return []
+
+
+async def get_platform_mcp_opt_outs(org_id: str) -> set[str]:
+    """
+    Fetch the platform MCP configuration IDs an organization has opted out of.
+
+    Args:
+        org_id: Organization ID whose settings hold the opt-outs
+
+    Returns:
+        Set of mcp_configurations IDs to skip
+    """
+    try:
+        supabase = get_supabase_client()
+
+        result = await supabase.select(
+            "organizations",
+            filters={"id": f"eq.{org_id}"},
+            columns="settings",
+        )
+        if not result.data:
+            return set()
+
+        settings = result.data[0].get("settings") or {}
+        return {str(value) for value in settings.get(PLATFORM_MCP_OPT_OUT_KEY) or []}
+    except Exception as e:
+        logger.error(f"Error fetching platform MCP opt-outs: {e}")
+        return set()
+
+
+def convert_mcp_configuration_to_mcp_config(configuration: dict[str, Any]) -> dict[str, Any]:
+    """
+    Convert an mcp_configurations row to MCP server configuration format.
+
+    Args:
+        configuration: Database record from mcp_configurations table
+
+    Returns:
+        MCP server configuration dict compatible with Claude Agent SDK
+    """
+    endpoint = configuration.get("endpoint")
+    if not endpoint:
+        logger.warning(f"No endpoint configured for MCP configuration {configuration.get('id')}")
+        return {}
+
+    config: dict[str, Any] = {
+        "type": configuration.get("type") or "http",
+        "url": endpoint,
+    }
+
+    auth_type = configuration.get("auth_type")
+    auth_token = configuration.get("auth_token")
+    if auth_token and auth_type == "bearer":
+        config["headers"] = {"Authorization": f"Bearer {auth_token}"}
+    elif auth_token and auth_type == "api_key":
+        header_name = configuration.get("auth_header") or "X-API-Key"
+        config["headers"] = {header_name: auth_token}
+
+    return config
+
+
 def convert_db_server_to_mcp_config(
     server: dict[str, Any], 
     *, 
     oauth_token: str | None = None,
-    user_token: str | None = None
+    user_token: str | None = None,
+    sandbox_scope: Sequence[str] = (),
 ) -> dict[str, Any]:
     """
     Convert database MCP server record to MCP server configuration format.
@@ -211,6 +369,7 @@ def convert_db_server_to_mcp_config(
         server: Database record from mcp_servers table
         oauth_token: Optional OAuth token to use for authentication
         user_token: Optional user token (AuthKit JWT) for internal MCPs
+        sandbox_scope: Owner IDs (e.g. user, session) keying the stdio sandbox directory
 
     Returns:
         MCP server configuration dict compatible with Claude Agent SDK
@@ -230,6 +389,14 @@ def convert_db_server_to_mcp_config(
         if env:
             config["env"] = env
 
+        sandbox = get_config().mcp_stdio_sandbox
+        if sandbox is not None and config["command"]:
+            config = sandbox_stdio_config(
+                config,
+                StdioSandboxPolicy.from_config(sandbox),
+                server_id=str(server.get("id") or server.get("name")),
+                scope=sandbox_scope,
+            )
         return config
 
     elif transport_type in ("http", "sse"):
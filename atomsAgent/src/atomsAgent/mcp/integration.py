@@ -68,7 +68,8 @@ async def compose_mcp_servers(
     org_id: str | None = None,
     project_id: str | None = None,
     additional_servers: dict[str, Any] | None = None,
-    user_token: str | None = None
+    user_token: str | None = None,
+    session_id: str | None = None,
 ) -> dict[str, Any]:
     """
     Compose MCP servers based on user/org/project context.
@@ -78,21 +79,29 @@ async def compose_mcp_servers(
     2. Add user-specific servers from database
     3. Add org-specific servers from database
     4. Add project-specific servers from database
-    5. Add any additional servers passed in
+    5. Add platform servers the org has not opted out of
+    6. Add any additional servers passed in
     
     Args:
         user_id: User ID to fetch user-specific servers
         org_id: Organization ID to fetch org-specific servers
         project_id: Project ID to fetch project-specific servers
         additional_servers: Additional MCP servers to include
+        session_id: Chat session the servers are started for; stdio servers get
+            a sandbox directory per user and session
     
     Returns:
         Dictionary with all composed MCP server configurations
     """
     from atomsAgent.mcp.database import (
         convert_db_server_to_mcp_config,
+        convert_mcp_configuration_to_mcp_config,
         get_active_profile_servers,
+        get_default_mcp_opt_outs,
+        get_org_default_mcp_configurations,
         get_org_mcp_servers,
+        get_platform_mcp_configurations,
+        get_platform_mcp_opt_outs,
         get_project_mcp_servers,
         get_user_mcp_servers,
     )
@@ -167,7 +176,17 @@ async def compose_mcp_servers(
             org_uuid,
         )
         return None
+
+    async def _resolve_auth_token(configuration: dict[str, Any]) -> dict[str, Any]:
+        if not configuration.get("auth_token"):
+            return configuration
+        from atomsAgent.dependencies import get_secret_store  # avoid circular
+
+        secret = await get_secret_store().resolve(configuration["auth_token"])
+        return {**configuration, "auth_token": secret}
     
+    sandbox_scope = [part for part in (user_id or org_id, session_id) if part]
+
     # Start with default servers
     servers = get_default_mcp_servers()
     
@@ -192,6 +211,7 @@ async def compose_mcp_servers(
                     server_record,
                     oauth_token=oauth_token,
                     user_token=user_token,
+                    sandbox_scope=sandbox_scope,
                 )
                 if server_config:
                     servers[server_name] = server_config
@@ -215,10 +235,26 @@ async def compose_mcp_servers(
                     server_record,
                     oauth_token=oauth_token,
                     user_token=user_token,
+                    sandbox_scope=sandbox_scope,
                 )
                 if server_config:
                     servers[server_name] = server_config
                     logger.debug(f"Added org server: {server_name}")
+
+            # Attach org-default configurations unless the user opted out
+            opted_out = await get_default_mcp_opt_outs(user_id) if user_id else set()
+            for configuration in await get_org_default_mcp_configurations(org_id):
+                if str(configuration.get("id")) in opted_out:
+                    logger.debug(f"User opted out of default MCP: {configuration.get('name')}")
+                    continue
+                server_name = f"org_{configuration['name']}"
+                if server_name in servers:
+                    continue
+                configuration = await _resolve_auth_token(configuration)
+                server_config = convert_mcp_configuration_to_mcp_config(configuration)
+                if server_config:
+                    servers[server_name] = server_config
+                    logger.debug(f"Added org default server: {server_name}")
         except Exception as e:
             message = str(e)
             if "Supabase credentials not configured" in message:
@@ -238,6 +274,7 @@ async def compose_mcp_servers(
                     server_record,
                     oauth_token=oauth_token,
                     user_token=user_token,
+                    sandbox_scope=sandbox_scope,
                 )
                 if server_config:
                     servers[server_name] = server_config
@@ -249,6 +286,26 @@ async def compose_mcp_servers(
             else:
                 logger.error(f"Error loading project MCP servers: {message}")
     
+    # Attach platform servers unless the organization opted out
+    try:
+        opted_out = await get_platform_mcp_opt_outs(org_id) if org_id else set()
+        for configuration in await get_platform_mcp_configurations():
+            if str(configuration.get("id")) in opted_out:
+                logger.debug(f"Org opted out of platform MCP: {configuration.get('name')}")
+                continue
+            server_name = f"platform_{configuration['name']}"
+            configuration = await _resolve_auth_token(configuration)
+            server_config = convert_mcp_configuration_to_mcp_config(configuration)
+            if server_config:
+                servers[server_name] = server_config
+                logger.debug(f"Added platform server: {server_name}")
+    except Exception as e:
+        message = str(e)
+        if "Supabase credentials not configured" in message:
+            logger.debug("Supabase not configured; skipping platform MCP servers")
+        else:
+            logger.error(f"Error loading platform MCP servers: {message}")
+    
     # Add additional servers
     if additional_servers:
         servers.update(additional_servers)
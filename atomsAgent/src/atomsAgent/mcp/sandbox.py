@@ -0,0 +1,188 @@
+"""
+Sandboxed launcher for stdio MCP servers
+
+stdio MCPs are spawned by the Claude Agent SDK from a ``command``/``args``
+config. ``sandbox_stdio_config`` rewrites that config so the SDK starts this
+module instead, which then runs the real command:
+
+- in a working directory per user, session and server (also used as ``HOME``
+  and ``TMPDIR``),
+- with only allowlisted and explicitly configured environment variables,
+- under CPU-time, data-segment and open-file rlimits,
+- optionally killed after a wall-clock limit,
+- and refused outright once it has exited abnormally ``max_restarts`` times
+  within ``restart_window_seconds``.
+
+This limits resource use and environment leakage; it is not isolation. The
+server runs as the service user with that user's filesystem and network
+access, and the working directory is only where it starts. Run untrusted
+servers in a container or under a separate user instead.
+
+Usage: ``python -m atomsAgent.mcp.sandbox [options] -- <command> [args...]``
+"""
+
+from __future__ import annotations
+
+import argparse
+import os
+import re
+import signal
+import subprocess
+import sys
+import time
+from collections.abc import Sequence
+from dataclasses import dataclass, field
+from typing import Any
+
+DEFAULT_ENV_ALLOWLIST = ("PATH", "LANG", "LC_ALL", "TZ")
+EXITS_FILE = ".sandbox-exits"
+EXIT_RESTART_LIMIT = 75  # EX_TEMPFAIL
+EXIT_TIMEOUT = 124
+
+
+@dataclass
+class StdioSandboxPolicy:
+    workdir_root: str = "/tmp/atoms-mcp"
+    env_allowlist: list[str] = field(default_factory=lambda: list(DEFAULT_ENV_ALLOWLIST))
+    cpu_seconds: int | None = 300
+    # RLIMIT_DATA, not RLIMIT_AS: runtimes such as Node reserve far more
+    # address space than they use. Off by default; prefer cgroups for hard caps.
+    memory_mb: int | None = None
+    max_open_files: int | None = 256
+    # Wall-clock limit for one-shot servers; long-lived servers leave it off.
+    timeout_seconds: float | None = None
+    max_restarts: int | None = 5
+    restart_window_seconds: float = 600
+
+    @classmethod
+    def from_config(cls, raw: dict[str, Any]) -> StdioSandboxPolicy:
+        unknown = set(raw) - set(cls.__dataclass_fields__)
+        if unknown:
+            raise ValueError(f"Unknown stdio sandbox options: {', '.join(sorted(unknown))}")
+        return cls(**raw)
+
+
+def sandbox_stdio_config(
+    config: dict[str, Any],
+    policy: StdioSandboxPolicy,
+    *,
+    server_id: str,
+    scope: Sequence[str] = (),
+) -> dict[str, Any]:
+    """Wrap a ``{"command", "args", "env"}`` MCP config in the sandbox launcher.
+
+    ``scope`` (e.g. user and session IDs) nests the working directory, so
+    servers started for different users or sessions share neither files nor
+    restart history.
+    """
+    parts = [re.sub(r"[^A-Za-z0-9_.-]", "_", part) for part in (*scope, server_id)]
+    workdir = os.path.join(policy.workdir_root, *parts)
+    launcher = [sys.executable, "-m", "atomsAgent.mcp.sandbox", "--workdir", workdir]
+    for option, value in (
+        ("--cpu-seconds", policy.cpu_seconds),
+        ("--memory-mb", policy.memory_mb),
+        ("--max-open-files", policy.max_open_files),
+        ("--timeout-seconds", policy.timeout_seconds),
+        ("--max-restarts", policy.max_restarts),
+    ):
+        if value is not None:
+            launcher += [option, str(value)]
+    launcher += ["--restart-window-seconds", str(policy.restart_window_seconds)]
+    # Configured variables reach the launcher through the SDK, so they are allowed
+    # through alongside the host allowlist.
+    for name in [*policy.env_allowlist, *(config.get("env") or {})]:
+        launcher += ["--allow-env", name]
+    return {
+        **config,
+        "command": launcher[0],
+        "args": [*launcher[1:], "--", config["command"], *(config.get("args") or [])],
+    }
+
+
+def _recent_exits(path: str, window_seconds: float) -> list[float]:
+    try:
+        with open(path, encoding="utf-8") as handle:
+            stamps = [float(line) for line in handle if line.strip()]
+    except (OSError, ValueError):
+        return []
+    cutoff = time.time() - window_seconds
+    return [stamp for stamp in stamps if stamp >= cutoff]
+
+
+def _set_limits(args: argparse.Namespace) -> None:
+    import resource
+
+    if args.cpu_seconds is not None:
+        resource.setrlimit(resource.RLIMIT_CPU, (args.cpu_seconds, args.cpu_seconds))
+    if args.memory_mb is not None:
+        limit = args.memory_mb * 1024 * 1024
+        resource.setrlimit(resource.RLIMIT_DATA, (limit, limit))
+    if args.max_open_files is not None:
+        resource.setrlimit(resource.RLIMIT_NOFILE, (args.max_open_files, args.max_open_files))
+
+
+def main(argv: Sequence[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(prog="python -m atomsAgent.mcp.sandbox")
+    parser.add_argument("--workdir", required=True)
+    parser.add_argument("--cpu-seconds", type=int)
+    parser.add_argument("--memory-mb", type=int)
+    parser.add_argument("--max-open-files", type=int)
+    parser.add_argument("--timeout-seconds", type=float)
+    parser.add_argument("--max-restarts", type=int)
+    parser.add_argument("--restart-window-seconds", type=float, default=600)
+    parser.add_argument("--allow-env", action="append", default=[])
+    parser.add_argument("command", nargs=argparse.REMAINDER)
+    args = parser.parse_args(argv)
+    command = args.command[1:] if args.command[:1] == ["--"] else args.command
+    if not command:
+        parser.error("no command given")
+
+    os.makedirs(args.workdir, mode=0o700, exist_ok=True)
+    exits_path = os.path.join(args.workdir, EXITS_FILE)
+    recent = _recent_exits(exits_path, args.restart_window_seconds)
+    if args.max_restarts is not None and len(recent) >= args.max_restarts:
+        print(
+            f"MCP server exited abnormally {len(recent)} times in the last "
+            f"{args.restart_window_seconds:g}s; refusing to restart",
+            file=sys.stderr,
+        )
+        return EXIT_RESTART_LIMIT
+
+    env = {name: os.environ[name] for name in args.allow_env if name in os.environ}
+    env.update(HOME=args.workdir, TMPDIR=args.workdir)
+    process = subprocess.Popen(
+        command, cwd=args.workdir, env=env, preexec_fn=lambda: _set_limits(args)
+    )
+
+    # Signalled shutdowns and wall-clock kills are not crashes.
+    stopping = False
+
+    def _forward(signum: int, _frame: Any) -> None:
+        nonlocal stopping
+        stopping = True
+        process.send_signal(signum)
+
+    for signum in (signal.SIGTERM, signal.SIGINT):
+        signal.signal(signum, _forward)
+
+    try:
+        returncode = process.wait(timeout=args.timeout_seconds)
+    except subprocess.TimeoutExpired:
+        print(f"MCP server exceeded {args.timeout_seconds:g}s; killing it", file=sys.stderr)
+        stopping = True
+        process.terminate()
+        try:
+            process.wait(timeout=5)
+        except subprocess.TimeoutExpired:
+            process.kill()
+            process.wait()
+        returncode = EXIT_TIMEOUT
+
+    if returncode != 0 and not stopping:
+        with open(exits_path, "w", encoding="utf-8") as handle:
+            handle.writelines(f"{stamp}\n" for stamp in [*recent, time.time()])
+    return 128 - returncode if returncode < 0 else returncode
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -1,15 +1,19 @@
 from __future__ import annotations
 
-from typing import Literal
+from datetime import datetime
+from typing import Any, Literal
 from uuid import UUID
 
 from pydantic import BaseModel, Field, HttpUrl
 
 AuthTypeLiteral = Literal["none", "bearer", "oauth", "api_key"]
+MCPScopeType = Literal["platform", "organization", "user"]
+MCPSortField = Literal["name", "created_at"]
+MCPUsageGroupBy = Literal["day", "tool", "organization"]
 
 
 class MCPScope(BaseModel):
-    type: Literal["platform", "organization", "user"]
+    type: MCPScopeType
     organization_id: UUID | None = None
     user_id: UUID | None = None
 
@@ -28,9 +32,13 @@ class MCPConfiguration(BaseModel):
     bearer_token_id: UUID | None = None
     oauth_provider: str | None = None
     enabled: bool = True
+    is_default: bool = False
     metadata: MCPMetadata = Field(default_factory=MCPMetadata)
     created_at: str | None = None
     scope: MCPScope
+    opted_out: bool = Field(
+        default=False, description="Platform MCP the listing organization opted out of"
+    )
 
 
 class MCPCreateRequest(BaseModel):
@@ -41,6 +49,7 @@ class MCPCreateRequest(BaseModel):
     bearer_token: str | None = None
     oauth_provider: str | None = None
     enabled: bool = True
+    is_default: bool = False
     metadata: MCPMetadata = Field(default_factory=MCPMetadata)
     scope: MCPScope
 
@@ -53,8 +62,201 @@ class MCPUpdateRequest(BaseModel):
     bearer_token: str | None = None
     oauth_provider: str | None = None
     enabled: bool | None = None
+    is_default: bool | None = None
     metadata: MCPMetadata | None = None
 
 
 class MCPListResponse(BaseModel):
     items: list[MCPConfiguration]
+    total: int | None = Field(default=None, description="Matches before pagination")
+    limit: int | None = None
+    offset: int = 0
+    next_offset: int | None = Field(default=None, description="Offset of the next page, if any")
+
+
+class MCPConfigVersion(BaseModel):
+    version: int
+    action: str = Field(description="mcp_config.create, mcp_config.update or mcp_config.rollback")
+    changed_at: str | None = None
+    changed_by: str | None = None
+    changes: dict[str, dict[str, Any]] = Field(
+        default_factory=dict, description="Field diff against the previous version; secrets masked"
+    )
+    snapshot: dict[str, Any] = Field(
+        default_factory=dict, description="Non-secret fields as of this version"
+    )
+
+
+class MCPConfigVersionListResponse(BaseModel):
+    items: list[MCPConfigVersion]
+
+
+class MCPTemplateCredential(BaseModel):
+    key: str
+    label: str
+    target: str = Field(description="'bearer_token' or 'env:<NAME>'")
+    required: bool = True
+
+
+class MCPTemplate(BaseModel):
+    key: str
+    display_name: str
+    description: str | None = None
+    endpoint: HttpUrl
+    endpoint_overridable: bool = False
+    auth_type: AuthTypeLiteral = Field(default="none")
+    oauth_provider: str | None = None
+    credentials: list[MCPTemplateCredential] = Field(default_factory=list)
+
+
+class MCPCatalogResponse(BaseModel):
+    items: list[MCPTemplate]
+
+
+class MCPTemplateCreateRequest(BaseModel):
+    name: str | None = None
+    endpoint: HttpUrl | None = None
+    credentials: dict[str, str] = Field(default_factory=dict)
+    enabled: bool = True
+    is_default: bool = False
+    scope: MCPScope
+
+
+class MCPDrainRequest(BaseModel):
+    timeout_seconds: float = Field(default=30.0, gt=0, le=600)
+
+
+class MCPDrainResponse(BaseModel):
+    id: UUID
+    disconnected: bool = Field(description="Whether a live connection was closed")
+
+
+class MCPToolInfo(BaseModel):
+    name: str
+    description: str | None = None
+    input_schema: dict[str, Any] = Field(default_factory=dict)
+
+
+class MCPToolListResponse(BaseModel):
+    items: list[MCPToolInfo]
+
+
+class MCPToolCallRequest(BaseModel):
+    arguments: dict[str, Any] = Field(default_factory=dict)
+    stream: bool = Field(
+        default=False,
+        description="Respond with text/event-stream progress, chunk and done events",
+    )
+
+
+class MCPToolCallResponse(BaseModel):
+    tool: str
+    is_error: bool = Field(default=False, description="Whether the tool reported a failure")
+    content: list[dict[str, Any]] = Field(default_factory=list)
+    structured_content: dict[str, Any] | None = None
+
+
+class MCPResourceInfo(BaseModel):
+    uri: str
+    name: str
+    description: str | None = None
+    mime_type: str | None = None
+
+
+class MCPResourceListResponse(BaseModel):
+    items: list[MCPResourceInfo]
+
+
+class MCPResourceReadResponse(BaseModel):
+    uri: str
+    contents: list[dict[str, Any]] = Field(
+        default_factory=list, description="Text or base64 blob contents as returned by the server"
+    )
+
+
+class MCPPromptArgument(BaseModel):
+    name: str
+    description: str | None = None
+    required: bool = False
+
+
+class MCPPromptInfo(BaseModel):
+    name: str
+    description: str | None = None
+    arguments: list[MCPPromptArgument] = Field(default_factory=list)
+
+
+class MCPPromptListResponse(BaseModel):
+    items: list[MCPPromptInfo]
+
+
+class MCPPromptRenderRequest(BaseModel):
+    arguments: dict[str, str] = Field(default_factory=dict)
+
+
+class MCPPromptRenderResponse(BaseModel):
+    name: str
+    description: str | None = None
+    messages: list[dict[str, Any]] = Field(default_factory=list)
+
+
+class MCPCircuitBreakerInfo(BaseModel):
+    state: Literal["closed", "open", "half_open"] = "closed"
+    consecutive_failures: int = 0
+    total_failures: int = 0
+    total_successes: int = 0
+    rejected: int = Field(default=0, description="Calls refused while open")
+    opened_at: datetime | None = None
+
+
+class MCPHealthResponse(BaseModel):
+    id: UUID
+    name: str | None = None
+    status: Literal["healthy", "unhealthy", "unknown"] = "unknown"
+    last_checked_at: datetime | None = None
+    last_success_at: datetime | None = None
+    last_error: str | None = None
+    last_error_at: datetime | None = None
+    latency_ms: float | None = None
+    consecutive_failures: int = 0
+    breakers: dict[str, MCPCircuitBreakerInfo] = Field(
+        default_factory=dict, description="Circuit breakers by operation"
+    )
+
+
+class MCPToolPolicy(BaseModel):
+    """Per-organization restrictions on callable MCP tools.
+
+    Entries are ``fnmatch`` patterns of the form ``"<mcp name>"`` (every tool on
+    that server) or ``"<mcp name>/<tool>"``. Deny wins over allow; a non-empty
+    allow list blocks everything it does not match.
+
+    ``sampling`` lists ``"<mcp name>"`` patterns whose servers may ask the agent
+    for LLM completions; it is empty, denying every sampling request, by default.
+    """
+
+    allow: list[str] = Field(default_factory=list)
+    deny: list[str] = Field(default_factory=list)
+    sampling: list[str] = Field(default_factory=list)
+
+
+class MCPUsageBucket(BaseModel):
+    key: str = Field(description="Day (YYYY-MM-DD), '<mcp name>/<tool>' or organization ID")
+    mcp_id: str | None = Field(default=None, description="Set when grouping by tool")
+    calls: int = 0
+    failures: int = 0
+    users: int = Field(default=0, description="Distinct users that made calls")
+    avg_latency_ms: float | None = None
+    p95_latency_ms: float | None = None
+    request_bytes: int = 0
+    response_bytes: int = 0
+
+
+class MCPUsageResponse(BaseModel):
+    group_by: MCPUsageGroupBy
+    since: datetime
+    until: datetime
+    items: list[MCPUsageBucket]
+    truncated: bool = Field(
+        default=False, description="More calls matched than were read; totals are partial"
+    )
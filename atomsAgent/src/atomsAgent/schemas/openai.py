@@ -67,6 +67,7 @@ class ChatCompletionResponse(BaseModel):
     choices: list[ChatCompletionChoice]
     usage: UsageInfo
     system_fingerprint: str | None = None
+    resume_token: str | None = None
 
 
 class ModelInfo(BaseModel):
@@ -1,12 +1,34 @@
 from __future__ import annotations
 
+from datetime import datetime
+from typing import Literal
+
 from pydantic import BaseModel, Field
 
 
+class ErrorBudgetStatus(BaseModel):
+    events: int
+    errors: int
+    errors_by_code: dict[str, int] = Field(default_factory=dict)
+    error_rate: float
+    burn_rate: float = Field(description="Error rate divided by the budget threshold")
+    exhausted: bool
+
+
 class SystemHealth(BaseModel):
     status: str = "healthy"
     circuit_breaker_status: str | None = None
+    error_budget_status: str | None = None
     active_agents: list[str] = Field(default_factory=list)
+    mcp_servers: dict[str, int] | None = Field(
+        default=None, description="MCP configurations by health status, when monitoring is on"
+    )
+    mcp_breakers: dict[str, int] | None = Field(
+        default=None, description="MCP circuit breakers by state"
+    )
+    error_budgets: dict[str, ErrorBudgetStatus] | None = Field(
+        default=None, description="Operations with outcomes in the error budget window"
+    )
 
 
 class PlatformStats(BaseModel):
@@ -61,3 +83,65 @@ class AddAdminRequest(BaseModel):
 class AdminResponse(BaseModel):
     status: str = "success"
     email: str
+
+
+class AgentSessionInfo(BaseModel):
+    session_id: str
+    user_id: str | None = None
+    organization_id: str | None = None
+    model: str
+    state: str
+    created_at: datetime
+    last_used_at: datetime
+    idle_expires_at: datetime
+    tool_usage_count: int = 0
+    permission_requests: int = 0
+    prompt_tokens: int = 0
+    completion_tokens: int = 0
+    workspace_bytes: int | None = None
+    mcp_servers: dict[str, str] = Field(default_factory=dict)
+
+
+class AgentSessionListResponse(BaseModel):
+    sessions: list[AgentSessionInfo]
+    count: int
+
+
+class TerminateSessionRequest(BaseModel):
+    reason: str = "terminated by platform administrator"
+
+
+class TerminateSessionResponse(BaseModel):
+    status: str = "terminated"
+    session_id: str
+
+
+class CacheStatsInfo(BaseModel):
+    name: str
+    entries: int
+    current_bytes: int
+    max_bytes: int
+    hits: int
+    misses: int
+    evictions: int
+
+
+class CacheStatsResponse(BaseModel):
+    caches: list[CacheStatsInfo]
+
+
+LogLevelLiteral = Literal["CRITICAL", "ERROR", "WARNING", "INFO", "DEBUG"]
+
+
+class LogLevelInfo(BaseModel):
+    logger: str = Field(description="Logger name; 'root' for the root logger")
+    level: str
+
+
+class LogLevelListResponse(BaseModel):
+    loggers: list[LogLevelInfo]
+
+
+class LogLevelUpdateRequest(BaseModel):
+    logger: str = Field(default="root", description="Logger name, e.g. 'atomsAgent.mcp'")
+    level: LogLevelLiteral
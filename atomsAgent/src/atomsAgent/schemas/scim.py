@@ -0,0 +1,77 @@
+from __future__ import annotations
+
+from typing import Any, Literal
+
+from pydantic import BaseModel, ConfigDict, Field
+
+SCIM_USER_SCHEMA = "urn:ietf:params:scim:schemas:core:2.0:User"
+SCIM_GROUP_SCHEMA = "urn:ietf:params:scim:schemas:core:2.0:Group"
+SCIM_LIST_SCHEMA = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+SCIM_PATCH_SCHEMA = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+SCIM_ERROR_SCHEMA = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+
+class SCIMModel(BaseModel):
+    model_config = ConfigDict(populate_by_name=True, extra="ignore")
+
+
+class SCIMMeta(SCIMModel):
+    resource_type: str = Field(alias="resourceType")
+    created: str | None = None
+    last_modified: str | None = Field(default=None, alias="lastModified")
+
+
+class SCIMName(SCIMModel):
+    formatted: str | None = None
+    given_name: str | None = Field(default=None, alias="givenName")
+    family_name: str | None = Field(default=None, alias="familyName")
+
+
+class SCIMEmail(SCIMModel):
+    value: str
+    primary: bool = False
+    type: str | None = None
+
+
+class SCIMUser(SCIMModel):
+    schemas: list[str] = Field(default_factory=lambda: [SCIM_USER_SCHEMA])
+    id: str | None = None
+    external_id: str | None = Field(default=None, alias="externalId")
+    user_name: str = Field(alias="userName")
+    name: SCIMName | None = None
+    display_name: str | None = Field(default=None, alias="displayName")
+    emails: list[SCIMEmail] = Field(default_factory=list)
+    active: bool = True
+    meta: SCIMMeta | None = None
+
+
+class SCIMGroupMember(SCIMModel):
+    value: str
+    display: str | None = None
+
+
+class SCIMGroup(SCIMModel):
+    schemas: list[str] = Field(default_factory=lambda: [SCIM_GROUP_SCHEMA])
+    id: str | None = None
+    display_name: str = Field(alias="displayName")
+    members: list[SCIMGroupMember] = Field(default_factory=list)
+    meta: SCIMMeta | None = None
+
+
+class SCIMListResponse(SCIMModel):
+    schemas: list[str] = Field(default_factory=lambda: [SCIM_LIST_SCHEMA])
+    total_results: int = Field(alias="totalResults")
+    start_index: int = Field(default=1, alias="startIndex")
+    items_per_page: int = Field(alias="itemsPerPage")
+    resources: list[dict[str, Any]] = Field(default_factory=list, alias="Resources")
+
+
+class SCIMPatchOperation(SCIMModel):
+    op: Literal["add", "remove", "replace", "Add", "Remove", "Replace"]
+    path: str | None = None
+    value: Any = None
+
+
+class SCIMPatchRequest(SCIMModel):
+    schemas: list[str] = Field(default_factory=lambda: [SCIM_PATCH_SCHEMA])
+    operations: list[SCIMPatchOperation] = Field(alias="Operations")
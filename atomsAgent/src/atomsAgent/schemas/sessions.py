@@ -0,0 +1,24 @@
+from __future__ import annotations
+
+from datetime import datetime
+from typing import Literal
+
+from pydantic import BaseModel, Field
+
+SessionStateLiteral = Literal["idle", "thinking", "interrupted", "disconnected", "terminated"]
+
+
+class MCPConnectionStatus(BaseModel):
+    name: str
+    status: str
+
+
+class SessionHeartbeatResponse(BaseModel):
+    session_id: str
+    state: SessionStateLiteral
+    agent_alive: bool
+    mcp_healthy: bool
+    mcp_servers: list[MCPConnectionStatus] = Field(default_factory=list)
+    last_used_at: datetime
+    idle_expires_at: datetime
+    idle_timeout_seconds: int
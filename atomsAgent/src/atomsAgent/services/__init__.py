@@ -11,7 +11,20 @@ from atomsAgent.services.claude_client import (
     create_session_manager,
     default_session_id,
 )
+from atomsAgent.services.concurrency import ConcurrencyLimitExceeded, ConcurrencyLimiter
+from atomsAgent.services.error_budget import ErrorBudgetTracker
+from atomsAgent.services.mcp_breakers import MCPCircuitBreakers
+from atomsAgent.services.mcp_catalog import MCPCatalog
+from atomsAgent.services.mcp_connections import (
+    MCPConnectionDrainingError,
+    MCPConnectionManager,
+)
+from atomsAgent.services.mcp_health import MCPHealth, MCPHealthMonitor
+from atomsAgent.services.mcp_policy import MCPToolPolicyService
 from atomsAgent.services.mcp_registry import MCPRegistryService
+from atomsAgent.services.mcp_server_events import MCPSamplingError, MCPServerEvents
+from atomsAgent.services.mcp_tools import MCPToolError, MCPToolService
+from atomsAgent.services.mcp_usage import MCPUsageMeter
 from atomsAgent.services.platform import PlatformService
 from atomsAgent.services.prompts import PromptOrchestrator
 from atomsAgent.services.sandbox import SandboxContext, SandboxManager
@@ -23,7 +36,22 @@ __all__ = [
     "ClaudeSessionManager",
     "CompletionChunk",
     "CompletionResult",
+    "ConcurrencyLimitExceeded",
+    "ConcurrencyLimiter",
+    "ErrorBudgetTracker",
+    "MCPCatalog",
+    "MCPCircuitBreakers",
+    "MCPConnectionDrainingError",
+    "MCPConnectionManager",
+    "MCPHealth",
+    "MCPHealthMonitor",
     "MCPRegistryService",
+    "MCPSamplingError",
+    "MCPServerEvents",
+    "MCPToolError",
+    "MCPToolPolicyService",
+    "MCPToolService",
+    "MCPUsageMeter",
     "PlatformService",
     "PromptOrchestrator",
     "SandboxContext",
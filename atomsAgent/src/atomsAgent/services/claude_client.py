@@ -3,6 +3,7 @@
 from __future__ import annotations
 
 import asyncio
+import contextlib
 import logging
 import os
 import tempfile
@@ -16,7 +17,13 @@ from typing import Any
 from tenacity import AsyncRetrying, retry_if_exception_type, stop_after_attempt, wait_fixed
 
 from atomsAgent.config import settings
+from atomsAgent.services.mcp_policy import MCPToolPolicyService, chat_tool_hook
 from atomsAgent.services.sandbox import SandboxContext, SandboxManager
+from atomsAgent.services.session_tokens import (
+    SessionResumeCodec,
+    SessionResumeState,
+    check_resume_state,
+)
 
 try:
     from claude_agent_sdk import (
@@ -164,6 +171,7 @@ class CompletionResult:
     raw_messages: list[Any]
     interrupted: bool = False
     permission_requests: list[dict[str, Any]] = field(default_factory=list)
+    resume_token: str | None = None
 
 
 @dataclass(slots=True)
@@ -175,6 +183,8 @@ class CompletionChunk:
     usage: UsageStats | None = None
     tool_use: dict[str, Any] | None = None
     permission_request: dict[str, Any] | None = None
+    termination_reason: str | None = None
+    resume_token: str | None = None
 
 
 @dataclass(slots=True)
@@ -195,6 +205,9 @@ class SessionConfig:
     hooks: dict[str, list[Any]] | None = None
     max_turns: int | None = None
     include_partial_messages: bool = False
+    user_id: str | None = None
+    organization_id: str | None = None
+    resume: str | None = None
 
 
 @dataclass
@@ -209,6 +222,41 @@ class ClaudeSession:
     interrupted: bool = False
     permission_requests: list[dict[str, Any]] = field(default_factory=list)
     tool_usage_count: int = 0
+    idle_timeout: float = 3600.0
+    mcp_status: dict[str, str] = field(default_factory=dict)
+    created_at: float = field(default_factory=time.time)
+    prompt_tokens: int = 0
+    completion_tokens: int = 0
+    termination_reason: str | None = None
+    sdk_session_id: str | None = None
+
+    @property
+    def busy(self) -> bool:
+        """True while a completion is running against this session."""
+        return self.lock.locked()
+
+    @property
+    def state(self) -> str:
+        if self.termination_reason is not None:
+            return "terminated"
+        if self.interrupted:
+            return "interrupted"
+        if self.busy:
+            return "thinking"
+        if not self.connected:
+            return "disconnected"
+        return "idle"
+
+    @property
+    def idle_expires_at(self) -> float:
+        return self.last_used + self.idle_timeout
+
+    def touch(self) -> None:
+        self.last_used = time.time()
+
+    def record_usage(self, usage: UsageStats) -> None:
+        self.prompt_tokens += usage.prompt_tokens
+        self.completion_tokens += usage.completion_tokens
 
     async def ensure_connected(self) -> None:
         if not self.connected:
@@ -237,6 +285,10 @@ class ClaudeSessionManager:
         default_allowed_tools: list[str] | None = None,
         default_setting_sources: list[str] | None = None,
         default_hooks: dict[str, list[Any]] | None = None,
+        idle_timeout_seconds: float = 3600.0,
+        reap_interval_seconds: float = 60.0,
+        resume_codec: SessionResumeCodec | None = None,
+        tool_policies: MCPToolPolicyService | None = None,
     ) -> None:
         if _IMPORT_ERROR is not None:
             raise RuntimeError(
@@ -248,7 +300,13 @@ class ClaudeSessionManager:
         self._default_allowed_tools = default_allowed_tools or []
         self._default_setting_sources = default_setting_sources or ["project"]
         self._default_hooks = default_hooks or {}
+        self._idle_timeout_seconds = idle_timeout_seconds
+        self._reap_interval_seconds = reap_interval_seconds
+        self._resume_codec = resume_codec
+        # Organization MCP tool policies, checked before every MCP tool call.
+        self._tool_policies = tool_policies
         self._lock = asyncio.Lock()
+        self._reaper: asyncio.Task[None] | None = None
 
     async def get_session(
         self,
@@ -259,7 +317,7 @@ class ClaudeSessionManager:
         async with self._lock:
             session = self._sessions.get(session_id)
             if session is not None:
-                session.last_used = time.time()
+                session.touch()
                 return session
 
             sandbox = await self._sandbox_manager.acquire(session_id)
@@ -322,6 +380,11 @@ class ClaudeSessionManager:
                 "PostToolUse": [HookMatcher(hooks=[post_tool_hook])],  # type: ignore[list-item]
                 "UserPromptSubmit": [HookMatcher(hooks=[prompt_hook])],  # type: ignore[list-item]
             }
+            if self._tool_policies is not None and config.organization_id:
+                policy_hook = chat_tool_hook(self._tool_policies, config.organization_id)
+                hooks["PreToolUse"].append(
+                    HookMatcher(hooks=[policy_hook])  # type: ignore[list-item]
+                )
 
             # Merge with user-provided hooks
             if config.hooks:
@@ -392,6 +455,7 @@ class ClaudeSessionManager:
                 hooks=hooks,  # type: ignore[arg-type]
                 max_turns=config.max_turns,
                 include_partial_messages=config.include_partial_messages,
+                resume=config.resume,
                 extra_args={},
             )
             client = ClaudeSDKClient(options=options)
@@ -400,10 +464,142 @@ class ClaudeSessionManager:
                 sandbox=sandbox,
                 config=config,
                 client=client,
+                idle_timeout=self._idle_timeout_seconds,
             )
             self._sessions[session_id] = session
             return session
 
+    def find_session(self, session_id: str) -> ClaudeSession | None:
+        """Return an active session without creating or touching it."""
+        return self._sessions.get(session_id)
+
+    def list_sessions(
+        self,
+        *,
+        organization_id: str | None = None,
+        user_id: str | None = None,
+        model: str | None = None,
+    ) -> list[ClaudeSession]:
+        sessions = list(self._sessions.values())
+        if organization_id is not None:
+            sessions = [s for s in sessions if s.config.organization_id == organization_id]
+        if user_id is not None:
+            sessions = [s for s in sessions if s.config.user_id == user_id]
+        if model is not None:
+            sessions = [s for s in sessions if s.config.model == model]
+        return sorted(sessions, key=lambda s: s.last_used, reverse=True)
+
+    def issue_resume_token(self, session_id: str) -> str | None:
+        """Return an encrypted token that resumes this session once it has left memory."""
+        session = self._sessions.get(session_id)
+        if self._resume_codec is None or session is None:
+            return None
+        return self._resume_codec.issue(
+            SessionResumeState(
+                session_id=session.session_id,
+                sdk_session_id=session.sdk_session_id,
+                model=session.config.model,
+                user_id=session.config.user_id,
+                organization_id=session.config.organization_id,
+            )
+        )
+
+    def decode_resume_token(self, token: str) -> SessionResumeState:
+        if self._resume_codec is None:
+            raise ValueError("session resume tokens are not enabled")
+        return self._resume_codec.decode(token)
+
+    async def terminate_session(
+        self, session_id: str, *, reason: str, grace_seconds: float = 5.0
+    ) -> ClaudeSession | None:
+        """Forcefully stop a session, giving an in-flight stream time to notify its client."""
+        session = self._sessions.get(session_id)
+        if session is None:
+            return None
+        session.termination_reason = reason
+        if session.busy:
+            try:
+                await session.interrupt()
+            except Exception as exc:  # pragma: no cover - depends on CLI state
+                logger.warning("Failed to interrupt session %s: %s", session_id, exc)
+            try:
+                await asyncio.wait_for(session.lock.acquire(), timeout=grace_seconds)
+                session.lock.release()
+            except asyncio.TimeoutError:
+                logger.warning(
+                    "Session %s still busy after %.1fs; disconnecting anyway",
+                    session_id,
+                    grace_seconds,
+                )
+        await self.release_session(session_id)
+        return session
+
+    async def heartbeat(
+        self,
+        session_id: str,
+        *,
+        user_id: str | None = None,
+        organization_id: str | None = None,
+    ) -> ClaudeSession | None:
+        """Extend the idle timeout of an active session owned by the caller and return it.
+
+        A session started for a user or organization is only visible to that same
+        user or organization; anything else is reported as not found.
+        """
+        session = self._sessions.get(session_id)
+        if session is None:
+            return None
+        if session.config.user_id and session.config.user_id != user_id:
+            return None
+        if session.config.organization_id and session.config.organization_id != organization_id:
+            return None
+        session.touch()
+        return session
+
+    async def reap_idle(self) -> list[str]:
+        """Release sessions untouched for longer than their idle timeout; return their IDs."""
+        reaped: list[str] = []
+        for session in list(self._sessions.values()):
+            if session.busy or time.time() < session.idle_expires_at:
+                continue
+            async with self._lock:
+                # A request may have picked the session up since the scan.
+                if (
+                    self._sessions.get(session.session_id) is not session
+                    or session.busy
+                    or time.time() < session.idle_expires_at
+                ):
+                    continue
+                del self._sessions[session.session_id]
+            logger.info("Releasing idle session %s", session.session_id)
+            try:
+                await session.disconnect()
+            except Exception as exc:  # pragma: no cover - depends on CLI state
+                logger.warning("Failed to disconnect session %s: %s", session.session_id, exc)
+            reaped.append(session.session_id)
+        return reaped
+
+    def start_reaper(self) -> None:
+        if self._reaper is None or self._reaper.done():
+            self._reaper = asyncio.create_task(self._reap_forever())
+
+    async def shutdown(self) -> None:
+        if self._reaper is not None:
+            self._reaper.cancel()
+            with contextlib.suppress(asyncio.CancelledError):
+                await self._reaper
+            self._reaper = None
+        for session_id in list(self._sessions):
+            await self.release_session(session_id)
+
+    async def _reap_forever(self) -> None:
+        while True:
+            await asyncio.sleep(self._reap_interval_seconds)
+            try:
+                await self.reap_idle()
+            except Exception as exc:  # pragma: no cover - defensive
+                logger.error("Session reaper failed: %s", exc)
+
     async def release_session(self, session_id: str, *, delete_sandbox: bool = False) -> None:
         async with self._lock:
             session = self._sessions.pop(session_id, None)
@@ -540,12 +736,15 @@ class ClaudeAgentClient:
         mcp_servers: dict[str, Any] | None = None,
         user_token: str | None = None,
         user_identifier: str | None = None,
+        user_id: str | None = None,
+        organization_id: str | None = None,
         top_p: float | None = None,
         permission_mode: Any | None = None,
         can_use_tool: Callable | None = None,
         hooks: dict[str, list[Any]] | None = None,
         max_turns: int | None = None,
         include_partial_messages: bool = False,
+        resume_token: str | None = None,
     ) -> CompletionResult:
         self._ensure_vertex_configuration()
 
@@ -553,6 +752,8 @@ class ClaudeAgentClient:
         if model is None:
             raise ValueError("model parameter is required and cannot be None")
 
+        resume = self._resume_target(session_id, resume_token, user_id, organization_id)
+
         session = await self._session_manager.get_session(
             session_id=session_id,
             config=SessionConfig(
@@ -572,6 +773,9 @@ class ClaudeAgentClient:
                 max_turns=max_turns,
                 include_partial_messages=include_partial_messages,
                 env=self._session_env(),
+                user_id=user_id,
+                organization_id=organization_id,
+                resume=resume,
             ),
         )
 
@@ -598,11 +802,13 @@ class ClaudeAgentClient:
 
                 async for message in session.client.receive_response():
                     raw_messages.append(message)
+                    self._record_init_message(session, message)
                     if AssistantMessage is not None and isinstance(message, AssistantMessage):
                         collected_text.append(self._collect_text_blocks(message.content))
                     elif ResultMessage is not None and isinstance(message, ResultMessage):
                         usage = self._usage_from_result(message)
 
+                session.record_usage(usage)
                 return CompletionResult(
                     text="".join(collected_text),
                     usage=usage,
@@ -610,6 +816,7 @@ class ClaudeAgentClient:
                     raw_messages=raw_messages,
                     interrupted=session.interrupted,
                     permission_requests=session.permission_requests,
+                    resume_token=self._session_manager.issue_resume_token(session.session_id),
                 )
 
             except Exception as e:
@@ -640,12 +847,15 @@ class ClaudeAgentClient:
         mcp_servers: dict[str, Any] | None = None,
         user_token: str | None = None,
         user_identifier: str | None = None,
+        user_id: str | None = None,
+        organization_id: str | None = None,
         top_p: float | None = None,
         permission_mode: Any | None = None,
         can_use_tool: Callable | None = None,
         hooks: dict[str, list[Any]] | None = None,
         max_turns: int | None = None,
         include_partial_messages: bool = False,
+        resume_token: str | None = None,
     ) -> AsyncGenerator[CompletionChunk, None]:
         self._ensure_vertex_configuration()
 
@@ -653,6 +863,8 @@ class ClaudeAgentClient:
         if model is None:
             raise ValueError("model parameter is required and cannot be None")
 
+        resume = self._resume_target(session_id, resume_token, user_id, organization_id)
+
         session = await self._session_manager.get_session(
             session_id=session_id,
             config=SessionConfig(
@@ -672,6 +884,9 @@ class ClaudeAgentClient:
                 max_turns=max_turns,
                 include_partial_messages=include_partial_messages,
                 env=self._session_env(),
+                user_id=user_id,
+                organization_id=organization_id,
+                resume=resume,
             ),
         )
 
@@ -696,6 +911,7 @@ class ClaudeAgentClient:
                 current_tool_use = None
 
                 async for message in session.client.receive_messages():
+                    self._record_init_message(session, message)
                     if AssistantMessage is not None and isinstance(message, AssistantMessage):
                         # Extract tool use information
                         for block in message.content:
@@ -713,7 +929,15 @@ class ClaudeAgentClient:
 
                     elif ResultMessage is not None and isinstance(message, ResultMessage):
                         usage = self._usage_from_result(message)
-                        yield CompletionChunk(done=True, usage=usage)
+                        session.record_usage(usage)
+                        yield CompletionChunk(
+                            done=True,
+                            usage=usage,
+                            termination_reason=session.termination_reason,
+                            resume_token=self._session_manager.issue_resume_token(
+                                session.session_id
+                            ),
+                        )
                         return
 
                     # Check for permission requests in partial messages
@@ -727,12 +951,21 @@ class ClaudeAgentClient:
                         yield CompletionChunk(permission_request=permission_req)
 
                 # Fallback to done chunk if ResultMessage not emitted
-                yield CompletionChunk(done=True, usage=usage)
+                yield CompletionChunk(
+                    done=True,
+                    usage=usage,
+                    termination_reason=session.termination_reason,
+                    resume_token=self._session_manager.issue_resume_token(session.session_id),
+                )
 
             except Exception as e:
-                if "interrupted" in str(e).lower():
+                if session.termination_reason is not None or "interrupted" in str(e).lower():
                     session.interrupted = True
-                    yield CompletionChunk(done=True, usage=UsageStats())
+                    yield CompletionChunk(
+                        done=True,
+                        usage=UsageStats(),
+                        termination_reason=session.termination_reason,
+                    )
                 else:
                     raise
 
@@ -760,6 +993,58 @@ class ClaudeAgentClient:
             "workspace_path": str(session.sandbox.workspace_path),
             "model": session.config.model,
             "permission_mode": session.config.permission_mode,
+            "mcp_status": dict(session.mcp_status),
+        }
+
+    def check_resume_token(
+        self,
+        session_id: str,
+        resume_token: str,
+        *,
+        user_id: str | None,
+        organization_id: str | None,
+    ) -> None:
+        """Raise ``ValueError`` unless ``resume_token`` may resume ``session_id`` for this caller.
+
+        Routes call this before streaming so a bad token is rejected with a 400
+        rather than an error event inside the stream.
+        """
+        self._resume_target(session_id, resume_token, user_id, organization_id)
+
+    def _resume_target(
+        self,
+        session_id: str,
+        resume_token: str | None,
+        user_id: str | None,
+        organization_id: str | None,
+    ) -> str | None:
+        """Return the CLI session to resume when a token rehydrates a session not held here."""
+        if not resume_token or self._session_manager.find_session(session_id) is not None:
+            return None
+        state = self._session_manager.decode_resume_token(resume_token)
+        check_resume_state(
+            state, session_id=session_id, user_id=user_id, organization_id=organization_id
+        )
+        return state.sdk_session_id
+
+    @staticmethod
+    def _record_init_message(session: ClaudeSession, message: Any) -> None:
+        """Capture the CLI session id and MCP connection status reported by the CLI."""
+        sdk_session_id = getattr(message, "session_id", None)
+        if isinstance(sdk_session_id, str) and sdk_session_id:
+            session.sdk_session_id = sdk_session_id
+        if getattr(message, "subtype", None) != "init":
+            return
+        data = getattr(message, "data", None)
+        if not isinstance(data, dict):
+            return
+        if isinstance(data.get("session_id"), str):
+            session.sdk_session_id = data["session_id"]
+        servers = data.get("mcp_servers") or []
+        session.mcp_status = {
+            str(server["name"]): str(server.get("status", "unknown"))
+            for server in servers
+            if isinstance(server, dict) and server.get("name")
         }
 
     @staticmethod
@@ -829,12 +1114,16 @@ def create_session_manager(
     sandbox_manager: SandboxManager,
     default_allowed_tools: list[str] | None = None,
     default_setting_sources: list[str] | None = None,
+    idle_timeout_seconds: float = 3600.0,
+    resume_codec: SessionResumeCodec | None = None,
 ) -> ClaudeSessionManager:
     """Factory function to create enhanced session manager."""
     return ClaudeSessionManager(
         sandbox_manager=sandbox_manager,
         default_allowed_tools=default_allowed_tools,
         default_setting_sources=default_setting_sources,
+        idle_timeout_seconds=idle_timeout_seconds,
+        resume_codec=resume_codec,
     )
 
 
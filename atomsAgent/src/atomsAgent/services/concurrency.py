@@ -0,0 +1,80 @@
+"""Per-user and per-organization limits on in-flight requests.
+
+Rate limits bound how often requests start, not how many are open at once, so a
+single user could hold many slow streaming completions and starve the agent
+pool. ``ConcurrencyLimiter`` rejects a request outright when its user or
+organization already has the maximum number of requests in flight.
+"""
+
+from __future__ import annotations
+
+import threading
+
+
+class ConcurrencyLimitExceeded(RuntimeError):
+    """Raised when a user or organization is at its in-flight request limit."""
+
+    def __init__(self, scope: str, key: str, limit: int) -> None:
+        super().__init__(f"Too many concurrent requests for {scope} {key} (limit {limit})")
+        self.scope = scope
+        self.key = key
+        self.limit = limit
+
+
+class ConcurrencySlot:
+    """A held slot; ``release`` is idempotent so it can be called from several paths."""
+
+    def __init__(self, limiter: ConcurrencyLimiter, keys: list[tuple[str, str]]) -> None:
+        self._limiter = limiter
+        self._keys = keys
+        self._released = False
+
+    def release(self) -> None:
+        if not self._released:
+            self._released = True
+            self._limiter._release(self._keys)
+
+    def __enter__(self) -> ConcurrencySlot:
+        return self
+
+    def __exit__(self, *exc: object) -> None:
+        self.release()
+
+
+class ConcurrencyLimiter:
+    def __init__(
+        self, *, max_per_user: int | None = None, max_per_org: int | None = None
+    ) -> None:
+        self._limits = {"user": max_per_user, "organization": max_per_org}
+        self._in_flight: dict[tuple[str, str], int] = {}
+        self._lock = threading.Lock()
+
+    def acquire(
+        self, *, user_id: str | None = None, organization_id: str | None = None
+    ) -> ConcurrencySlot:
+        """Take a slot for the user and organization or raise ``ConcurrencyLimitExceeded``."""
+        keys = [
+            (scope, key)
+            for scope, key in (("user", user_id), ("organization", organization_id))
+            if key and self._limits[scope]
+        ]
+        with self._lock:
+            for scope, key in keys:
+                limit = self._limits[scope]
+                if self._in_flight.get((scope, key), 0) >= limit:
+                    raise ConcurrencyLimitExceeded(scope, key, limit)
+            for entry in keys:
+                self._in_flight[entry] = self._in_flight.get(entry, 0) + 1
+        return ConcurrencySlot(self, keys)
+
+    def in_flight(self, scope: str, key: str) -> int:
+        return self._in_flight.get((scope, key), 0)
+
+    def _release(self, keys: list[tuple[str, str]]) -> None:
+        with self._lock:
+            for entry in keys:
+                remaining = self._in_flight.get(entry, 0) - 1
+                if remaining > 0:
+                    self._in_flight[entry] = remaining
+                else:
+                    self._in_flight.pop(entry, None)
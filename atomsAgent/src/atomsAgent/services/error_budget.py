@@ -0,0 +1,104 @@
+"""Error budgets: sliding-window error rates per operation.
+
+``ErrorBudgetTracker`` counts outcomes (and errors by code) per operation in
+``buckets`` time buckets spanning ``window_seconds``, so memory stays bounded
+however busy an operation is. An operation's budget is exhausted once at least
+``min_events`` outcomes are in the window and more than ``threshold`` of them
+failed; its burn rate is the error rate divided by ``threshold``. ``/health``
+reports ``error_budget_status: degraded`` while any budget is exhausted.
+"""
+
+from __future__ import annotations
+
+import time
+from collections import Counter, deque
+from collections.abc import Callable
+from dataclasses import dataclass, field
+
+
+@dataclass(slots=True)
+class _Bucket:
+    index: int
+    events: int = 0
+    errors: Counter[str] = field(default_factory=Counter)
+
+
+@dataclass(frozen=True, slots=True)
+class ErrorBudget:
+    operation: str
+    events: int
+    errors: int
+    errors_by_code: dict[str, int]
+    error_rate: float
+    burn_rate: float
+    exhausted: bool
+
+
+class ErrorBudgetTracker:
+    def __init__(
+        self,
+        *,
+        window_seconds: float = 600.0,
+        threshold: float = 0.05,
+        min_events: int = 20,
+        buckets: int = 60,
+        clock: Callable[[], float] = time.monotonic,
+    ) -> None:
+        if not 0 < threshold <= 1:
+            raise ValueError("threshold must be in (0, 1]")
+        self.window_seconds = window_seconds
+        self.threshold = threshold
+        self.min_events = min_events
+        self._buckets = buckets
+        self._bucket_seconds = window_seconds / buckets
+        self._clock = clock
+        self._windows: dict[str, deque[_Bucket]] = {}
+
+    def record(self, operation: str, *, error_code: str | None = None) -> None:
+        """Count one outcome of ``operation``; ``error_code`` marks it as a failure."""
+        index = self._index()
+        window = self._windows.setdefault(operation, deque())
+        if not window or window[-1].index != index:
+            window.append(_Bucket(index))
+        bucket = window[-1]
+        bucket.events += 1
+        if error_code is not None:
+            bucket.errors[error_code] += 1
+        self._prune(window, index)
+
+    def budget(self, operation: str) -> ErrorBudget:
+        window = self._windows.get(operation, deque())
+        self._prune(window, self._index())
+        events = sum(bucket.events for bucket in window)
+        errors_by_code: Counter[str] = Counter()
+        for bucket in window:
+            errors_by_code.update(bucket.errors)
+        errors = sum(errors_by_code.values())
+        error_rate = errors / events if events else 0.0
+        return ErrorBudget(
+            operation=operation,
+            events=events,
+            errors=errors,
+            errors_by_code=dict(errors_by_code),
+            error_rate=error_rate,
+            burn_rate=error_rate / self.threshold,
+            exhausted=events >= self.min_events and error_rate > self.threshold,
+        )
+
+    def summary(self) -> dict[str, ErrorBudget]:
+        """Return the budget of every operation with outcomes in the window."""
+        budgets = {operation: self.budget(operation) for operation in list(self._windows)}
+        for operation, budget in budgets.items():
+            if not budget.events:
+                del self._windows[operation]
+        return {operation: budget for operation, budget in budgets.items() if budget.events}
+
+    def exhausted(self) -> list[str]:
+        return [operation for operation, budget in self.summary().items() if budget.exhausted]
+
+    def _index(self) -> int:
+        return int(self._clock() // self._bucket_seconds)
+
+    def _prune(self, window: deque[_Bucket], index: int) -> None:
+        while window and window[0].index <= index - self._buckets:
+            window.popleft()
@@ -0,0 +1,97 @@
+"""Circuit breakers for direct MCP calls, keyed by (operation, MCP configuration).
+
+A breaker opens after ``failure_threshold`` consecutive failures and rejects
+calls for ``reset_timeout_seconds``; the next call is then let through as a
+trial and closes the breaker again if it succeeds. Keying by configuration
+means one flaky server only pauses its own calls. Breakers are created on first
+use and the least recently used are dropped beyond ``max_breakers``.
+"""
+
+from __future__ import annotations
+
+import logging
+import time
+from collections import Counter, OrderedDict
+from dataclasses import dataclass
+from typing import Literal
+
+logger = logging.getLogger(__name__)
+
+BreakerState = Literal["closed", "open", "half_open"]
+
+
+@dataclass
+class CircuitBreaker:
+    failure_threshold: int
+    reset_timeout_seconds: float
+    state: BreakerState = "closed"
+    consecutive_failures: int = 0
+    total_failures: int = 0
+    total_successes: int = 0
+    rejected: int = 0
+    opened_at: float | None = None
+
+    def allow(self) -> bool:
+        """Return whether a call may proceed, moving an expired open breaker to half-open."""
+        if self.state == "open":
+            if time.time() - (self.opened_at or 0.0) < self.reset_timeout_seconds:
+                self.rejected += 1
+                return False
+            self.state = "half_open"
+        return True
+
+    def record_success(self) -> None:
+        self.state = "closed"
+        self.consecutive_failures = 0
+        self.total_successes += 1
+
+    def record_failure(self) -> None:
+        self.consecutive_failures += 1
+        self.total_failures += 1
+        if self.state == "half_open" or self.consecutive_failures >= self.failure_threshold:
+            self.state = "open"
+            self.opened_at = time.time()
+
+
+class MCPCircuitBreakers:
+    def __init__(
+        self,
+        *,
+        failure_threshold: int = 5,
+        reset_timeout_seconds: float = 30.0,
+        max_breakers: int = 1024,
+    ) -> None:
+        self._failure_threshold = failure_threshold
+        self._reset_timeout_seconds = reset_timeout_seconds
+        self._max_breakers = max_breakers
+        self._breakers: OrderedDict[tuple[str, str], CircuitBreaker] = OrderedDict()
+
+    def get(self, operation: str, config_id: str) -> CircuitBreaker:
+        key = (operation, config_id)
+        breaker = self._breakers.get(key)
+        if breaker is None:
+            breaker = self._breakers[key] = CircuitBreaker(
+                failure_threshold=self._failure_threshold,
+                reset_timeout_seconds=self._reset_timeout_seconds,
+            )
+            while len(self._breakers) > self._max_breakers:
+                (operation_evicted, config_evicted), _ = self._breakers.popitem(last=False)
+                logger.debug(
+                    "Evicted MCP circuit breaker %s for %s", operation_evicted, config_evicted
+                )
+        else:
+            self._breakers.move_to_end(key)
+        return breaker
+
+    def for_config(self, config_id: str) -> dict[str, CircuitBreaker]:
+        """Return the breakers of one configuration by operation."""
+        return {
+            operation: breaker
+            for (operation, key), breaker in self._breakers.items()
+            if key == config_id
+        }
+
+    def summary(self) -> dict[str, int]:
+        """Count breakers by state."""
+        counts = Counter(breaker.state for breaker in self._breakers.values())
+        return {state: counts.get(state, 0) for state in ("closed", "open", "half_open")}
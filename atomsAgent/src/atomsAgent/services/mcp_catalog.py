@@ -0,0 +1,96 @@
+"""Curated MCP templates for quick setup.
+
+Templates live in ``config/mcp_catalog.yml`` and prefill the endpoint and auth
+flow for common integrations so that callers only need to provide credentials.
+"""
+
+from __future__ import annotations
+
+from pathlib import Path
+from typing import Any
+
+import yaml
+
+from atomsAgent.schemas.mcp import (
+    MCPCatalogResponse,
+    MCPCreateRequest,
+    MCPMetadata,
+    MCPTemplate,
+    MCPTemplateCreateRequest,
+)
+
+# Wheels ship config/ inside the package; source checkouts keep it at the project root.
+_PACKAGE_CATALOG_PATH = Path(__file__).resolve().parents[1] / "config" / "mcp_catalog.yml"
+_PROJECT_CATALOG_PATH = Path(__file__).resolve().parents[3] / "config" / "mcp_catalog.yml"
+_DEFAULT_CATALOG_PATH = (
+    _PACKAGE_CATALOG_PATH if _PACKAGE_CATALOG_PATH.exists() else _PROJECT_CATALOG_PATH
+)
+
+
+def _load_yaml_config(config_path: Path) -> dict[str, Any]:
+    if not config_path.exists():
+        return {}
+    with config_path.open("r", encoding="utf-8") as handle:
+        return yaml.safe_load(handle) or {}
+
+
+class MCPCatalog:
+    """Read-only catalog of MCP templates."""
+
+    def __init__(self, *, config_path: Path | None = None) -> None:
+        self._templates = self._load_templates(config_path or _DEFAULT_CATALOG_PATH)
+
+    def list(self) -> MCPCatalogResponse:
+        return MCPCatalogResponse(items=list(self._templates.values()))
+
+    def get(self, key: str) -> MCPTemplate:
+        try:
+            return self._templates[key]
+        except KeyError as exc:
+            raise KeyError(f"MCP template '{key}' not found") from exc
+
+    def build_create_request(
+        self, key: str, payload: MCPTemplateCreateRequest
+    ) -> MCPCreateRequest:
+        """Expand a template plus user-supplied credentials into a create request."""
+        template = self.get(key)
+
+        if payload.endpoint is not None and not template.endpoint_overridable:
+            raise ValueError(f"Template '{key}' does not allow overriding the endpoint")
+
+        unknown = set(payload.credentials) - {cred.key for cred in template.credentials}
+        if unknown:
+            raise ValueError(f"Unknown credentials for template '{key}': {sorted(unknown)}")
+
+        bearer_token: str | None = None
+        env: dict[str, str] = {}
+        for credential in template.credentials:
+            value = payload.credentials.get(credential.key)
+            if not value:
+                if credential.required:
+                    raise ValueError(f"Missing credential '{credential.key}'")
+                continue
+            if credential.target == "bearer_token":
+                bearer_token = value
+            elif credential.target.startswith("env:"):
+                env[credential.target[len("env:") :]] = value
+
+        return MCPCreateRequest(
+            name=payload.name or template.key,
+            endpoint=payload.endpoint or template.endpoint,
+            auth_type=template.auth_type,
+            bearer_token=bearer_token,
+            oauth_provider=template.oauth_provider,
+            enabled=payload.enabled,
+            is_default=payload.is_default,
+            metadata=MCPMetadata(env=env),
+            scope=payload.scope,
+        )
+
+    @staticmethod
+    def _load_templates(config_path: Path) -> dict[str, MCPTemplate]:
+        raw_config = _load_yaml_config(config_path)
+        templates_cfg = raw_config.get("templates", {}) or {}
+        return {
+            key: MCPTemplate(key=key, **(payload or {})) for key, payload in templates_cfg.items()
+        }
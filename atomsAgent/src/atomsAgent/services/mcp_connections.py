@@ -0,0 +1,292 @@
+"""Long-lived client connections to configured MCP servers.
+
+``MCPConnectionManager`` keeps a small pool of FastMCP clients per
+``mcp_configurations`` row. Calls go to the least busy client and a new one is
+opened while every pooled client is busy and the pool has room, so parallel chat
+sessions using the same MCP do not queue behind each other. A background reaper
+disconnects clients which have been idle too long or stopped answering pings, so
+vanished servers do not leak sockets. Configurations can also be drained: new
+calls are refused, in-flight calls are allowed to finish, and the pool is then
+disconnected.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import contextlib
+import logging
+import time
+from collections.abc import AsyncIterator, Callable
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+from atomsAgent.db.repositories import MCPConfigRecord
+from atomsAgent.services.secret_store import SecretStore
+
+try:
+    from fastmcp import Client
+    from fastmcp.client.transports import SSETransport, StreamableHttpTransport
+
+    _IMPORT_ERROR = None
+except ImportError as exc:  # pragma: no cover - library not installed in some environments
+    Client = None  # type: ignore
+    SSETransport = None  # type: ignore
+    StreamableHttpTransport = None  # type: ignore
+    _IMPORT_ERROR = exc
+
+logger = logging.getLogger(__name__)
+
+
+class MCPConnectionDrainingError(RuntimeError):
+    """Raised when a call targets an MCP configuration that is being drained."""
+
+
+@dataclass(eq=False)
+class MCPConnection:
+    config_id: str
+    name: str
+    client: Any
+    created_at: float = field(default_factory=time.time)
+    last_activity: float = field(default_factory=time.time)
+    failed_pings: int = 0
+    in_flight: int = 0
+
+    def touch(self) -> None:
+        self.last_activity = time.time()
+
+
+def create_fastmcp_client(
+    record: MCPConfigRecord,
+    *,
+    message_handler: Callable[..., Any] | None = None,
+    sampling_handler: Callable[..., Any] | None = None,
+) -> Any:
+    """Build a FastMCP client for an ``mcp_configurations`` record."""
+    if _IMPORT_ERROR is not None:
+        raise RuntimeError("fastmcp is not installed. Install with `pip install fastmcp`.") from (
+            _IMPORT_ERROR
+        )
+    from atomsAgent.mcp.database import convert_mcp_configuration_to_mcp_config
+
+    config = convert_mcp_configuration_to_mcp_config(asdict(record))
+    if not config:
+        raise ValueError(f"MCP configuration {record.id} has no endpoint")
+    transport_cls = SSETransport if config["url"].rstrip("/").endswith("/sse") else (
+        StreamableHttpTransport
+    )
+    return Client(
+        transport_cls(config["url"], headers=config.get("headers")),
+        message_handler=message_handler,
+        sampling_handler=sampling_handler,
+    )
+
+
+class MCPConnectionManager:
+    """Pools connected MCP clients and reaps idle or unresponsive ones."""
+
+    def __init__(
+        self,
+        *,
+        client_factory: Callable[[MCPConfigRecord], Any] = create_fastmcp_client,
+        idle_timeout_seconds: float = 300.0,
+        reap_interval_seconds: float = 60.0,
+        max_failed_pings: int = 2,
+        pool_size: int = 1,
+        on_disconnect: Callable[[str], None] | None = None,
+        secret_store: SecretStore | None = None,
+    ) -> None:
+        self._client_factory = client_factory
+        self._secret_store = secret_store
+        self._on_disconnect = on_disconnect
+        self._idle_timeout_seconds = idle_timeout_seconds
+        self._reap_interval_seconds = reap_interval_seconds
+        self._max_failed_pings = max_failed_pings
+        self._pool_size = max(1, pool_size)
+        self._connections: dict[str, list[MCPConnection]] = {}
+        self._draining: set[str] = set()
+        self._lock = asyncio.Lock()
+        self._connect_locks: dict[str, asyncio.Lock] = {}
+        self._idle = asyncio.Condition()
+        self._reaper: asyncio.Task[None] | None = None
+
+    async def acquire(self, record: MCPConfigRecord) -> MCPConnection:
+        """Reserve the least busy pooled client, connecting another if all are busy.
+
+        The connection counts as in flight until it is passed to ``release``.
+        Connecting happens outside the manager lock, serialized per configuration,
+        so a slow server only delays calls to itself.
+        """
+        async with self._lock:
+            connection = self._reuse(record.id)
+            if connection is not None:
+                return connection
+        connect_lock = self._connect_locks.setdefault(record.id, asyncio.Lock())
+        async with connect_lock:
+            # Another call may have connected while this one waited.
+            async with self._lock:
+                connection = self._reuse(record.id)
+                if connection is not None:
+                    return connection
+            resolved = record
+            if self._secret_store is not None:
+                resolved = await self._secret_store.resolve_record(record)
+            client = self._client_factory(resolved)
+            await client.__aenter__()
+            connection = MCPConnection(config_id=record.id, name=record.name, client=client)
+            async with self._lock:
+                if record.id not in self._draining:
+                    self._connections[record.id] = [
+                        *self._connections.get(record.id, []),
+                        connection,
+                    ]
+                    connection.in_flight += 1
+                    connection.touch()
+                    return connection
+        await self._close(connection)
+        raise MCPConnectionDrainingError(f"MCP configuration {record.id} is draining")
+
+    async def release(self, connection: MCPConnection) -> None:
+        connection.in_flight -= 1
+        connection.touch()
+        async with self._idle:
+            self._idle.notify_all()
+
+    @contextlib.asynccontextmanager
+    async def session(self, record: MCPConfigRecord) -> AsyncIterator[Any]:
+        """Yield a connected client, tracking the call as in flight until it exits."""
+        connection = await self.acquire(record)
+        try:
+            yield connection.client
+        finally:
+            await self.release(connection)
+
+    async def drain(self, config_id: str, *, timeout_seconds: float = 30.0) -> bool:
+        """Refuse new calls, wait for in-flight ones, then disconnect.
+
+        Returns whether any live connection was closed. Raises ``asyncio.TimeoutError`` if
+        calls are still running after ``timeout_seconds``; the connection is then
+        left open and accepts calls again.
+        """
+        async with self._lock:
+            self._draining.add(config_id)
+        try:
+            async with self._idle:
+                await asyncio.wait_for(
+                    self._idle.wait_for(
+                        lambda: all(
+                            item.in_flight == 0 for item in self._connections.get(config_id, [])
+                        )
+                    ),
+                    timeout=timeout_seconds,
+                )
+            return await self.disconnect(config_id)
+        finally:
+            self._draining.discard(config_id)
+
+    def connections(self) -> list[MCPConnection]:
+        return [connection for pool in self._connections.values() for connection in pool]
+
+    async def disconnect(self, config_id: str) -> bool:
+        """Close every pooled connection for the configuration."""
+        async with self._lock:
+            pool = self._connections.pop(config_id, [])
+        for connection in pool:
+            await self._close(connection)
+        if not pool:
+            return False
+        if self._on_disconnect is not None:
+            self._on_disconnect(config_id)
+        return True
+
+    async def reap(self) -> list[str]:
+        """Run one reaper pass and return the IDs of connections that were closed."""
+        now = time.time()
+        reaped: list[str] = []
+        for connection in self.connections():
+            if connection.in_flight or connection.config_id in self._draining:
+                continue
+            if now - connection.last_activity >= self._idle_timeout_seconds:
+                logger.info("Reaping idle MCP connection %s", connection.name)
+            elif not await self._ping(connection):
+                connection.failed_pings += 1
+                if connection.failed_pings < self._max_failed_pings:
+                    continue
+                logger.warning(
+                    "Reaping MCP connection %s after %d failed pings",
+                    connection.name,
+                    connection.failed_pings,
+                )
+            else:
+                connection.failed_pings = 0
+                continue
+            if await self._remove(connection):
+                reaped.append(connection.config_id)
+        return reaped
+
+    def start_reaper(self) -> None:
+        if self._reaper is None or self._reaper.done():
+            self._reaper = asyncio.create_task(self._reap_forever())
+
+    async def shutdown(self) -> None:
+        if self._reaper is not None:
+            self._reaper.cancel()
+            with contextlib.suppress(asyncio.CancelledError):
+                await self._reaper
+            self._reaper = None
+        for config_id in list(self._connections):
+            await self.disconnect(config_id)
+
+    async def _reap_forever(self) -> None:
+        while True:
+            await asyncio.sleep(self._reap_interval_seconds)
+            try:
+                await self.reap()
+            except Exception as exc:  # pragma: no cover - defensive
+                logger.error("MCP connection reaper failed: %s", exc)
+
+    def _reuse(self, config_id: str) -> MCPConnection | None:
+        """Reserve a pooled connection, or return ``None`` when a new one should be opened.
+
+        Must be called with ``_lock`` held.
+        """
+        if config_id in self._draining:
+            raise MCPConnectionDrainingError(f"MCP configuration {config_id} is draining")
+        pool = self._connections.get(config_id, [])
+        connection = min(pool, key=lambda item: item.in_flight, default=None)
+        if connection is None or (connection.in_flight and len(pool) < self._pool_size):
+            return None
+        connection.in_flight += 1
+        connection.touch()
+        return connection
+
+    async def _remove(self, connection: MCPConnection) -> bool:
+        """Close an idle connection; calls that reserved it meanwhile keep it open."""
+        async with self._lock:
+            pool = self._connections.get(connection.config_id, [])
+            if (
+                connection not in pool
+                or connection.in_flight
+                or connection.config_id in self._draining
+            ):
+                return False
+            pool.remove(connection)
+            if not pool:
+                del self._connections[connection.config_id]
+        await self._close(connection)
+        if self._on_disconnect is not None:
+            self._on_disconnect(connection.config_id)
+        return True
+
+    async def _ping(self, connection: MCPConnection) -> bool:
+        try:
+            return bool(await asyncio.wait_for(connection.client.ping(), timeout=10))
+        except Exception as exc:
+            logger.debug("Ping to MCP %s failed: %s", connection.name, exc)
+            return False
+
+    @staticmethod
+    async def _close(connection: MCPConnection) -> None:
+        try:
+            await connection.client.__aexit__(None, None, None)
+        except Exception as exc:
+            logger.warning("Error closing MCP connection %s: %s", connection.name, exc)
@@ -0,0 +1,198 @@
+"""Background health checks for configured MCP servers.
+
+``MCPHealthMonitor`` periodically opens a short-lived connection to every
+enabled ``mcp_configurations`` row and pings it, recording latency and the last
+success/error. A server is marked unhealthy after ``failure_threshold``
+consecutive failures. Every check is also written to the ``mcp_health`` table,
+so replicas that have not checked a server yet, and restarted processes, report
+(and continue counting from) the latest persisted state.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import contextlib
+import logging
+import time
+from collections import Counter
+from collections.abc import Callable
+from dataclasses import dataclass
+from datetime import datetime, timezone
+from typing import Any, Literal
+
+from atomsAgent.db.repositories import MCPConfigRecord, MCPRepository
+from atomsAgent.services.mcp_connections import create_fastmcp_client
+from atomsAgent.services.secret_store import SecretStore
+
+logger = logging.getLogger(__name__)
+
+HealthStatus = Literal["healthy", "unhealthy", "unknown"]
+
+
+@dataclass
+class MCPHealth:
+    config_id: str
+    name: str
+    status: HealthStatus = "unknown"
+    last_checked_at: float | None = None
+    last_success_at: float | None = None
+    last_error: str | None = None
+    last_error_at: float | None = None
+    latency_ms: float | None = None
+    consecutive_failures: int = 0
+
+    def to_row(self) -> dict[str, Any]:
+        return {
+            "mcp_id": self.config_id,
+            "name": self.name,
+            "status": self.status,
+            "last_checked_at": _isoformat(self.last_checked_at),
+            "last_success_at": _isoformat(self.last_success_at),
+            "last_error": self.last_error,
+            "last_error_at": _isoformat(self.last_error_at),
+            "latency_ms": self.latency_ms,
+            "consecutive_failures": self.consecutive_failures,
+        }
+
+    @classmethod
+    def from_row(cls, row: dict[str, Any]) -> MCPHealth:
+        return cls(
+            config_id=str(row["mcp_id"]),
+            name=row.get("name") or "",
+            status=row.get("status") or "unknown",
+            last_checked_at=_epoch(row.get("last_checked_at")),
+            last_success_at=_epoch(row.get("last_success_at")),
+            last_error=row.get("last_error"),
+            last_error_at=_epoch(row.get("last_error_at")),
+            latency_ms=row.get("latency_ms"),
+            consecutive_failures=row.get("consecutive_failures") or 0,
+        )
+
+
+def _isoformat(value: float | None) -> str | None:
+    return None if value is None else datetime.fromtimestamp(value, tz=timezone.utc).isoformat()
+
+
+def _epoch(value: str | None) -> float | None:
+    return None if not value else datetime.fromisoformat(value).timestamp()
+
+
+class MCPHealthMonitor:
+    def __init__(
+        self,
+        repository: MCPRepository,
+        *,
+        client_factory: Callable[[MCPConfigRecord], Any] = create_fastmcp_client,
+        interval_seconds: float = 60.0,
+        timeout_seconds: float = 10.0,
+        failure_threshold: int = 2,
+        max_concurrency: int = 10,
+        secret_store: SecretStore | None = None,
+    ) -> None:
+        self._repository = repository
+        self._client_factory = client_factory
+        self._secret_store = secret_store
+        self._interval_seconds = interval_seconds
+        self._timeout_seconds = timeout_seconds
+        self._failure_threshold = failure_threshold
+        self._semaphore = asyncio.Semaphore(max_concurrency)
+        self._health: dict[str, MCPHealth] = {}
+        self._task: asyncio.Task[None] | None = None
+
+    def get(self, config_id: str) -> MCPHealth | None:
+        return self._health.get(config_id)
+
+    async def fetch(self, config_id: str) -> MCPHealth | None:
+        """Return this process's state, falling back to the last persisted check."""
+        health = self._health.get(config_id)
+        if health is not None:
+            return health
+        return await self._load(config_id)
+
+    def summary(self) -> dict[str, int]:
+        """Count monitored configurations by status."""
+        counts = Counter(health.status for health in self._health.values())
+        return {status: counts.get(status, 0) for status in ("healthy", "unhealthy", "unknown")}
+
+    async def check_all(self) -> list[MCPHealth]:
+        records = await self._repository.list_enabled_configs()
+        live = {record.id for record in records}
+        for config_id in set(self._health) - live:
+            del self._health[config_id]
+            try:
+                await self._repository.delete_health(config_id)
+            except Exception as exc:
+                logger.warning("Failed to delete health state of MCP %s: %s", config_id, exc)
+        return list(await asyncio.gather(*(self.check(record) for record in records)))
+
+    async def check(self, record: MCPConfigRecord) -> MCPHealth:
+        health = self._health.get(record.id)
+        if health is None:
+            health = await self._load(record.id) or MCPHealth(
+                config_id=record.id, name=record.name
+            )
+            self._health[record.id] = health
+        health.name = record.name
+        async with self._semaphore:
+            started = time.perf_counter()
+            try:
+                await asyncio.wait_for(self._ping(record), timeout=self._timeout_seconds)
+            except Exception as exc:
+                self._record_failure(health, exc)
+            else:
+                health.latency_ms = round((time.perf_counter() - started) * 1000, 2)
+                health.last_success_at = time.time()
+                health.consecutive_failures = 0
+                health.status = "healthy"
+        health.last_checked_at = time.time()
+        try:
+            await self._repository.save_health(health.to_row())
+        except Exception as exc:
+            logger.warning("Failed to persist health state of MCP %s: %s", health.name, exc)
+        return health
+
+    def start(self) -> None:
+        if self._task is None or self._task.done():
+            self._task = asyncio.create_task(self._check_forever())
+
+    async def shutdown(self) -> None:
+        if self._task is not None:
+            self._task.cancel()
+            with contextlib.suppress(asyncio.CancelledError):
+                await self._task
+            self._task = None
+
+    async def _load(self, config_id: str) -> MCPHealth | None:
+        try:
+            row = await self._repository.get_health(config_id)
+        except Exception as exc:
+            logger.warning("Failed to load health state of MCP %s: %s", config_id, exc)
+            return None
+        return MCPHealth.from_row(row) if row else None
+
+    async def _ping(self, record: MCPConfigRecord) -> None:
+        # A throwaway client keeps health checks from resetting the idle timer
+        # of the shared connection in MCPConnectionManager.
+        if self._secret_store is not None:
+            record = await self._secret_store.resolve_record(record)
+        client = self._client_factory(record)
+        async with client:
+            if not await client.ping():
+                raise ConnectionError("ping returned false")
+
+    def _record_failure(self, health: MCPHealth, exc: BaseException) -> None:
+        health.consecutive_failures += 1
+        health.last_error = str(exc) or type(exc).__name__
+        health.last_error_at = time.time()
+        if health.consecutive_failures >= self._failure_threshold:
+            if health.status != "unhealthy":
+                logger.warning("MCP %s is unhealthy: %s", health.name, health.last_error)
+            health.status = "unhealthy"
+
+    async def _check_forever(self) -> None:
+        while True:
+            try:
+                await self.check_all()
+            except Exception as exc:  # pragma: no cover - defensive
+                logger.error("MCP health check pass failed: %s", exc)
+            await asyncio.sleep(self._interval_seconds)
@@ -0,0 +1,192 @@
+"""Per-organization MCP tool allow/deny policy.
+
+The policy is stored in ``organizations.settings`` and enforced in two places:
+``MCPToolService.call_tool`` checks every direct call, and chat completions use
+``restrict_chat_servers`` to drop servers the policy blocks entirely. Chat
+sessions then check each ``mcp__<server>__<tool>`` call with ``chat_tool_hook``,
+which covers tool-level allow and deny globs. The hook reads the policy on every
+call (through a short cache), so long-lived sessions pick up policy changes.
+"""
+
+from __future__ import annotations
+
+import logging
+from collections.abc import Awaitable, Callable
+from fnmatch import fnmatchcase
+from typing import Any
+from uuid import UUID
+
+from atomsAgent.db.repositories import MCPRepository
+from atomsAgent.schemas.mcp import MCPToolPolicy
+from atomsAgent.utils.caching import SizedLRUCache
+from atomsAgent.utils.log_audit import log_security_event, security_extra
+
+logger = logging.getLogger(__name__)
+
+# compose_mcp_servers prefixes server keys with their scope.
+_CHAT_SERVER_PREFIXES = ("user_", "org_", "proj_", "platform_")
+_GLOB_CHARS = frozenset("*?[")
+
+
+class MCPToolPolicyService:
+    def __init__(
+        self,
+        repository: MCPRepository,
+        *,
+        cache: SizedLRUCache | None = None,
+        cache_ttl_seconds: float = 30.0,
+    ) -> None:
+        self._repository = repository
+        self._cache = cache
+        self._cache_ttl_seconds = cache_ttl_seconds
+
+    async def get_policy(self, organization_id: UUID | str) -> MCPToolPolicy:
+        try:
+            stored = await self._repository.get_tool_policy(organization_id)
+        except ValueError:
+            return MCPToolPolicy()
+        return MCPToolPolicy.model_validate(stored)
+
+    async def current_policy(self, organization_id: UUID | str) -> MCPToolPolicy:
+        """``get_policy`` through the cache, for checks made on every tool call.
+
+        Changes made through this service apply immediately; changes made
+        elsewhere (another replica) within ``cache_ttl_seconds``.
+        """
+        if self._cache is None:
+            return await self.get_policy(organization_id)
+        policy = self._cache.get(str(organization_id))
+        if policy is None:
+            policy = await self.get_policy(organization_id)
+            self._cache.set(str(organization_id), policy, ttl=self._cache_ttl_seconds)
+        return policy
+
+    async def set_policy(self, organization_id: UUID, policy: MCPToolPolicy) -> MCPToolPolicy:
+        await self._repository.set_tool_policy(organization_id, policy.model_dump())
+        if self._cache is not None:
+            self._cache.delete(str(organization_id))
+        log_security_event(
+            logger,
+            logging.INFO,
+            "MCP tool policy updated for organization %s",
+            organization_id,
+            extra=security_extra(
+                "mcp_tool_policy.update",
+                "organization",
+                str(organization_id),
+                allow=policy.allow,
+                deny=policy.deny,
+                sampling=policy.sampling,
+            ),
+        )
+        return policy
+
+    async def clear_policy(self, organization_id: UUID) -> None:
+        await self._repository.set_tool_policy(organization_id, None)
+        if self._cache is not None:
+            self._cache.delete(str(organization_id))
+        log_security_event(
+            logger,
+            logging.INFO,
+            "MCP tool policy cleared for organization %s",
+            organization_id,
+            extra=security_extra("mcp_tool_policy.clear", "organization", str(organization_id)),
+        )
+
+
+def is_tool_allowed(policy: MCPToolPolicy, server: str, tool: str) -> bool:
+    if any(_matches(pattern, server, tool) for pattern in policy.deny):
+        return False
+    return not policy.allow or any(_matches(pattern, server, tool) for pattern in policy.allow)
+
+
+def is_sampling_allowed(policy: MCPToolPolicy, server: str) -> bool:
+    return any(fnmatchcase(server, pattern) for pattern in policy.sampling)
+
+
+def log_denied_tool(organization_id: UUID | str, server: str, tool: str) -> None:
+    log_security_event(
+        logger,
+        logging.WARNING,
+        "Blocked call to MCP tool %s/%s by organization policy",
+        server,
+        tool,
+        extra=security_extra(
+            "mcp_tool.denied",
+            "mcp_tool",
+            f"{server}/{tool}",
+            organization_id=str(organization_id),
+        ),
+    )
+
+
+def restrict_chat_servers(
+    policy: MCPToolPolicy, mcp_servers: dict[str, Any]
+) -> tuple[dict[str, Any], list[str]]:
+    """Apply ``policy`` to composed chat servers.
+
+    Returns the servers that may be attached and the agent ``disallowed_tools``
+    entries (``mcp__<server>__<tool>``) for exact tool-level deny patterns, so
+    those tools are not offered at all. Other tool-level rules are enforced per
+    call by ``chat_tool_hook``.
+    """
+    allowed: dict[str, Any] = {}
+    disallowed: list[str] = []
+    for key, config in mcp_servers.items():
+        name = chat_server_name(key)
+        if any(_blocks_server(pattern, name) for pattern in policy.deny):
+            continue
+        if policy.allow and not any(
+            fnmatchcase(name, pattern.partition("/")[0]) for pattern in policy.allow
+        ):
+            continue
+        allowed[key] = config
+        for pattern in policy.deny:
+            server_pattern, _, tool = pattern.partition("/")
+            if tool and not _GLOB_CHARS & set(tool) and fnmatchcase(name, server_pattern):
+                disallowed.append(f"mcp__{key}__{tool}")
+    return allowed, disallowed
+
+
+def _matches(pattern: str, server: str, tool: str) -> bool:
+    server_pattern, _, tool_pattern = pattern.partition("/")
+    return fnmatchcase(server, server_pattern) and fnmatchcase(tool, tool_pattern or "*")
+
+
+def _blocks_server(pattern: str, server: str) -> bool:
+    server_pattern, _, tool_pattern = pattern.partition("/")
+    return tool_pattern in ("", "*") and fnmatchcase(server, server_pattern)
+
+
+def chat_tool_hook(
+    policies: MCPToolPolicyService, organization_id: UUID | str
+) -> Callable[[dict[str, Any], str | None, Any], Awaitable[dict[str, Any]]]:
+    """Build a ``PreToolUse`` hook that denies MCP tool calls the current policy blocks."""
+
+    async def hook(input_data: dict[str, Any], tool_use_id: str | None, context: Any) -> dict:
+        tool_name = str(input_data.get("tool_name", ""))
+        if not tool_name.startswith("mcp__"):
+            return {}
+        key, _, tool = tool_name[len("mcp__") :].partition("__")
+        server = chat_server_name(key)
+        policy = await policies.current_policy(organization_id)
+        if is_tool_allowed(policy, server, tool):
+            return {}
+        log_denied_tool(organization_id, server, tool)
+        return {
+            "hookSpecificOutput": {
+                "hookEventName": "PreToolUse",
+                "permissionDecision": "deny",
+                "permissionDecisionReason": f"{server}/{tool} is blocked by organization policy",
+            }
+        }
+
+    return hook
+
+
+def chat_server_name(key: str) -> str:
+    """Strip the scope prefix ``compose_mcp_servers`` adds to a chat server key."""
+    for prefix in _CHAT_SERVER_PREFIXES:
+        if key.startswith(prefix):
+            return key[len(prefix) :]
+    return key
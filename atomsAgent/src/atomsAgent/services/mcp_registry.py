@@ -1,19 +1,34 @@
 from __future__ import annotations
 
+import contextlib
+import json
+import logging
+from dataclasses import asdict
 from typing import Any, Literal, cast
-from uuid import UUID
+from uuid import UUID, uuid4
 
 from pydantic import BaseModel, Field, HttpUrl, TypeAdapter, ValidationError
 
-from atomsAgent.db.repositories import MCPConfigRecord, MCPRepository
+from atomsAgent.db.repositories import MCPConfigRecord, MCPRepository, PlatformRepository
+from atomsAgent.db.supabase import UNIQUE_VIOLATION, SupabaseError
 from atomsAgent.schemas.mcp import (
+    MCPConfigVersion,
     MCPConfiguration,
     MCPCreateRequest,
     MCPListResponse,
     MCPMetadata,
     MCPScope,
+    MCPScopeType,
+    MCPSortField,
     MCPUpdateRequest,
 )
+from atomsAgent.services.mcp_connections import MCPConnectionManager
+from atomsAgent.services.secret_store import SecretStore
+from atomsAgent.utils.caching import SizedLRUCache
+from atomsAgent.utils.diffing import field_diff
+from atomsAgent.utils.log_audit import log_security_event, security_extra
+
+logger = logging.getLogger(__name__)
 
 
 # Custom response that accepts string IDs
@@ -26,62 +41,411 @@ ConfigTypeLiteral = Literal["http"]
 
 _HTTP_URL_ADAPTER = TypeAdapter(HttpUrl)
 
+# Audit actions that produce a configuration version; see ``list_versions``.
+VERSION_ACTIONS = ("mcp_config.create", "mcp_config.update", "mcp_config.rollback")
+# Attempts at claiming the next version number when concurrent changes race.
+_VERSION_INSERT_ATTEMPTS = 5
+# Columns restored by ``rollback``. Credentials and ownership are never rolled back.
+_ROLLBACK_FIELDS = ("name", "type", "endpoint", "auth_type", "description", "enabled")
+
 
 class MCPRegistryService:
-    """Service for managing MCP configurations."""
+    """Service for managing MCP configurations.
 
-    def __init__(self, repository: MCPRepository):
+    Platform-scoped configurations are visible to every organization but can only
+    be changed by a platform admin; callers pass ``platform_admin=True`` only for
+    requests that presented the platform admin token (see ``api/auth.py``).
+    Organizations opt out of them individually with ``opt_out_of_platform``.
+    """
+
+    def __init__(
+        self,
+        repository: MCPRepository,
+        audit_repository: PlatformRepository | None = None,
+        tool_cache: SizedLRUCache | None = None,
+        secret_store: SecretStore | None = None,
+        connections: MCPConnectionManager | None = None,
+    ):
         self._repository = repository
+        # Bearer tokens are written to the store and only a reference is saved.
+        self._secret_store = secret_store
+        self._audit_repository = audit_repository
+        # Shared with MCPToolService; cleared whenever a configuration changes.
+        self._tool_cache = tool_cache
+        # Pooled clients keep the endpoint and credentials they connected with,
+        # so they are closed whenever a configuration changes.
+        self._connections = connections
 
     async def list(
         self,
         organization_id: UUID | None = None,
         user_id: UUID | None = None,
         include_platform: bool = False,
+        *,
+        scope: MCPScopeType | None = None,
+        auth_type: AuthTypeLiteral | None = None,
+        name_prefix: str | None = None,
+        sort: MCPSortField | None = None,
+        descending: bool = False,
+        limit: int | None = None,
+        offset: int = 0,
     ) -> MCPListResponse:
+        """List visible configurations, optionally filtered, sorted and paged.
+
+        Filtering, sorting and paging run in the database. Without ``sort``
+        configurations are listed oldest first.
+        """
         # Default organization_id if None
         org_id = (
             organization_id
             if organization_id is not None
             else UUID("00000000-0000-0000-0000-000000000000")
         )
-        records = await self._repository.list_configs(
+        order = None
+        if sort is not None:
+            order = [f"{sort}.{'desc' if descending else 'asc'}"]
+        records, total = await self._repository.list_configs(
             organization_id=org_id,
             user_id=user_id,
             include_platform=include_platform,
+            scope=scope,
+            auth_type=auth_type,
+            name_prefix=name_prefix,
+            order=order,
+            limit=limit,
+            offset=offset,
+        )
+        items = [self._map_record(r) for r in records]
+        if include_platform and organization_id is not None:
+            opted_out = set(await self._platform_opt_outs(organization_id))
+            for item in items:
+                item.opted_out = item.scope.type == "platform" and str(item.id) in opted_out
+
+        end = offset + len(items)
+        return MCPListResponse(
+            items=items,
+            total=total,
+            limit=limit,
+            offset=offset,
+            next_offset=end if end < total else None,
         )
-        return MCPListResponse(items=[self._map_record(r) for r in records])
 
-    async def create(self, payload: MCPCreateRequest) -> MCPConfiguration:
+    async def create(
+        self, payload: MCPCreateRequest, *, platform_admin: bool = False
+    ) -> MCPConfiguration:
+        if payload.scope.type == "platform":
+            self.require_platform_admin(platform_admin)
+        if payload.is_default and payload.scope.type != "organization":
+            raise ValueError("Only organization-scoped MCP configurations can be marked default")
         supabase_payload = self._build_payload(payload)
+        if payload.bearer_token and self._secret_store is not None:
+            supabase_payload["auth_token"] = await self._secret_store.put(
+                uuid4().hex, payload.bearer_token
+            )
         record = await self._repository.create_config(supabase_payload)
+        logger.info("Created MCP configuration %s", record.id)
+        await self._record_version(None, record, "mcp_config.create")
         return self._map_record(record)
 
-    async def update(self, config_id: UUID, payload: MCPUpdateRequest) -> MCPConfiguration:
+    async def update(
+        self, config_id: UUID, payload: MCPUpdateRequest, *, platform_admin: bool = False
+    ) -> MCPConfiguration:
+        existing = await self._repository.get_config(config_id)
+        if self._is_platform(existing):
+            self.require_platform_admin(platform_admin)
+        if payload.is_default and self._map_record(existing).scope.type != "organization":
+            raise ValueError("Only organization-scoped MCP configurations can be marked default")
         supabase_payload = self._build_payload(payload, partial=True)
-        record = await self._repository.update_config(config_id, supabase_payload)
+        if payload.is_default is not None or payload.metadata is not None:
+            # The default flag shares the config JSON with metadata, so merge
+            # against the stored value instead of overwriting it.
+            config = self._parse_config(existing.config)
+            if payload.metadata is not None:
+                config.update(payload.metadata.model_dump())
+            if payload.is_default is not None:
+                config["default"] = payload.is_default
+            supabase_payload["config"] = json.dumps(config)
+        new_secret = None
+        if payload.bearer_token and self._secret_store is not None:
+            new_secret = await self._secret_store.put(uuid4().hex, payload.bearer_token)
+            supabase_payload["auth_token"] = new_secret
+        try:
+            record = await self._repository.update_config(config_id, supabase_payload)
+        except Exception:
+            await self._delete_secret(new_secret)
+            raise
+        await self._invalidate(config_id)
+        if existing.auth_token != record.auth_token:
+            await self._delete_secret(existing.auth_token)
+        await self._record_version(existing, record, "mcp_config.update")
         return self._map_record(record)
 
-    async def delete(self, config_id: UUID) -> None:
+    async def list_versions(
+        self,
+        config_id: UUID,
+        *,
+        organization_id: UUID | None,
+        platform_admin: bool = False,
+    ) -> list[MCPConfigVersion]:
+        """Return the recorded versions of a configuration, newest first.
+
+        Versions are the ``audit_logs`` rows written on create, update and
+        rollback. Configurations changed before versioning existed start their
+        history at the first change made since. Raises ``PermissionError`` when
+        the configuration belongs to an organization other than
+        ``organization_id``, unless the caller is a platform admin.
+        """
+        if not platform_admin:
+            record = await self._repository.get_config(config_id)
+            if record.org_id is not None and record.org_id != str(organization_id):
+                raise PermissionError("MCP configuration belongs to another organization")
+        return await self._versions(config_id)
+
+    async def _versions(self, config_id: UUID) -> list[MCPConfigVersion]:
+        if self._audit_repository is None:
+            return []
+        entries = await self._audit_repository.list_resource_audit_logs(
+            "mcp_configuration", str(config_id), actions=VERSION_ACTIONS
+        )
+        return [
+            MCPConfigVersion(
+                version=entry.details["version"],
+                action=entry.action,
+                changed_at=entry.timestamp,
+                changed_by=entry.details.get("changed_by"),
+                changes=entry.details.get("changes") or {},
+                snapshot=entry.details.get("snapshot") or {},
+            )
+            for entry in entries
+            if isinstance(entry.details.get("version"), int)
+        ]
+
+    async def rollback(
+        self, config_id: UUID, version: int, *, platform_admin: bool = False
+    ) -> MCPConfiguration:
+        """Restore the non-secret fields of an earlier version as a new version.
+
+        Tokens and ``metadata.env`` values are not part of a version snapshot and
+        keep their current values. Raises ``KeyError`` for an unknown version.
+        """
+        target = next(
+            (item for item in await self._versions(config_id) if item.version == version),
+            None,
+        )
+        if target is None:
+            raise KeyError(f"MCP configuration {config_id} has no version {version}")
+
+        existing = await self._repository.get_config(config_id)
+        if self._is_platform(existing):
+            self.require_platform_admin(platform_admin)
+        payload = {key: target.snapshot[key] for key in _ROLLBACK_FIELDS if key in target.snapshot}
+        config = dict(target.snapshot.get("config") or {})
+        current_env = self._parse_config(existing.config).get("env")
+        if current_env is not None:
+            config["env"] = current_env
+        payload["config"] = json.dumps(config)
+        record = await self._repository.update_config(config_id, payload)
+        await self._invalidate(config_id)
+        await self._record_version(
+            existing, record, "mcp_config.rollback", rolled_back_to=version
+        )
+        logger.info("Rolled back MCP configuration %s to version %d", config_id, version)
+        return self._map_record(record)
+
+    async def opt_out_of_default(self, config_id: UUID, user_id: UUID) -> None:
+        """Stop an org-default MCP from being attached to the user's new sessions."""
+        config = await self.get_by_id(config_id)
+        if not config.is_default:
+            raise ValueError("MCP configuration is not an organization default")
+        opt_outs = await self._repository.get_default_opt_outs(user_id)
+        if str(config_id) not in opt_outs:
+            await self._repository.set_default_opt_outs(user_id, [*opt_outs, str(config_id)])
+
+    async def opt_in_to_default(self, config_id: UUID, user_id: UUID) -> None:
+        opt_outs = await self._repository.get_default_opt_outs(user_id)
+        if str(config_id) in opt_outs:
+            await self._repository.set_default_opt_outs(
+                user_id, [value for value in opt_outs if value != str(config_id)]
+            )
+
+    async def opt_out_of_platform(self, config_id: UUID, organization_id: UUID) -> None:
+        """Stop a platform MCP from being offered to the organization's sessions."""
+        if not self._is_platform(await self._repository.get_config(config_id)):
+            raise ValueError("MCP configuration is not platform-scoped")
+        opt_outs = await self._repository.get_platform_opt_outs(organization_id)
+        if str(config_id) not in opt_outs:
+            await self._repository.set_platform_opt_outs(
+                organization_id, [*opt_outs, str(config_id)]
+            )
+            log_security_event(
+                logger,
+                logging.INFO,
+                "Organization %s opted out of platform MCP %s",
+                organization_id,
+                config_id,
+                extra=security_extra(
+                    "mcp_config.platform_opt_out",
+                    "mcp_configuration",
+                    str(config_id),
+                    organization_id=str(organization_id),
+                ),
+            )
+
+    async def opt_in_to_platform(self, config_id: UUID, organization_id: UUID) -> None:
+        opt_outs = await self._repository.get_platform_opt_outs(organization_id)
+        if str(config_id) in opt_outs:
+            await self._repository.set_platform_opt_outs(
+                organization_id, [value for value in opt_outs if value != str(config_id)]
+            )
+
+    async def delete(self, config_id: UUID, *, platform_admin: bool = False) -> None:
+        existing = None
+        with contextlib.suppress(ValueError):
+            existing = await self._repository.get_config(config_id)
+        if existing is not None and self._is_platform(existing):
+            self.require_platform_admin(platform_admin)
         await self._repository.delete_config(config_id)
+        if existing is not None:
+            await self._delete_secret(existing.auth_token)
+        await self._invalidate(config_id)
+        log_security_event(
+            logger,
+            logging.INFO,
+            "Deleted MCP configuration %s",
+            config_id,
+            extra=security_extra("mcp_config.delete", "mcp_configuration", str(config_id)),
+        )
 
     async def get_by_id(self, config_id: UUID) -> MCPConfiguration:
         record = await self._repository.get_config(config_id)
         return self._map_record(record)
 
     @staticmethod
-    def _map_record(record: MCPConfigRecord) -> MCPConfiguration:
-        # Extract metadata from config field or build default
-        metadata_dict: dict[str, Any] = {}
-        if record.config and record.config != "null":
-            try:
-                import json
+    def require_platform_admin(platform_admin: bool) -> None:
+        if not platform_admin:
+            raise PermissionError(
+                "Platform MCP configurations can only be changed by platform admins"
+            )
+
+    async def _platform_opt_outs(self, organization_id: UUID) -> list[str]:
+        try:
+            return await self._repository.get_platform_opt_outs(organization_id)
+        except ValueError:  # unknown organization
+            return []
+
+    @staticmethod
+    def _is_platform(record: MCPConfigRecord) -> bool:
+        return record.scope == "platform" or (record.org_id is None and record.user_id is None)
+
+    async def _delete_secret(self, reference: str | None) -> None:
+        if self._secret_store is None or not self._secret_store.owns(reference):
+            return
+        try:
+            await self._secret_store.delete(reference)  # type: ignore[arg-type]
+        except Exception as exc:
+            logger.warning("Failed to delete stored MCP credential: %s", exc)
+
+    async def _invalidate(self, config_id: UUID) -> None:
+        if self._tool_cache is not None:
+            self._tool_cache.delete(str(config_id))
+        if self._connections is not None:
+            await self._connections.disconnect(str(config_id))
+
+    async def _record_version(
+        self,
+        before: MCPConfigRecord | None,
+        after: MCPConfigRecord,
+        action: str,
+        **details: Any,
+    ) -> None:
+        if self._audit_repository is None:
+            return
+        changes = field_diff(
+            self._audit_view(before) if before is not None else {},
+            self._audit_view(after),
+            secret_prefixes=("config.env.",),
+        )
+        if not changes and before is not None:
+            return
+        # The change itself is already saved, so a failed audit write is
+        # logged rather than failing the request.
+        try:
+            await self._insert_version(self._audit_repository, after, action, changes, details)
+        except Exception as exc:
+            logger.error("Failed to record %s audit entry for %s: %s", action, after.id, exc)
+
+    async def _insert_version(
+        self,
+        audit_repository: PlatformRepository,
+        after: MCPConfigRecord,
+        action: str,
+        changes: dict[str, Any],
+        details: dict[str, Any],
+    ) -> None:
+        """Write the audit row for the next version of ``after``.
 
-                metadata_dict = (
-                    json.loads(record.config) if isinstance(record.config, str) else record.config
+        A unique index on ``(resource_id, details->>'version')`` rejects a
+        version number a concurrent change already claimed; the number is then
+        re-read and the insert retried.
+        """
+        for attempt in range(1, _VERSION_INSERT_ATTEMPTS + 1):
+            latest = await audit_repository.list_resource_audit_logs(
+                "mcp_configuration", after.id, actions=VERSION_ACTIONS, limit=1
+            )
+            previous = latest[0].details.get("version") if latest else None
+            try:
+                await audit_repository.insert_audit_log(
+                    {
+                        "action": action,
+                        "resource_type": "mcp_configuration",
+                        "resource_id": after.id,
+                        "details": {
+                            "organization_id": after.org_id,
+                            "user_id": after.user_id,
+                            "version": (previous if isinstance(previous, int) else 0) + 1,
+                            "changed_by": after.updated_by or after.created_by,
+                            "changes": changes,
+                            "snapshot": self._version_snapshot(after),
+                            **details,
+                        },
+                        "success": True,
+                    }
                 )
-            except Exception:
-                metadata_dict = {}
+                return
+            except SupabaseError as exc:
+                if exc.code != UNIQUE_VIOLATION or attempt == _VERSION_INSERT_ATTEMPTS:
+                    raise
+
+    @classmethod
+    def _audit_view(cls, record: MCPConfigRecord) -> dict[str, Any]:
+        view = asdict(record)
+        for key in ("id", "created_at", "updated_at", "created_by", "updated_by"):
+            view.pop(key, None)
+        view["config"] = cls._parse_config(record.config)
+        return view
+
+    @classmethod
+    def _version_snapshot(cls, record: MCPConfigRecord) -> dict[str, Any]:
+        view = cls._audit_view(record)
+        for key in ("auth_token", "auth_header"):
+            view.pop(key, None)
+        view["config"] = {key: value for key, value in view["config"].items() if key != "env"}
+        return view
+
+    @staticmethod
+    def _parse_config(raw: str | None) -> dict[str, Any]:
+        if not raw or raw == "null":
+            return {}
+        try:
+            parsed = json.loads(raw) if isinstance(raw, str) else raw
+        except Exception:
+            return {}
+        return dict(parsed) if isinstance(parsed, dict) else {}
+
+    @staticmethod
+    def _map_record(record: MCPConfigRecord) -> MCPConfiguration:
+        # Extract metadata from config field or build default
+        metadata_dict = MCPRegistryService._parse_config(record.config)
 
         # Use default empty metadata if not found
         args = metadata_dict.get("args", [])
@@ -139,6 +503,7 @@ class MCPRegistryService:
             endpoint=endpoint,  # Already an HttpUrl
             auth_type=auth_type,
             enabled=record.enabled,
+            is_default=bool(metadata_dict.get("default", False)),
             metadata=metadata,
             scope=scope,
             created_at=record.created_at,
@@ -148,8 +513,6 @@ class MCPRegistryService:
     def _build_payload(
         payload: MCPCreateRequest | MCPUpdateRequest, *, partial: bool = False
     ) -> dict:
-        import json
-
         base: dict = {}
         if getattr(payload, "name", None) is not None:
             base["name"] = payload.name
@@ -169,6 +532,8 @@ class MCPRegistryService:
                 if hasattr(payload.metadata, "model_dump")
                 else payload.metadata
             )
+            if not partial and getattr(payload, "is_default", False):
+                meta_dict = {**meta_dict, "default": True}
             base["config"] = json.dumps(meta_dict)
         scope = getattr(payload, "scope", None)
         if scope is not None:
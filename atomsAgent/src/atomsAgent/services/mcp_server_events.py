@@ -0,0 +1,193 @@
+"""Server-initiated notifications and sampling requests on MCP connections.
+
+MCP servers can tell a client that their tool, resource or prompt lists changed
+or that a resource was updated, and can ask the client to run an LLM completion
+on their behalf (``sampling/createMessage``). ``MCPServerEvents`` builds the
+per-configuration handlers given to each pooled FastMCP client:
+
+- ``tools/list_changed`` drops the cached tool list of that configuration;
+- ``resources/updated`` is recorded per URI and can be read back with
+  ``resource_updates``;
+- sampling requests run as a one-shot chat completion, but only for servers that
+  the owning organization lists in its policy's ``sampling`` patterns.
+Platform configurations have no owning organization and cannot sample. The
+sampling prompt comes from the remote server, so the completion runs without
+MCP servers and with every agent tool disallowed and refused.
+"""
+
+from __future__ import annotations
+
+import logging
+import time
+from collections.abc import Awaitable, Callable
+from typing import Any
+from uuid import uuid4
+
+from atomsAgent.db.repositories import MCPConfigRecord
+from atomsAgent.services.mcp_connections import create_fastmcp_client
+from atomsAgent.services.mcp_policy import MCPToolPolicyService, is_sampling_allowed
+from atomsAgent.utils.caching import SizedLRUCache
+from atomsAgent.utils.log_audit import log_security_event, security_extra
+
+logger = logging.getLogger(__name__)
+
+# Built-in agent tools, all disallowed for sampling completions. Anything not
+# listed here is refused by ``_deny_tool`` instead.
+SAMPLING_DISALLOWED_TOOLS = [
+    "Agent",
+    "Bash",
+    "BashOutput",
+    "Edit",
+    "ExitPlanMode",
+    "Glob",
+    "Grep",
+    "KillShell",
+    "MultiEdit",
+    "NotebookEdit",
+    "Read",
+    "Skill",
+    "SlashCommand",
+    "Task",
+    "TodoWrite",
+    "WebFetch",
+    "WebSearch",
+    "Write",
+]
+
+
+class MCPSamplingError(RuntimeError):
+    """Raised to refuse a sampling request; the message is returned to the server."""
+
+
+class MCPServerEvents:
+    def __init__(
+        self,
+        *,
+        tool_cache: SizedLRUCache | None = None,
+        policy_service: MCPToolPolicyService | None = None,
+        claude_client: Any | None = None,
+        session_manager: Any | None = None,
+        sampling_model: str | None = None,
+        sampling_max_tokens: int = 1024,
+    ) -> None:
+        self._tool_cache = tool_cache
+        self._policy_service = policy_service
+        self._claude_client = claude_client
+        self._session_manager = session_manager
+        self._sampling_model = sampling_model
+        self._sampling_max_tokens = sampling_max_tokens
+        self._resource_updates: dict[str, dict[str, float]] = {}
+
+    def create_client(self, record: MCPConfigRecord) -> Any:
+        """Client factory for ``MCPConnectionManager`` with both handlers attached."""
+        return create_fastmcp_client(
+            record,
+            message_handler=self.message_handler(record),
+            sampling_handler=self.sampling_handler(record),
+        )
+
+    def resource_updates(self, config_id: str) -> dict[str, float]:
+        """Return when each resource of a configuration was last reported updated."""
+        return dict(self._resource_updates.get(config_id, {}))
+
+    def message_handler(self, record: MCPConfigRecord) -> Callable[[Any], Awaitable[None]]:
+        async def handle(message: Any) -> None:
+            await self.handle_notification(record, message)
+
+        return handle
+
+    def sampling_handler(self, record: MCPConfigRecord) -> Callable[..., Awaitable[str]]:
+        async def handle(messages: list[Any], params: Any, context: Any = None) -> str:
+            return await self.sample(record, messages, params)
+
+        return handle
+
+    async def handle_notification(self, record: MCPConfigRecord, message: Any) -> None:
+        notification = getattr(message, "root", message)
+        method = getattr(notification, "method", None)
+        if method == "notifications/tools/list_changed":
+            logger.info("MCP %s changed its tool list", record.name)
+            if self._tool_cache is not None:
+                self._tool_cache.delete(record.id)
+        elif method == "notifications/resources/updated":
+            uri = str(notification.params.uri)
+            logger.debug("MCP %s updated resource %s", record.name, uri)
+            self._resource_updates.setdefault(record.id, {})[uri] = time.time()
+        elif method in (
+            "notifications/resources/list_changed",
+            "notifications/prompts/list_changed",
+        ):
+            logger.info("MCP %s sent %s", record.name, method)
+
+    async def sample(self, record: MCPConfigRecord, messages: list[Any], params: Any) -> str:
+        if self._claude_client is None or self._sampling_model is None:
+            raise MCPSamplingError("Sampling is not enabled on this client")
+        if record.org_id is None:
+            raise MCPSamplingError("Platform MCP servers cannot request sampling")
+        allowed = False
+        if self._policy_service is not None:
+            policy = await self._policy_service.get_policy(record.org_id)
+            allowed = is_sampling_allowed(policy, record.name)
+        if not allowed:
+            log_security_event(
+                logger,
+                logging.WARNING,
+                "Refused sampling request from MCP %s by organization policy",
+                record.name,
+                extra=security_extra(
+                    "mcp_sampling.denied",
+                    "mcp_configuration",
+                    record.id,
+                    organization_id=record.org_id,
+                ),
+            )
+            raise MCPSamplingError(f"Sampling is not allowed for {record.name}")
+
+        chat = [
+            {"role": message.role, "content": message.content.text}
+            for message in messages
+            if getattr(message.content, "type", None) == "text"
+        ]
+        if not chat:
+            raise MCPSamplingError("Only text sampling messages are supported")
+        max_tokens = min(params.maxTokens or self._sampling_max_tokens, self._sampling_max_tokens)
+        log_security_event(
+            logger,
+            logging.INFO,
+            "Running sampling request from MCP %s",
+            record.name,
+            extra=security_extra(
+                "mcp_sampling.request",
+                "mcp_configuration",
+                record.id,
+                organization_id=record.org_id,
+                max_tokens=max_tokens,
+            ),
+        )
+        session_id = f"mcp-sampling-{uuid4()}"
+        try:
+            result = await self._claude_client.complete(
+                session_id=session_id,
+                messages=chat,
+                temperature=params.temperature if params.temperature is not None else 1.0,
+                max_tokens=max_tokens,
+                model=self._sampling_model,
+                system_prompt=params.systemPrompt or "",
+                mcp_servers={},
+                disallowed_tools=SAMPLING_DISALLOWED_TOOLS,
+                can_use_tool=_deny_tool,
+                organization_id=record.org_id,
+                max_turns=1,
+            )
+        finally:
+            if self._session_manager is not None:
+                await self._session_manager.release_session(session_id)
+        return result.text
+
+
+async def _deny_tool(tool_name: str, input_data: dict[str, Any], context: Any) -> dict[str, Any]:
+    return {
+        "behavior": "deny",
+        "message": "Tools are not available to MCP sampling requests",
+        "interrupt": True,
+    }
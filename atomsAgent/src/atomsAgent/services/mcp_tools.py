@@ -0,0 +1,494 @@
+"""Direct tool, resource and prompt access on configured MCP servers.
+
+``MCPToolService`` resolves an ``mcp_configurations`` row, checks that the
+calling organization can see it, and runs ``tools/*``, ``resources/*`` or
+``prompts/*`` requests over the shared ``MCPConnectionManager`` connection.
+
+Tool lists are cached per configuration for ``tool_cache_ttl_seconds``; the
+registry drops entries when a configuration changes and the connection manager
+when a connection is closed. Remote calls go through a circuit breaker per
+(operation, configuration) when ``breakers`` is given, and tool calls are
+metered when ``usage`` is given and counted against the configuration's
+``mcp.tool_call:<id>`` error budget when ``error_budget`` is given.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import contextlib
+import time
+from collections.abc import AsyncIterator
+from typing import Any
+from uuid import UUID
+
+from atomsAgent.db.repositories import MCPConfigRecord, MCPRepository
+from atomsAgent.schemas.mcp import (
+    MCPPromptArgument,
+    MCPPromptInfo,
+    MCPPromptRenderResponse,
+    MCPResourceInfo,
+    MCPResourceReadResponse,
+    MCPToolCallResponse,
+    MCPToolInfo,
+)
+from atomsAgent.services.error_budget import ErrorBudgetTracker
+from atomsAgent.services.mcp_breakers import MCPCircuitBreakers
+from atomsAgent.services.mcp_connections import MCPConnectionDrainingError, MCPConnectionManager
+from atomsAgent.services.mcp_policy import MCPToolPolicyService, is_tool_allowed, log_denied_tool
+from atomsAgent.services.mcp_usage import MCPUsageMeter, json_size
+from atomsAgent.utils.caching import SizedLRUCache
+
+
+class MCPToolError(Exception):
+    """Raised when a tool cannot be listed or called; carries the HTTP status and code."""
+
+    def __init__(
+        self,
+        status: int,
+        detail: str,
+        code: str = "MCP_TOOL_ERROR",
+        *,
+        violations: list[dict[str, str]] | None = None,
+    ) -> None:
+        super().__init__(detail)
+        self.status = status
+        self.detail = detail
+        self.code = code
+        self.violations = violations or []
+
+
+class MCPToolService:
+    def __init__(
+        self,
+        repository: MCPRepository,
+        connections: MCPConnectionManager,
+        *,
+        tool_cache: SizedLRUCache | None = None,
+        tool_cache_ttl_seconds: float = 300.0,
+        policy_service: MCPToolPolicyService | None = None,
+        breakers: MCPCircuitBreakers | None = None,
+        usage: MCPUsageMeter | None = None,
+        error_budget: ErrorBudgetTracker | None = None,
+    ) -> None:
+        self._repository = repository
+        self._connections = connections
+        self._tool_cache = tool_cache
+        self._tool_cache_ttl_seconds = tool_cache_ttl_seconds
+        self._policy_service = policy_service
+        self._breakers = breakers
+        self._usage = usage
+        self._error_budget = error_budget
+
+    async def list_tools(
+        self, config_id: UUID, *, organization_id: UUID, refresh: bool = False
+    ) -> list[MCPToolInfo]:
+        record = await self._get_record(config_id, organization_id)
+        return list((await self._tools(record, refresh=refresh)).values())
+
+    async def call_tool(
+        self,
+        config_id: UUID,
+        tool_name: str,
+        arguments: dict[str, Any],
+        *,
+        organization_id: UUID,
+        user_id: UUID | None = None,
+    ) -> MCPToolCallResponse:
+        record = await self._check_call(config_id, tool_name, arguments, organization_id)
+        started = time.perf_counter()
+        try:
+            async with self._session(record, "call_tool") as client:
+                result = await client.call_tool_mcp(tool_name, arguments)
+            response = _call_response(tool_name, result)
+        except Exception as exc:
+            self._meter(record, tool_name, arguments, started, organization_id, user_id, error=exc)
+            raise
+        self._meter(record, tool_name, arguments, started, organization_id, user_id, response)
+        return response
+
+    async def stream_tool(
+        self,
+        config_id: UUID,
+        tool_name: str,
+        arguments: dict[str, Any],
+        *,
+        organization_id: UUID,
+        user_id: UUID | None = None,
+    ) -> AsyncIterator[tuple[str, dict[str, Any]]]:
+        """Check the call up front, then return an iterator of ``(event, data)`` pairs.
+
+        ``progress`` events are relayed from the server's progress notifications
+        while the tool runs, followed by one ``chunk`` per content block and a
+        final ``done``. Failures after the call has started end the stream with an
+        ``error`` event instead of raising.
+        """
+        record = await self._check_call(config_id, tool_name, arguments, organization_id)
+        return self._stream_call(record, tool_name, arguments, organization_id, user_id)
+
+    async def _stream_call(
+        self,
+        record: MCPConfigRecord,
+        tool_name: str,
+        arguments: dict[str, Any],
+        organization_id: UUID,
+        user_id: UUID | None,
+    ) -> AsyncIterator[tuple[str, dict[str, Any]]]:
+        progress: asyncio.Queue[dict[str, Any]] = asyncio.Queue()
+
+        async def on_progress(value: float, total: float | None, message: str | None) -> None:
+            progress.put_nowait({"progress": value, "total": total, "message": message})
+
+        async def run() -> Any:
+            async with self._session(record, "call_tool") as client:
+                return await client.call_tool_mcp(
+                    tool_name, arguments, progress_handler=on_progress
+                )
+
+        started = time.perf_counter()
+        call = asyncio.create_task(run())
+        try:
+            while True:
+                update = asyncio.ensure_future(progress.get())
+                done, _ = await asyncio.wait({call, update}, return_when=asyncio.FIRST_COMPLETED)
+                if update not in done:
+                    update.cancel()
+                    break
+                yield "progress", update.result()
+            while not progress.empty():
+                yield "progress", progress.get_nowait()
+            try:
+                response = _call_response(tool_name, call.result())
+            except MCPToolError as exc:
+                self._meter(
+                    record, tool_name, arguments, started, organization_id, user_id, error=exc
+                )
+                yield "error", {"code": exc.code, "message": exc.detail}
+                return
+            except Exception as exc:
+                self._meter(
+                    record, tool_name, arguments, started, organization_id, user_id, error=exc
+                )
+                yield "error", {"code": "MCP_TOOL_ERROR", "message": str(exc)}
+                return
+            self._meter(record, tool_name, arguments, started, organization_id, user_id, response)
+            for index, block in enumerate(response.content):
+                yield "chunk", {"index": index, "content": block}
+            yield "done", {
+                "tool": tool_name,
+                "is_error": response.is_error,
+                "structured_content": response.structured_content,
+            }
+        finally:
+            # The client went away mid-stream; do not leave the call running.
+            if not call.done():
+                call.cancel()
+                self._meter(
+                    record,
+                    tool_name,
+                    arguments,
+                    started,
+                    organization_id,
+                    user_id,
+                    error=asyncio.CancelledError(),
+                )
+
+    def _meter(
+        self,
+        record: MCPConfigRecord,
+        tool_name: str,
+        arguments: dict[str, Any],
+        started: float,
+        organization_id: UUID,
+        user_id: UUID | None,
+        response: MCPToolCallResponse | None = None,
+        *,
+        error: BaseException | None = None,
+    ) -> None:
+        if isinstance(error, MCPToolError):
+            error_code: str | None = error.code
+        elif isinstance(error, asyncio.CancelledError):
+            error_code = "MCP_CALL_CANCELLED"
+        elif error is not None:
+            error_code = "MCP_TOOL_ERROR"
+        else:
+            error_code = None
+        success = response is not None and not response.is_error
+        # A caller hanging up says nothing about the server's health, and an
+        # ``is_error`` result is the tool's answer (bad input, nothing found),
+        # so only calls that failed outright count against the budget.
+        if self._error_budget is not None and not isinstance(error, asyncio.CancelledError):
+            self._error_budget.record(f"mcp.tool_call:{record.id}", error_code=error_code)
+        if self._usage is None:
+            return
+        self._usage.record(
+            record,
+            tool_name,
+            organization_id=organization_id,
+            user_id=user_id,
+            latency_ms=round((time.perf_counter() - started) * 1000, 2),
+            success=success,
+            request_bytes=json_size(arguments),
+            response_bytes=json_size(response.model_dump(mode="json")) if response else 0,
+            error_code=error_code,
+        )
+
+    async def _check_call(
+        self,
+        config_id: UUID,
+        tool_name: str,
+        arguments: dict[str, Any],
+        organization_id: UUID,
+    ) -> MCPConfigRecord:
+        record = await self._get_record(config_id, organization_id)
+        if self._policy_service is not None:
+            policy = await self._policy_service.get_policy(organization_id)
+            if not is_tool_allowed(policy, record.name, tool_name):
+                log_denied_tool(organization_id, record.name, tool_name)
+                raise MCPToolError(
+                    403,
+                    f"Tool '{tool_name}' on {record.name} is blocked by organization policy",
+                    "MCP_TOOL_DENIED",
+                )
+        tool = (await self._tools(record)).get(tool_name)
+        if tool is None:
+            raise MCPToolError(
+                404, f"Tool '{tool_name}' not found on {record.name}", "MCP_TOOL_NOT_FOUND"
+            )
+        violations = _schema_violations(tool.input_schema, arguments)
+        if violations:
+            fields = ", ".join(dict.fromkeys(item["field"] for item in violations))
+            raise MCPToolError(
+                422,
+                f"Invalid arguments for '{tool_name}': {fields}",
+                "MCP_INVALID_ARGUMENTS",
+                violations=violations,
+            )
+        return record
+
+    async def list_resources(
+        self, config_id: UUID, *, organization_id: UUID
+    ) -> list[MCPResourceInfo]:
+        record = await self._get_record(config_id, organization_id)
+        async with self._session(record, "list_resources") as client:
+            resources = await client.list_resources()
+        return [
+            MCPResourceInfo(
+                uri=str(resource.uri),
+                name=resource.name,
+                description=resource.description,
+                mime_type=resource.mimeType,
+            )
+            for resource in resources
+        ]
+
+    async def read_resource(
+        self, config_id: UUID, uri: str, *, organization_id: UUID
+    ) -> MCPResourceReadResponse:
+        record = await self._get_record(config_id, organization_id)
+        async with self._session(record, "read_resource") as client:
+            contents = await client.read_resource(uri)
+        return MCPResourceReadResponse(
+            uri=uri,
+            contents=[item.model_dump(mode="json", exclude_none=True) for item in contents],
+        )
+
+    async def list_prompts(self, config_id: UUID, *, organization_id: UUID) -> list[MCPPromptInfo]:
+        record = await self._get_record(config_id, organization_id)
+        async with self._session(record, "list_prompts") as client:
+            prompts = await client.list_prompts()
+        return [
+            MCPPromptInfo(
+                name=prompt.name,
+                description=prompt.description,
+                arguments=[
+                    MCPPromptArgument(
+                        name=argument.name,
+                        description=argument.description,
+                        required=bool(argument.required),
+                    )
+                    for argument in prompt.arguments or []
+                ],
+            )
+            for prompt in prompts
+        ]
+
+    async def render_prompt(
+        self,
+        config_id: UUID,
+        prompt_name: str,
+        arguments: dict[str, str],
+        *,
+        organization_id: UUID,
+    ) -> MCPPromptRenderResponse:
+        record = await self._get_record(config_id, organization_id)
+        async with self._session(record, "get_prompt") as client:
+            result = await client.get_prompt(prompt_name, arguments)
+        return MCPPromptRenderResponse(
+            name=prompt_name,
+            description=result.description,
+            messages=[
+                message.model_dump(mode="json", exclude_none=True) for message in result.messages
+            ],
+        )
+
+    async def _tools(
+        self, record: MCPConfigRecord, *, refresh: bool = False
+    ) -> dict[str, MCPToolInfo]:
+        if self._tool_cache is not None and not refresh:
+            cached = self._tool_cache.get(record.id)
+            if cached is not None:
+                return cached
+        async with self._session(record, "list_tools") as client:
+            tools = {tool.name: _tool_info(tool) for tool in await client.list_tools()}
+        if self._tool_cache is not None:
+            self._tool_cache.set(record.id, tools, ttl=self._tool_cache_ttl_seconds)
+        return tools
+
+    @contextlib.asynccontextmanager
+    async def _session(self, record: MCPConfigRecord, operation: str) -> AsyncIterator[Any]:
+        breaker = self._breakers.get(operation, record.id) if self._breakers is not None else None
+        if breaker is not None and not breaker.allow():
+            raise MCPToolError(
+                503,
+                f"{operation} calls to {record.name} are paused after repeated failures",
+                "MCP_CIRCUIT_OPEN",
+            )
+        try:
+            async with self._connections.session(record) as client:
+                yield client
+        except MCPConnectionDrainingError as exc:
+            raise MCPToolError(503, str(exc), "MCP_DRAINING") from exc
+        except Exception:
+            if breaker is not None:
+                breaker.record_failure()
+            raise
+        if breaker is not None:
+            breaker.record_success()
+
+    async def _get_record(self, config_id: UUID, organization_id: UUID) -> MCPConfigRecord:
+        try:
+            record = await self._repository.get_config(config_id)
+        except ValueError as exc:
+            raise MCPToolError(404, str(exc), "MCP_NOT_FOUND") from exc
+        # Platform rows (no org) are visible to everyone; others only to their org.
+        if record.org_id is not None and record.org_id != str(organization_id):
+            raise MCPToolError(
+                403, "MCP configuration belongs to another organization", "MCP_FORBIDDEN"
+            )
+        if record.org_id is None:
+            opt_outs: list[str] = []
+            with contextlib.suppress(ValueError):  # unknown organization
+                opt_outs = await self._repository.get_platform_opt_outs(organization_id)
+            if record.id in opt_outs:
+                raise MCPToolError(
+                    403, "Organization opted out of this platform MCP", "MCP_OPTED_OUT"
+                )
+        if not record.enabled:
+            raise MCPToolError(409, "MCP configuration is disabled", "MCP_DISABLED")
+        return record
+
+
+def _call_response(tool_name: str, result: Any) -> MCPToolCallResponse:
+    return MCPToolCallResponse(
+        tool=tool_name,
+        is_error=bool(result.isError),
+        content=[block.model_dump(mode="json", exclude_none=True) for block in result.content],
+        structured_content=result.structuredContent,
+    )
+
+
+def _tool_info(tool: Any) -> MCPToolInfo:
+    return MCPToolInfo(
+        name=tool.name,
+        description=tool.description,
+        input_schema=tool.inputSchema or {},
+    )
+
+
+_JSON_TYPES: dict[str, tuple[type, ...]] = {
+    "object": (dict,),
+    "array": (list,),
+    "string": (str,),
+    "integer": (int,),
+    "number": (int, float),
+    "boolean": (bool,),
+    "null": (type(None),),
+}
+
+
+def _schema_violations(
+    schema: dict[str, Any], value: Any, path: str = ""
+) -> list[dict[str, str]]:
+    """Check ``value`` against the common subset of JSON Schema used by tool inputs.
+
+    Covers ``type``, ``enum``, ``required``, ``properties``, ``additionalProperties``,
+    ``items`` and the length/range bounds; unknown keywords are ignored so the
+    remote server stays the final judge.
+    """
+    field = path or "$"
+    violations: list[dict[str, str]] = []
+
+    def violation(message: str, at: str = field) -> None:
+        violations.append({"field": at, "message": message})
+
+    expected = schema.get("type")
+    if expected is not None:
+        names = expected if isinstance(expected, list) else [expected]
+        if not any(_is_type(value, name) for name in names):
+            violation(f"expected {' or '.join(names)}, got {_type_name(value)}")
+            return violations
+    if "enum" in schema and value not in schema["enum"]:
+        violation(f"must be one of {schema['enum']}")
+
+    if isinstance(value, dict):
+        properties = schema.get("properties") or {}
+        for name in schema.get("required", []):
+            if name not in value:
+                violation("is required", _join(path, name))
+        for name, item in value.items():
+            if name in properties:
+                violations.extend(_schema_violations(properties[name], item, _join(path, name)))
+            elif schema.get("additionalProperties") is False:
+                violation("is not an accepted argument", _join(path, name))
+            elif isinstance(schema.get("additionalProperties"), dict):
+                violations.extend(
+                    _schema_violations(schema["additionalProperties"], item, _join(path, name))
+                )
+    elif isinstance(value, list):
+        if isinstance(schema.get("items"), dict):
+            for index, item in enumerate(value):
+                violations.extend(_schema_violations(schema["items"], item, f"{field}[{index}]"))
+        if len(value) < schema.get("minItems", 0):
+            violation(f"must contain at least {schema['minItems']} items")
+        if "maxItems" in schema and len(value) > schema["maxItems"]:
+            violation(f"must contain at most {schema['maxItems']} items")
+    elif isinstance(value, str):
+        if len(value) < schema.get("minLength", 0):
+            violation(f"must be at least {schema['minLength']} characters")
+        if "maxLength" in schema and len(value) > schema["maxLength"]:
+            violation(f"must be at most {schema['maxLength']} characters")
+    elif isinstance(value, (int, float)) and not isinstance(value, bool):
+        if "minimum" in schema and value < schema["minimum"]:
+            violation(f"must be >= {schema['minimum']}")
+        if "maximum" in schema and value > schema["maximum"]:
+            violation(f"must be <= {schema['maximum']}")
+    return violations
+
+
+def _is_type(value: Any, name: str) -> bool:
+    if name in ("integer", "number") and isinstance(value, bool):
+        return False
+    if name == "integer" and isinstance(value, float):
+        return value.is_integer()
+    return isinstance(value, _JSON_TYPES.get(name, (object,)))
+
+
+def _type_name(value: Any) -> str:
+    for name, types in _JSON_TYPES.items():
+        if _is_type(value, name):
+            return name
+    return type(value).__name__
+
+
+def _join(path: str, name: str) -> str:
+    return f"{path}.{name}" if path else name
@@ -0,0 +1,180 @@
+"""Metering of direct MCP tool calls.
+
+``MCPToolService`` reports every tool call that reaches a server to
+``MCPUsageMeter.record``: configuration, tool, user, organization, latency,
+outcome and request/response sizes. Rows are buffered in memory and written to
+``mcp_usage`` in batches every ``flush_interval_seconds`` or as soon as
+``max_buffer`` rows are waiting; a batch that fails to write is logged and
+dropped. ``summarize`` reads a time range back and aggregates it by day, tool
+or organization.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import contextlib
+import json
+import logging
+import math
+from collections import defaultdict
+from datetime import datetime, timezone
+from typing import Any
+from uuid import UUID
+
+from atomsAgent.db.repositories import MCPConfigRecord, MCPRepository, MCPUsageRecord
+from atomsAgent.schemas.mcp import MCPUsageBucket, MCPUsageGroupBy, MCPUsageResponse
+
+logger = logging.getLogger(__name__)
+
+_PAGE_SIZE = 1000
+
+
+def json_size(value: Any) -> int:
+    """Size in bytes of ``value`` serialized as compact JSON."""
+    return len(json.dumps(value, separators=(",", ":"), default=str).encode("utf-8"))
+
+
+class MCPUsageMeter:
+    def __init__(
+        self,
+        repository: MCPRepository,
+        *,
+        flush_interval_seconds: float = 10.0,
+        max_buffer: int = 500,
+        query_max_rows: int = 50_000,
+    ) -> None:
+        self._repository = repository
+        self._flush_interval_seconds = flush_interval_seconds
+        self._max_buffer = max_buffer
+        self._query_max_rows = query_max_rows
+        self._buffer: list[dict[str, Any]] = []
+        self._flushing: asyncio.Task[int] | None = None
+        self._task: asyncio.Task[None] | None = None
+
+    def record(
+        self,
+        record: MCPConfigRecord,
+        tool: str,
+        *,
+        organization_id: UUID | str | None,
+        user_id: UUID | str | None,
+        latency_ms: float,
+        success: bool,
+        request_bytes: int,
+        response_bytes: int,
+        error_code: str | None = None,
+    ) -> None:
+        self._buffer.append(
+            {
+                "mcp_id": record.id,
+                "mcp_name": record.name,
+                "tool": tool,
+                "org_id": str(organization_id) if organization_id else None,
+                "user_id": str(user_id) if user_id else None,
+                "latency_ms": latency_ms,
+                "success": success,
+                "request_bytes": request_bytes,
+                "response_bytes": response_bytes,
+                "error_code": error_code,
+                "created_at": datetime.now(timezone.utc).isoformat(),
+            }
+        )
+        if len(self._buffer) >= self._max_buffer and (
+            self._flushing is None or self._flushing.done()
+        ):
+            self._flushing = asyncio.create_task(self.flush())
+
+    async def flush(self) -> int:
+        """Write buffered rows and return how many were written."""
+        rows, self._buffer = self._buffer, []
+        if not rows:
+            return 0
+        try:
+            await self._repository.insert_usage(rows)
+        except Exception as exc:
+            logger.warning("Dropped %d MCP usage rows that failed to write: %s", len(rows), exc)
+            return 0
+        return len(rows)
+
+    def start(self) -> None:
+        if self._task is None or self._task.done():
+            self._task = asyncio.create_task(self._flush_forever())
+
+    async def shutdown(self) -> None:
+        if self._task is not None:
+            self._task.cancel()
+            with contextlib.suppress(asyncio.CancelledError):
+                await self._task
+            self._task = None
+        await self.flush()
+
+    async def summarize(
+        self,
+        *,
+        group_by: MCPUsageGroupBy,
+        since: datetime,
+        until: datetime,
+        organization_id: UUID | None = None,
+        mcp_id: UUID | None = None,
+    ) -> MCPUsageResponse:
+        """Aggregate calls in ``[since, until)``, optionally for one organization or MCP.
+
+        Days are UTC and listed oldest first; tools and organizations are listed
+        by number of calls. Rows buffered in this process are written first.
+        """
+        await self.flush()
+        rows: list[MCPUsageRecord] = []
+        truncated = False
+        while True:
+            limit = min(_PAGE_SIZE, self._query_max_rows - len(rows))
+            page = await self._repository.list_usage(
+                since=since.isoformat(),
+                until=until.isoformat(),
+                organization_id=organization_id,
+                mcp_id=mcp_id,
+                limit=limit,
+                offset=len(rows),
+            )
+            rows.extend(page)
+            if len(page) < limit:
+                break
+            if len(rows) >= self._query_max_rows:
+                truncated = True
+                break
+
+        groups: dict[tuple[str, str | None], list[MCPUsageRecord]] = defaultdict(list)
+        for row in rows:
+            if group_by == "day":
+                groups[(row.created_at[:10], None)].append(row)
+            elif group_by == "tool":
+                groups[(f"{row.mcp_name}/{row.tool}", row.mcp_id)].append(row)
+            else:
+                groups[(row.org_id or "", None)].append(row)
+        items = [_bucket(key, mcp, group) for (key, mcp), group in groups.items()]
+        if group_by == "day":
+            items.sort(key=lambda item: item.key)
+        else:
+            items.sort(key=lambda item: (-item.calls, item.key))
+        return MCPUsageResponse(
+            group_by=group_by, since=since, until=until, items=items, truncated=truncated
+        )
+
+    async def _flush_forever(self) -> None:
+        while True:
+            await asyncio.sleep(self._flush_interval_seconds)
+            await self.flush()
+
+
+def _bucket(key: str, mcp_id: str | None, rows: list[MCPUsageRecord]) -> MCPUsageBucket:
+    latencies = sorted(row.latency_ms for row in rows)
+    return MCPUsageBucket(
+        key=key,
+        mcp_id=mcp_id,
+        calls=len(rows),
+        failures=sum(1 for row in rows if not row.success),
+        users=len({row.user_id for row in rows if row.user_id}),
+        avg_latency_ms=round(sum(latencies) / len(latencies), 2),
+        p95_latency_ms=latencies[math.ceil(0.95 * len(latencies)) - 1],
+        request_bytes=sum(row.request_bytes for row in rows),
+        response_bytes=sum(row.response_bytes for row in rows),
+    )
@@ -70,6 +70,25 @@ class PlatformService:
             offset=offset,
         )
 
+    async def record_audit(
+        self,
+        *,
+        action: str,
+        resource_type: str,
+        resource_id: str | None = None,
+        details: dict[str, Any] | None = None,
+        success: bool = True,
+    ) -> None:
+        await self._repository.insert_audit_log(
+            {
+                "action": action,
+                "resource_type": resource_type,
+                "resource_id": resource_id,
+                "details": details or {},
+                "success": success,
+            }
+        )
+
     @staticmethod
     def _map_admin(record: AdminRecord) -> AdminInfo:
         return AdminInfo(
@@ -59,3 +59,15 @@ class SandboxManager:
                 workspace = self.root_path / sandbox_id
                 if workspace.exists():
                     shutil.rmtree(workspace, ignore_errors=True)
+
+    def disk_usage(self, sandbox_id: str) -> int:
+        """Return the total size in bytes of files inside the sandbox workspace."""
+        workspace = self.root_path / sandbox_id
+        total = 0
+        for dirpath, _, filenames in os.walk(workspace):
+            for filename in filenames:
+                try:
+                    total += os.lstat(os.path.join(dirpath, filename)).st_size
+                except OSError:
+                    continue
+        return total
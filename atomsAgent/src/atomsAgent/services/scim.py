@@ -0,0 +1,389 @@
+"""SCIM 2.0 provisioning service.
+
+Identity providers provision users into ``profiles`` and push group membership,
+which is translated into organization roles (or platform admin rights) using the
+configured ``scim_group_role_map``. Groups are therefore virtual: only groups
+named in the map exist, and their members are derived from role assignments.
+"""
+
+from __future__ import annotations
+
+import json
+import re
+from typing import Any
+from uuid import uuid4
+
+from atomsAgent.db.repositories import SCIMRepository, SCIMUserRecord
+from atomsAgent.schemas.scim import (
+    SCIMEmail,
+    SCIMGroup,
+    SCIMGroupMember,
+    SCIMListResponse,
+    SCIMMeta,
+    SCIMName,
+    SCIMPatchRequest,
+    SCIMUser,
+)
+
+PLATFORM_ADMIN_ROLE = "platform_admin"
+ORGANIZATION_ROLES = {"member", "admin", "owner"}
+
+_FILTER_RE = re.compile(r'^\s*([\w.]+)\s+eq\s+"([^"]*)"\s*$', re.IGNORECASE)
+_MEMBER_PATH_RE = re.compile(r'^members\[value eq "([^"]+)"\]$', re.IGNORECASE)
+_USER_FILTER_COLUMNS = {
+    "id": "id",
+    "username": "email",
+    "externalid": "workos_id",
+    "emails.value": "email",
+}
+
+
+class SCIMError(Exception):
+    """Raised for SCIM protocol errors; carries the HTTP status and scimType."""
+
+    def __init__(self, status: int, detail: str, scim_type: str | None = None) -> None:
+        super().__init__(detail)
+        self.status = status
+        self.detail = detail
+        self.scim_type = scim_type
+
+
+class SCIMService:
+    def __init__(
+        self,
+        repository: SCIMRepository,
+        *,
+        organization_id: str | None,
+        group_role_map: dict[str, str],
+    ) -> None:
+        invalid = set(group_role_map.values()) - ORGANIZATION_ROLES - {PLATFORM_ADMIN_ROLE}
+        if invalid:
+            raise ValueError(f"Unsupported SCIM group roles: {sorted(invalid)}")
+        self._repository = repository
+        self._organization_id = organization_id
+        self._group_role_map = dict(group_role_map)
+
+    # ------------------------------------------------------------------ Users
+    async def list_users(
+        self, *, filter_expr: str | None = None, start_index: int = 1, count: int = 100
+    ) -> SCIMListResponse:
+        filters: dict[str, str] = {}
+        if filter_expr:
+            attribute, value = self._parse_filter(filter_expr)
+            column = _USER_FILTER_COLUMNS.get(attribute.lower())
+            if column is None:
+                raise SCIMError(
+                    400, f"Filtering on '{attribute}' is not supported", "invalidFilter"
+                )
+            filters[column] = f"eq.{value}"
+
+        start_index = max(start_index, 1)
+        records, total = await self._repository.list_users(
+            filters=filters, limit=max(count, 0), offset=start_index - 1
+        )
+        return SCIMListResponse(
+            total_results=total,
+            start_index=start_index,
+            items_per_page=len(records),
+            resources=[self._dump(self._map_user(r)) for r in records],
+        )
+
+    async def get_user(self, user_id: str) -> SCIMUser:
+        return self._map_user(await self._require_user(user_id))
+
+    async def create_user(self, user: SCIMUser) -> SCIMUser:
+        email = self._primary_email(user)
+        existing, _ = await self._repository.list_users(filters={"email": f"eq.{email}"})
+        if existing:
+            raise SCIMError(409, f"User '{email}' already exists", "uniqueness")
+
+        # Deleting only soft-deletes the profile, whose email stays unique, so a
+        # re-provisioned user gets their old profile back.
+        deleted = await self._repository.find_deleted_user(email)
+        if deleted is not None:
+            record = await self._repository.update_user(
+                deleted.id,
+                {"is_deleted": False, "is_approved": True, **self._profile_payload(user)},
+            )
+            if record is None:
+                raise SCIMError(404, f"User '{deleted.id}' not found")
+        else:
+            record = await self._repository.create_user(
+                {"id": str(uuid4()), "is_approved": True, **self._profile_payload(user)}
+            )
+        if self._organization_id:
+            await self._repository.set_member_role(self._organization_id, record.id, "member")
+        return self._map_user(record)
+
+    async def replace_user(self, user_id: str, user: SCIMUser) -> SCIMUser:
+        await self._require_user(user_id)
+        return await self._update_user(user_id, self._profile_payload(user))
+
+    async def patch_user(self, user_id: str, patch: SCIMPatchRequest) -> SCIMUser:
+        current = self._map_user(await self._require_user(user_id))
+        data = self._dump(current)
+        for operation in patch.operations:
+            if operation.op.lower() == "remove":
+                if not operation.path:
+                    raise SCIMError(400, "remove operations require a path", "noTarget")
+                self._remove_user_path(data, operation.path)
+            elif operation.path:
+                self._apply_user_path(data, operation.path, operation.value)
+            elif isinstance(operation.value, dict):
+                for path, value in operation.value.items():
+                    self._apply_user_path(data, path, value)
+        return await self._update_user(user_id, self._profile_payload(SCIMUser(**data)))
+
+    async def delete_user(self, user_id: str) -> None:
+        await self._require_user(user_id)
+        await self._repository.update_user(user_id, {"is_deleted": True, "status": "inactive"})
+        if self._organization_id:
+            await self._repository.remove_member(self._organization_id, user_id)
+
+    # ------------------------------------------------------------------ Groups
+    async def list_groups(
+        self, *, filter_expr: str | None = None, start_index: int = 1, count: int = 100
+    ) -> SCIMListResponse:
+        names = sorted(self._group_role_map)
+        if filter_expr:
+            attribute, value = self._parse_filter(filter_expr)
+            if attribute.lower() not in {"displayname", "id"}:
+                raise SCIMError(
+                    400, f"Filtering on '{attribute}' is not supported", "invalidFilter"
+                )
+            names = [name for name in names if name == value]
+
+        start_index = max(start_index, 1)
+        page = names[start_index - 1 : start_index - 1 + max(count, 0)]
+        groups = [await self.get_group(name) for name in page]
+        return SCIMListResponse(
+            total_results=len(names),
+            start_index=start_index,
+            items_per_page=len(groups),
+            resources=[self._dump(g) for g in groups],
+        )
+
+    async def get_group(self, group_id: str) -> SCIMGroup:
+        role = self._group_role(group_id)
+        member_ids = await self._member_ids(role)
+        return SCIMGroup(
+            id=group_id,
+            display_name=group_id,
+            members=[SCIMGroupMember(value=member_id) for member_id in member_ids],
+            meta=SCIMMeta(resource_type="Group"),
+        )
+
+    async def create_group(self, group: SCIMGroup) -> SCIMGroup:
+        if group.display_name not in self._group_role_map:
+            raise SCIMError(
+                400,
+                f"Group '{group.display_name}' is not mapped to a role",
+                "invalidValue",
+            )
+        return await self.replace_group(group.display_name, group)
+
+    async def replace_group(self, group_id: str, group: SCIMGroup) -> SCIMGroup:
+        role = self._group_role(group_id)
+        current = set(await self._member_ids(role))
+        desired = {member.value for member in group.members}
+        for user_id in sorted(desired - current):
+            await self._add_member(role, user_id)
+        for user_id in sorted(current - desired):
+            await self._remove_member(role, user_id)
+        return await self.get_group(group_id)
+
+    async def patch_group(self, group_id: str, patch: SCIMPatchRequest) -> SCIMGroup:
+        role = self._group_role(group_id)
+        for operation in patch.operations:
+            op = operation.op.lower()
+            path = (operation.path or "").strip()
+            value = operation.value
+            if not path and isinstance(value, dict):
+                if "members" not in value:
+                    continue  # mapped groups cannot be renamed
+                path, value = "members", value["members"]
+
+            if match := _MEMBER_PATH_RE.match(path):
+                if op == "remove":
+                    await self._remove_member(role, match.group(1))
+                continue
+            if path.lower() != "members":
+                continue
+
+            values = self._member_values(value)
+            if op == "add":
+                for user_id in values:
+                    await self._add_member(role, user_id)
+            elif op == "remove":
+                targets = values if value is not None else await self._member_ids(role)
+                for user_id in targets:
+                    await self._remove_member(role, user_id)
+            elif op == "replace":
+                await self.replace_group(
+                    group_id,
+                    SCIMGroup(
+                        display_name=group_id,
+                        members=[SCIMGroupMember(value=v) for v in values],
+                    ),
+                )
+        return await self.get_group(group_id)
+
+    async def delete_group(self, group_id: str) -> None:
+        role = self._group_role(group_id)
+        for user_id in await self._member_ids(role):
+            await self._remove_member(role, user_id)
+
+    # ------------------------------------------------------------------ Helpers
+    async def _require_user(self, user_id: str) -> SCIMUserRecord:
+        record = await self._repository.get_user(user_id)
+        if record is None:
+            raise SCIMError(404, f"User '{user_id}' not found")
+        return record
+
+    async def _update_user(self, user_id: str, payload: dict[str, Any]) -> SCIMUser:
+        record = await self._repository.update_user(user_id, payload)
+        if record is None:
+            raise SCIMError(404, f"User '{user_id}' not found")
+        return self._map_user(record)
+
+    def _group_role(self, group_id: str) -> str:
+        try:
+            return self._group_role_map[group_id]
+        except KeyError as exc:
+            raise SCIMError(404, f"Group '{group_id}' not found") from exc
+
+    def _require_organization(self) -> str:
+        if not self._organization_id:
+            raise SCIMError(400, "SCIM organization is not configured", "invalidValue")
+        return self._organization_id
+
+    async def _member_ids(self, role: str) -> list[str]:
+        if role == PLATFORM_ADMIN_ROLE:
+            emails = await self._repository.list_platform_admin_emails()
+            if not emails:
+                return []
+            quoted = ",".join(json.dumps(email) for email in emails)
+            records, _ = await self._repository.list_users(filters={"email": f"in.({quoted})"})
+            return [record.id for record in records]
+        return await self._repository.list_role_member_ids(self._require_organization(), role)
+
+    async def _add_member(self, role: str, user_id: str) -> None:
+        user = await self._require_user(user_id)
+        if role == PLATFORM_ADMIN_ROLE:
+            if user.email not in await self._repository.list_platform_admin_emails():
+                await self._repository.add_platform_admin(user)
+            return
+        await self._repository.set_member_role(self._require_organization(), user_id, role)
+
+    async def _remove_member(self, role: str, user_id: str) -> None:
+        user = await self._require_user(user_id)
+        if role == PLATFORM_ADMIN_ROLE:
+            await self._repository.remove_platform_admin(user.email)
+        elif role == "member":
+            await self._repository.remove_member(self._require_organization(), user_id)
+        else:
+            # Leaving an elevated-role group demotes rather than removes the member.
+            await self._repository.set_member_role(self._require_organization(), user_id, "member")
+
+    @staticmethod
+    def _member_values(value: Any) -> list[str]:
+        if isinstance(value, dict):
+            value = [value]
+        if not isinstance(value, list):
+            return []
+        return [str(item["value"]) for item in value if isinstance(item, dict) and "value" in item]
+
+    @staticmethod
+    def _parse_filter(filter_expr: str) -> tuple[str, str]:
+        match = _FILTER_RE.match(filter_expr)
+        if match is None:
+            raise SCIMError(400, f"Unsupported filter '{filter_expr}'", "invalidFilter")
+        return match.group(1), match.group(2)
+
+    @staticmethod
+    def _apply_user_path(data: dict[str, Any], path: str, value: Any) -> None:
+        key = path.lower()
+        if key == "active":
+            data["active"] = value if isinstance(value, bool) else str(value).lower() == "true"
+        elif key == "username":
+            data["userName"] = value
+        elif key == "displayname":
+            data["displayName"] = value
+        elif key == "externalid":
+            data["externalId"] = value
+        elif key.startswith("name."):
+            name = dict(data.get("name") or {})
+            field = {"givenname": "givenName", "familyname": "familyName"}.get(
+                key[len("name.") :], "formatted"
+            )
+            name[field] = value
+            data["name"] = name
+        elif key == "name" and isinstance(value, dict):
+            data["name"] = value
+        elif key.startswith("emails"):
+            email = value[0].get("value") if isinstance(value, list) and value else value
+            if isinstance(email, str):
+                data["emails"] = [{"value": email, "primary": True}]
+
+    @staticmethod
+    def _remove_user_path(data: dict[str, Any], path: str) -> None:
+        key = path.lower()
+        if key in {"displayname", "name"}:
+            # Both are stored as full_name, so removing either clears it.
+            data.pop("displayName", None)
+            data.pop("name", None)
+        elif key.startswith("name."):
+            name = dict(data.get("name") or {})
+            field = {"givenname": "givenName", "familyname": "familyName"}.get(
+                key[len("name.") :], "formatted"
+            )
+            name.pop(field, None)
+            data["name"] = name or None
+        elif key == "externalid":
+            data.pop("externalId", None)
+        elif key in {"username", "active"} or key.startswith("emails"):
+            raise SCIMError(400, f"Attribute '{path}' is required", "mutability")
+        else:
+            raise SCIMError(400, f"Unsupported attribute path '{path}'", "invalidPath")
+
+    @staticmethod
+    def _primary_email(user: SCIMUser) -> str:
+        for email in user.emails:
+            if email.primary:
+                return email.value
+        return user.emails[0].value if user.emails else user.user_name
+
+    @classmethod
+    def _profile_payload(cls, user: SCIMUser) -> dict[str, Any]:
+        full_name = user.display_name
+        if not full_name and user.name:
+            full_name = user.name.formatted or " ".join(
+                part for part in (user.name.given_name, user.name.family_name) if part
+            )
+        return {
+            "email": cls._primary_email(user),
+            "full_name": full_name or None,
+            "workos_id": user.external_id,
+            "status": "active" if user.active else "inactive",
+        }
+
+    @staticmethod
+    def _map_user(record: SCIMUserRecord) -> SCIMUser:
+        return SCIMUser(
+            id=record.id,
+            external_id=record.workos_id,
+            user_name=record.email,
+            display_name=record.full_name,
+            name=SCIMName(formatted=record.full_name) if record.full_name else None,
+            emails=[SCIMEmail(value=record.email, primary=True, type="work")],
+            active=record.status != "inactive" and not record.is_deleted,
+            meta=SCIMMeta(
+                resource_type="User",
+                created=record.created_at,
+                last_modified=record.updated_at,
+            ),
+        )
+
+    @staticmethod
+    def _dump(model: SCIMUser | SCIMGroup) -> dict[str, Any]:
+        return model.model_dump(by_alias=True, exclude_none=True)
@@ -0,0 +1,189 @@
+"""Pluggable storage for MCP credentials.
+
+A ``SecretStore`` keeps a credential somewhere and returns a reference that is
+saved in its place (for example in ``mcp_configurations.auth_token``). References
+start with the store's scheme, so values written before a store was configured
+are recognised as plain text and still work:
+
+- ``database`` (``enc:``): Fernet-encrypted under a key derived from
+  ``token_encryption_key`` and kept in the row itself; without a key values stay
+  in plain text as before.
+- ``vault`` (``vault:``): HashiCorp Vault KV v2 under ``vault_mount``/``vault_prefix``.
+- ``aws`` (``aws-sm:``): AWS Secrets Manager, named ``aws_secrets_prefix + key``.
+"""
+
+from __future__ import annotations
+
+import asyncio
+from abc import ABC, abstractmethod
+from dataclasses import replace
+from typing import Any
+
+from cryptography.fernet import InvalidToken, MultiFernet
+
+from atomsAgent.db.repositories import MCPConfigRecord
+from atomsAgent.utils.crypto import MCP_SECRET_KEY_INFO, derive_fernet, legacy_fernet
+
+
+class SecretStoreError(RuntimeError):
+    """Raised when a secret cannot be stored or resolved."""
+
+
+class SecretStore(ABC):
+    scheme: str
+
+    @abstractmethod
+    async def put(self, key: str, value: str) -> str:
+        """Store ``value`` under ``key`` and return the reference to persist."""
+
+    @abstractmethod
+    async def get(self, reference: str) -> str: ...
+
+    async def delete(self, reference: str) -> None:
+        return None
+
+    def owns(self, value: str | None) -> bool:
+        return value is not None and value.startswith(f"{self.scheme}:")
+
+    async def resolve(self, value: str | None) -> str | None:
+        """Return the secret behind a reference; other values are returned unchanged."""
+        if value is None or not self.owns(value):
+            return value
+        return await self.get(value)
+
+    async def resolve_record(self, record: MCPConfigRecord) -> MCPConfigRecord:
+        if not self.owns(record.auth_token):
+            return record
+        secret = await self.get(record.auth_token)  # type: ignore[arg-type]
+        return replace(record, auth_token=secret)
+
+
+class DatabaseSecretStore(SecretStore):
+    scheme = "enc"
+
+    def __init__(self, encryption_key: str | None = None) -> None:
+        self._fernet = None
+        if encryption_key:
+            # Encrypt under the derived key; still read rows written under the
+            # key that was once shared with resume tokens.
+            self._fernet = MultiFernet(
+                [
+                    derive_fernet(encryption_key, MCP_SECRET_KEY_INFO),
+                    legacy_fernet(encryption_key),
+                ]
+            )
+
+    async def put(self, key: str, value: str) -> str:
+        if self._fernet is None:
+            return value
+        return f"{self.scheme}:{self._fernet.encrypt(value.encode('utf-8')).decode('ascii')}"
+
+    async def get(self, reference: str) -> str:
+        if self._fernet is None:
+            raise SecretStoreError("token_encryption_key is required to decrypt stored secrets")
+        try:
+            token = reference.removeprefix(f"{self.scheme}:").encode("ascii")
+            return self._fernet.decrypt(token).decode("utf-8")
+        except (InvalidToken, UnicodeError) as exc:
+            raise SecretStoreError("Stored secret could not be decrypted") from exc
+
+
+class VaultSecretStore(SecretStore):
+    scheme = "vault"
+
+    def __init__(
+        self, url: str, token: str, *, mount: str = "secret", prefix: str = "atoms/mcp"
+    ) -> None:
+        self._url = url.rstrip("/")
+        self._token = token
+        self._mount = mount.strip("/")
+        self._prefix = prefix.strip("/")
+
+    async def put(self, key: str, value: str) -> str:
+        path = f"{self._prefix}/{key}" if self._prefix else key
+        await self._request("POST", f"data/{path}", {"data": {"value": value}})
+        return f"{self.scheme}:{path}"
+
+    async def get(self, reference: str) -> str:
+        body = await self._request("GET", f"data/{reference.removeprefix(f'{self.scheme}:')}")
+        try:
+            return body["data"]["data"]["value"]
+        except (KeyError, TypeError) as exc:
+            raise SecretStoreError(f"Vault secret {reference} has no value") from exc
+
+    async def delete(self, reference: str) -> None:
+        await self._request("DELETE", f"metadata/{reference.removeprefix(f'{self.scheme}:')}")
+
+    async def _request(
+        self, method: str, path: str, payload: dict[str, Any] | None = None
+    ) -> dict[str, Any]:
+        import httpx
+
+        async with httpx.AsyncClient() as client:
+            response = await client.request(
+                method,
+                f"{self._url}/v1/{self._mount}/{path}",
+                headers={"X-Vault-Token": self._token},
+                json=payload,
+            )
+        if response.status_code >= 400:
+            raise SecretStoreError(f"Vault {method} {path} failed: {response.status_code}")
+        return response.json() if response.content else {}
+
+
+class AWSSecretsManagerStore(SecretStore):
+    scheme = "aws-sm"
+
+    def __init__(self, *, region: str | None = None, prefix: str = "atoms/mcp/") -> None:
+        try:
+            import boto3
+        except ImportError as exc:  # pragma: no cover - optional dependency
+            raise SecretStoreError(
+                "boto3 is not installed. Install with `pip install boto3`."
+            ) from exc
+        self._client = boto3.client("secretsmanager", region_name=region)
+        self._prefix = prefix
+
+    async def put(self, key: str, value: str) -> str:
+        name = f"{self._prefix}{key}"
+        client = self._client
+        try:
+            await asyncio.to_thread(client.put_secret_value, SecretId=name, SecretString=value)
+        except client.exceptions.ResourceNotFoundException:
+            await asyncio.to_thread(client.create_secret, Name=name, SecretString=value)
+        return f"{self.scheme}:{name}"
+
+    async def get(self, reference: str) -> str:
+        name = reference.removeprefix(f"{self.scheme}:")
+        response = await asyncio.to_thread(self._client.get_secret_value, SecretId=name)
+        return response["SecretString"]
+
+    async def delete(self, reference: str) -> None:
+        await asyncio.to_thread(
+            self._client.delete_secret,
+            SecretId=reference.removeprefix(f"{self.scheme}:"),
+            ForceDeleteWithoutRecovery=True,
+        )
+
+
+def create_secret_store(settings: Any) -> SecretStore:
+    """Build the store named by ``secret_store_backend``."""
+    backend = getattr(settings, "secret_store_backend", "database")
+    if backend == "vault":
+        url, token = getattr(settings, "vault_url", None), getattr(settings, "vault_token", None)
+        if not url or not token:
+            raise SecretStoreError("vault_url and the vault_token secret are required for Vault")
+        return VaultSecretStore(
+            url,
+            token,
+            mount=getattr(settings, "vault_mount", "secret"),
+            prefix=getattr(settings, "vault_prefix", "atoms/mcp"),
+        )
+    if backend == "aws":
+        return AWSSecretsManagerStore(
+            region=getattr(settings, "aws_secrets_region", None),
+            prefix=getattr(settings, "aws_secrets_prefix", "atoms/mcp/"),
+        )
+    if backend != "database":
+        raise SecretStoreError(f"Unknown secret store backend '{backend}'")
+    return DatabaseSecretStore(getattr(settings, "token_encryption_key", None))
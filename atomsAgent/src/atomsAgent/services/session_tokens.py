@@ -0,0 +1,80 @@
+"""Encrypted session-resume tokens.
+
+A resume token carries just enough state to rehydrate a Claude session that is
+no longer held in memory, e.g. after an idle expiry or a restart. Tokens are
+Fernet tokens (AES-CBC with an HMAC-SHA256 signature) under a key derived from
+``token_encryption_key`` (see ``utils.crypto``), so clients can neither read nor
+forge them, and each token embeds its own issue time.
+
+The token does not carry the conversation: the Claude CLI keeps its transcript
+on local disk, so a token only resumes on the host that issued it (or on
+replicas sharing that CLI state directory). Elsewhere the CLI starts afresh.
+"""
+
+from __future__ import annotations
+
+import json
+import time
+from dataclasses import asdict, dataclass
+
+from cryptography.fernet import InvalidToken
+
+from atomsAgent.utils.crypto import RESUME_TOKEN_KEY_INFO, derive_fernet
+
+
+class InvalidResumeTokenError(ValueError):
+    """Raised when a resume token is malformed, tampered with, or expired."""
+
+
+@dataclass(slots=True)
+class SessionResumeState:
+    session_id: str
+    sdk_session_id: str | None
+    model: str
+    user_id: str | None = None
+    organization_id: str | None = None
+    issued_at: float = 0.0
+
+
+def check_resume_state(
+    state: SessionResumeState,
+    *,
+    session_id: str,
+    user_id: str | None,
+    organization_id: str | None,
+) -> None:
+    """Raise ``InvalidResumeTokenError`` unless ``state`` belongs to this session and caller.
+
+    A token bound to a user or organization only resumes for that same user or
+    organization; a request that leaves them out does not match.
+    """
+    if state.session_id != session_id:
+        raise InvalidResumeTokenError("session resume token does not match session_id")
+    if state.user_id and state.user_id != user_id:
+        raise InvalidResumeTokenError("session resume token was issued to a different user")
+    if state.organization_id and state.organization_id != organization_id:
+        raise InvalidResumeTokenError(
+            "session resume token was issued to a different organization"
+        )
+
+
+class SessionResumeCodec:
+    """Issues and validates resume tokens for ``ClaudeSessionManager``."""
+
+    def __init__(self, secret: str, *, ttl_seconds: int = 86400) -> None:
+        if not secret:
+            raise ValueError("A secret is required to issue session resume tokens")
+        self._fernet = derive_fernet(secret, RESUME_TOKEN_KEY_INFO)
+        self._ttl_seconds = ttl_seconds
+
+    def issue(self, state: SessionResumeState) -> str:
+        payload = asdict(state)
+        payload["issued_at"] = time.time()
+        return self._fernet.encrypt(json.dumps(payload).encode("utf-8")).decode("ascii")
+
+    def decode(self, token: str) -> SessionResumeState:
+        try:
+            raw = self._fernet.decrypt(token.encode("ascii"), ttl=self._ttl_seconds)
+            return SessionResumeState(**json.loads(raw))
+        except (InvalidToken, UnicodeError, ValueError, TypeError) as exc:
+            raise InvalidResumeTokenError("invalid or expired session resume token") from exc
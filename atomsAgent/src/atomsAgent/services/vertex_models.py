@@ -9,6 +9,7 @@ from aiocache import Cache
 from tenacity import AsyncRetrying, retry_if_exception_type, stop_after_attempt, wait_exponential
 
 from atomsAgent.schemas.openai import ModelInfo, ModelListResponse
+from atomsAgent.utils.caching import AsyncCacheAdapter
 
 try:  # pragma: no cover - optional dependency
     from google.auth.transport.requests import (
@@ -43,7 +44,7 @@ class VertexModelService:
         cache_ttl: int = 600,
         credentials_path: str | None = None,
         credentials_json: str | None = None,
-        cache: Cache | None = None,
+        cache: Cache | AsyncCacheAdapter | None = None,
     ) -> None:
         self.project_id = project_id
         self.location = location
@@ -2,6 +2,7 @@ from __future__ import annotations
 
 import pathlib
 from functools import lru_cache
+from typing import Any, Literal
 
 import yaml
 from pydantic import Field
@@ -15,12 +16,44 @@ class ConfigSettings(BaseSettings):
 
     app_version: str = Field(default="0.1.0")
     enable_docs: bool = Field(default=True)
+    # Serves the operator UI at /admin without authentication; only enable it
+    # behind network-level access control (VPN, internal ingress, IP allowlist).
+    enable_admin_ui: bool = Field(default=False)
+
+    # "default" keeps FastAPI's {"detail": ...} errors; "problem" emits RFC 7807
+    # application/problem+json with type URIs under problem_type_base_uri.
+    error_response_format: Literal["default", "problem"] = Field(default="default")
+    problem_type_base_uri: str | None = Field(default=None)
+    # Add the traceback to 500 responses; for development only, it leaks internals.
+    error_include_traceback: bool = Field(default=False)
     cors_allow_origins: list[str] = Field(default_factory=list)
 
+    # Root log level and sinks; see atomsAgent.utils.log_sinks for sink options.
+    log_level: str = Field(default="INFO")
+    # Per-logger overrides, e.g. "atomsAgent.api=debug,httpx=warn,default=info".
+    log_levels: str = Field(default="")
+    log_sinks: list[dict[str, Any]] = Field(default_factory=lambda: [{"type": "stdout"}])
+    # Extra field names to mask in logs, on top of the built-in credential keys.
+    log_redact_keys: list[str] = Field(default_factory=list)
+    # Regexes masked wherever they match, in logs and in error responses.
+    log_redact_patterns: list[str] = Field(default_factory=list)
+    log_mask_emails: bool = Field(default=True)
+    # Write logs from a background thread via a bounded queue.
+    log_async: bool = Field(default=False)
+    log_queue_size: int = Field(default=10_000, ge=1)
+    log_queue_policy: Literal["drop_oldest", "block"] = Field(default="drop_oldest")
+    # Copy security-tagged log records (auth failures, admin actions) to audit_logs.
+    audit_log_bridge: bool = Field(default=True)
+    # Repetitive-line sampling, e.g. {"first": 10, "every": 100, "window_seconds": 60}.
+    log_sampling: dict[str, Any] | None = Field(default=None)
+    # Exit status of log_sinks.fatal() unless the caller passes one.
+    log_fatal_exit_code: int = Field(default=1)
+
     vertex_project_id: str = Field(default="")
     vertex_location: str = Field(default="us-central1")
 
     model_cache_ttl_seconds: int = Field(default=600)
+    model_cache_max_bytes: int = Field(default=1_048_576)
 
     platform_prompt_id: str | None = Field(default=None)
     platform_system_prompt: str | None = Field(default=None)
@@ -31,6 +64,57 @@ class ConfigSettings(BaseSettings):
         default_factory=lambda: ["Read", "Write", "Edit", "Bash", "Skill"]
     )
     default_setting_sources: list[str] = Field(default_factory=list)
+    session_resume_token_ttl_seconds: int = Field(default=86400)
+    # How often sessions idle past session_timeout are released.
+    session_reap_interval_seconds: float = Field(default=60.0)
+
+    # Maximum in-flight chat completions; null disables the limit.
+    max_concurrent_requests_per_user: int | None = Field(default=5)
+    max_concurrent_requests_per_org: int | None = Field(default=50)
+
+    mcp_connection_idle_timeout_seconds: float = Field(default=300.0)
+    mcp_connection_reap_interval_seconds: float = Field(default=60.0)
+    mcp_connection_max_failed_pings: int = Field(default=2)
+    # Maximum concurrent client connections kept per MCP configuration.
+    mcp_connection_pool_size: int = Field(default=4, ge=1)
+    # Seconds between background pings of every enabled MCP; null disables.
+    mcp_health_check_interval_seconds: float | None = Field(default=60.0)
+    mcp_health_check_timeout_seconds: float = Field(default=10.0)
+    mcp_health_failure_threshold: int = Field(default=2)
+    mcp_tool_cache_ttl_seconds: float = Field(default=300.0)
+    mcp_tool_cache_max_bytes: int = Field(default=1_048_576)
+    # Where MCP bearer tokens live: "database" (encrypted in the row with the
+    # token_encryption_key secret), "vault" or "aws". See services/secret_store.py.
+    secret_store_backend: Literal["database", "vault", "aws"] = Field(default="database")
+    vault_url: str | None = Field(default=None)
+    vault_mount: str = Field(default="secret")
+    vault_prefix: str = Field(default="atoms/mcp")
+    aws_secrets_region: str | None = Field(default=None)
+    aws_secrets_prefix: str = Field(default="atoms/mcp/")
+    # Model used for server sampling requests; null refuses all of them.
+    mcp_sampling_model: str | None = Field(default=None)
+    mcp_sampling_max_tokens: int = Field(default=1024, ge=1)
+    mcp_breaker_failure_threshold: int = Field(default=5, ge=1)
+    mcp_breaker_reset_timeout_seconds: float = Field(default=30.0)
+    mcp_breaker_max_breakers: int = Field(default=1024, ge=1)
+    # Limits for stdio MCP processes; see atomsAgent.mcp.sandbox. null disables.
+    mcp_stdio_sandbox: dict[str, Any] | None = Field(default_factory=dict)
+    # Direct tool calls are metered into mcp_usage, written in batches.
+    mcp_usage_metering: bool = Field(default=True)
+    mcp_usage_flush_interval_seconds: float = Field(default=10.0)
+    mcp_usage_max_buffer: int = Field(default=500, ge=1)
+    # Rows read per usage report; larger ranges are reported as truncated.
+    mcp_usage_query_max_rows: int = Field(default=50_000, ge=1)
+    # /health reports error_budget_status "degraded" while an operation's error
+    # rate exceeds the threshold over the window (given at least min_events outcomes).
+    error_budget_window_seconds: float = Field(default=600.0, gt=0)
+    error_budget_threshold: float = Field(default=0.05, gt=0, le=1)
+    error_budget_min_events: int = Field(default=20, ge=1)
+
+    # SCIM provisioning: organization users are provisioned into, and IdP group
+    # display name -> role ("member", "admin", "owner" or "platform_admin").
+    scim_organization_id: str | None = Field(default=None)
+    scim_group_role_map: dict[str, str] = Field(default_factory=dict)
 
     @classmethod
     def from_yaml_file(cls, yaml_path: pathlib.Path) -> ConfigSettings:
@@ -37,6 +37,14 @@ class SecretSettings(BaseSettings):
 
     # Security Configuration
     token_encryption_key: str | None = None
+    # Token for secret_store_backend: vault
+    vault_token: str | None = None
+
+    # SCIM Provisioning Configuration
+    scim_bearer_token: str | None = None
+
+    # Platform Admin Configuration
+    platform_admin_token: str | None = None
 
     # Static API Configuration
     static_api_key: str | None = None
@@ -1,31 +1,161 @@
 from __future__ import annotations
 
-import functools
+import sys
+import threading
 import time
-from collections.abc import Callable
-from typing import Any, TypeVar
+from collections import OrderedDict
+from collections.abc import Callable, Iterable, Mapping
+from dataclasses import dataclass
+from typing import Any
 
-T = TypeVar("T")
+_MAX_SIZE_DEPTH = 4
+_REGISTRY: dict[str, SizedLRUCache] = {}
 
 
-def ttl_cache(ttl_seconds: int) -> Callable[[Callable[..., T]], Callable[..., T]]:
-    """Simple TTL cache decorator for synchronous functions."""
+def approximate_size(value: Any, *, _depth: int = 0) -> int:
+    """Approximate the memory footprint of a cached value in bytes.
 
-    def decorator(func: Callable[..., T]) -> Callable[..., T]:
-        cache: dict[str, tuple[float, T]] = {}
+    Sums ``sys.getsizeof`` over containers, dataclasses and plain objects a few
+    levels deep without serializing anything. Shared references are counted
+    once per occurrence, so the estimate errs high.
+    """
+    size = sys.getsizeof(value)
+    if _depth >= _MAX_SIZE_DEPTH or isinstance(value, (str, bytes, bytearray, int, float)):
+        return size
+    if isinstance(value, Mapping):
+        items: Iterable[Any] = (part for pair in value.items() for part in pair)
+    elif isinstance(value, (list, tuple, set, frozenset)):
+        items = value
+    elif hasattr(value, "__dict__"):
+        items = vars(value).values()
+    elif hasattr(value, "__slots__"):
+        items = (getattr(value, slot, None) for slot in value.__slots__)
+    else:
+        return size
+    return size + sum(approximate_size(item, _depth=_depth + 1) for item in items)
 
-        @functools.wraps(func)
-        def wrapper(*args: Any, **kwargs: Any) -> T:
-            key = repr((args, kwargs))
-            now = time.time()
-            if key in cache:
-                timestamp, value = cache[key]
-                if now - timestamp < ttl_seconds:
-                    return value
-            value = func(*args, **kwargs)
-            cache[key] = (now, value)
-            return value
 
-        return wrapper
+@dataclass(slots=True)
+class CacheStats:
+    name: str
+    entries: int
+    current_bytes: int
+    max_bytes: int
+    hits: int
+    misses: int
+    evictions: int
 
-    return decorator
+
+class SizedLRUCache:
+    """LRU cache bounded by the approximate size of its values in bytes.
+
+    Entries may carry a TTL. Oversized values that could never fit are not stored.
+    """
+
+    def __init__(
+        self,
+        max_bytes: int,
+        *,
+        name: str | None = None,
+        sizeof: Callable[[Any], int] = approximate_size,
+    ) -> None:
+        if max_bytes <= 0:
+            raise ValueError("max_bytes must be positive")
+        self.name = name or f"cache-{id(self):x}"
+        self.max_bytes = max_bytes
+        self._sizeof = sizeof
+        self._entries: OrderedDict[Any, tuple[Any, int, float | None]] = OrderedDict()
+        self._current_bytes = 0
+        self._hits = 0
+        self._misses = 0
+        self._evictions = 0
+        self._lock = threading.Lock()
+        if name:
+            _REGISTRY[name] = self
+
+    def get(self, key: Any, default: Any = None) -> Any:
+        with self._lock:
+            entry = self._entries.get(key)
+            if entry is not None and entry[2] is not None and entry[2] <= time.monotonic():
+                self._remove(key)
+                entry = None
+            if entry is None:
+                self._misses += 1
+                return default
+            self._entries.move_to_end(key)
+            self._hits += 1
+            return entry[0]
+
+    def set(
+        self, key: Any, value: Any, ttl: float | None = None, *, size: int | None = None
+    ) -> bool:
+        """Store ``value``, returning whether it was cached.
+
+        ``ttl`` of ``None`` never expires; ``0`` or less means don't cache (and
+        drops any existing entry). Pass ``size`` when the caller already knows
+        it, to skip estimating.
+        """
+        if ttl is not None and ttl <= 0:
+            self.delete(key)
+            return False
+        if size is None:
+            size = self._sizeof(value)
+        expires_at = time.monotonic() + ttl if ttl is not None else None
+        with self._lock:
+            if key in self._entries:
+                self._remove(key)
+            if size > self.max_bytes:
+                return False
+            while self._current_bytes + size > self.max_bytes and self._entries:
+                self._remove(next(iter(self._entries)))
+                self._evictions += 1
+            self._entries[key] = (value, size, expires_at)
+            self._current_bytes += size
+            return True
+
+    def delete(self, key: Any) -> None:
+        with self._lock:
+            if key in self._entries:
+                self._remove(key)
+
+    def clear(self) -> None:
+        with self._lock:
+            self._entries.clear()
+            self._current_bytes = 0
+
+    def stats(self) -> CacheStats:
+        with self._lock:
+            return CacheStats(
+                name=self.name,
+                entries=len(self._entries),
+                current_bytes=self._current_bytes,
+                max_bytes=self.max_bytes,
+                hits=self._hits,
+                misses=self._misses,
+                evictions=self._evictions,
+            )
+
+    def _remove(self, key: Any) -> None:
+        _, size, _ = self._entries.pop(key)
+        self._current_bytes -= size
+
+
+class AsyncCacheAdapter:
+    """Expose a SizedLRUCache through the awaitable aiocache-style get/set API."""
+
+    def __init__(self, cache: SizedLRUCache) -> None:
+        self.cache = cache
+
+    async def get(self, key: Any, default: Any = None) -> Any:
+        return self.cache.get(key, default)
+
+    async def set(self, key: Any, value: Any, ttl: float | None = None) -> bool:
+        return self.cache.set(key, value, ttl=ttl)
+
+    async def delete(self, key: Any) -> None:
+        self.cache.delete(key)
+
+
+def cache_stats() -> list[CacheStats]:
+    """Return stats for every named cache created in this process."""
+    return [cache.stats() for cache in _REGISTRY.values()]
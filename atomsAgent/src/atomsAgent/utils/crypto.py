@@ -0,0 +1,33 @@
+"""Fernet keys derived from the ``token_encryption_key`` secret.
+
+Each use of the secret gets its own key, derived with HKDF-SHA256 under a
+distinct ``info`` label, so a ciphertext produced for one purpose (say an
+encrypted MCP credential) is never accepted by another (a session resume token).
+"""
+
+from __future__ import annotations
+
+import base64
+import hashlib
+
+from cryptography.fernet import Fernet
+from cryptography.hazmat.primitives import hashes
+from cryptography.hazmat.primitives.kdf.hkdf import HKDF
+
+RESUME_TOKEN_KEY_INFO = b"atoms/resume-token"
+MCP_SECRET_KEY_INFO = b"atoms/mcp-secret"
+
+
+def derive_fernet(secret: str, info: bytes) -> Fernet:
+    key = HKDF(algorithm=hashes.SHA256(), length=32, salt=None, info=info).derive(
+        secret.encode("utf-8")
+    )
+    return Fernet(base64.urlsafe_b64encode(key))
+
+
+def legacy_fernet(secret: str) -> Fernet:
+    """The single key every purpose shared before keys were derived per purpose.
+
+    Only used to read values encrypted under it; never encrypt with it.
+    """
+    return Fernet(base64.urlsafe_b64encode(hashlib.sha256(secret.encode("utf-8")).digest()))
@@ -0,0 +1,45 @@
+from __future__ import annotations
+
+from collections.abc import Mapping
+from typing import Any
+
+SECRET_MASK = "***"
+_SECRET_MARKERS = ("token", "secret", "password", "credential", "authorization", "api_key", "apikey")
+
+
+def is_secret_field(path: str) -> bool:
+    """Return True when any segment of a dotted field path looks like a credential."""
+    return any(marker in segment.lower() for segment in path.split(".") for marker in _SECRET_MARKERS)
+
+
+def field_diff(
+    before: Mapping[str, Any],
+    after: Mapping[str, Any],
+    *,
+    secret_prefixes: tuple[str, ...] = (),
+    prefix: str = "",
+) -> dict[str, dict[str, Any]]:
+    """Compute a flattened field-level diff of two mappings.
+
+    Nested mappings are walked and reported with dotted paths. Values of fields
+    that look like secrets, or that live under one of ``secret_prefixes``, are
+    masked so the diff is safe to persist in audit logs.
+    """
+    changes: dict[str, dict[str, Any]] = {}
+    for key in sorted(set(before) | set(after), key=str):
+        path = f"{prefix}{key}"
+        old, new = before.get(key), after.get(key)
+        if isinstance(old, Mapping | None) and isinstance(new, Mapping | None) and old != new:
+            changes.update(
+                field_diff(
+                    old or {}, new or {}, secret_prefixes=secret_prefixes, prefix=f"{path}."
+                )
+            )
+            continue
+        if old == new:
+            continue
+        if is_secret_field(path) or path.startswith(secret_prefixes):
+            old = SECRET_MASK if old is not None else None
+            new = SECRET_MASK if new is not None else None
+        changes[path] = {"old": old, "new": new}
+    return changes
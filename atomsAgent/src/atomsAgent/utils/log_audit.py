@@ -0,0 +1,99 @@
+"""Mirror security-relevant log records into the durable audit log.
+
+Call sites log security events with ``log_security_event(logger, level, msg,
+extra=security_extra(action, resource_type, ...))``. The record goes through
+normal logging and is also handed straight to the installed ``AuditLogHandler``,
+which turns it into a ``record_audit`` call. Audit entries therefore do not
+depend on logger levels, which can be changed at runtime. The handler carries
+its own ``RedactionFilter`` so entries are redacted like every other sink.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import logging
+import sys
+from collections.abc import Awaitable, Callable
+from concurrent.futures import Future
+from typing import Any
+
+RecordAudit = Callable[..., Awaitable[None]]
+
+_audit_handler: logging.Handler | None = None
+
+
+def security_extra(
+    action: str, resource_type: str, resource_id: str | None = None, **details: Any
+) -> dict[str, Any]:
+    """Build the ``extra=`` mapping that marks a record for the audit bridge."""
+    return {
+        "security": True,
+        "audit_action": action,
+        "audit_resource_type": resource_type,
+        "audit_resource_id": resource_id,
+        "audit_details": details,
+    }
+
+
+def install_audit_handler(handler: logging.Handler | None) -> None:
+    """Set the handler that receives every ``log_security_event``; ``None`` removes it."""
+    global _audit_handler
+    _audit_handler = handler
+
+
+def log_security_event(
+    logger: logging.Logger, level: int, msg: str, *args: Any, extra: dict[str, Any]
+) -> None:
+    """Log a security event and pass it to the audit handler whatever the logger level."""
+    logger.log(level, msg, *args, extra=extra)
+    handler = _audit_handler
+    if handler is not None:
+        handler.handle(logger.makeRecord(logger.name, level, "", 0, msg, args, None, extra=extra))
+
+
+class AuditLogHandler(logging.Handler):
+    """Schedule ``record_audit`` on ``loop`` for every security-tagged record.
+
+    Safe to call from any thread; the insert runs on the application loop and
+    failures are reported on stderr (logging them could recurse into here).
+    """
+
+    def __init__(self, record_audit: RecordAudit, loop: asyncio.AbstractEventLoop) -> None:
+        super().__init__()
+        self._record_audit = record_audit
+        self._loop = loop
+
+    def emit(self, record: logging.LogRecord) -> None:
+        if not getattr(record, "security", False):
+            return
+        try:
+            details = {
+                **getattr(record, "audit_details", {}),
+                "message": record.getMessage(),
+                "logger": record.name,
+                "level": record.levelname,
+                # Copied onto the record from the bound log context.
+                "request_id": getattr(record, "request_id", "-"),
+                "organization_id": getattr(record, "org_id", "-"),
+                "user_id": getattr(record, "user_id", "-"),
+            }
+            coroutine = self._record_audit(
+                action=getattr(record, "audit_action", record.name),
+                resource_type=getattr(record, "audit_resource_type", "log"),
+                resource_id=getattr(record, "audit_resource_id", None),
+                details={key: value for key, value in details.items() if value != "-"},
+                success=record.levelno < logging.WARNING,
+            )
+            future = asyncio.run_coroutine_threadsafe(coroutine, self._loop)
+        except Exception:
+            self.handleError(record)
+            return
+        future.add_done_callback(self._report_failure)
+
+    @staticmethod
+    def _report_failure(future: Future[None]) -> None:
+        if not future.cancelled() and future.exception() is not None:
+            print(
+                f"AuditLogHandler: failed to record audit entry: {future.exception()}",
+                file=sys.stderr,
+            )
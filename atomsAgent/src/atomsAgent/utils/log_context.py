@@ -0,0 +1,77 @@
+"""Per-request logging context.
+
+Fields bound with ``bind_log_context`` live in a ``ContextVar`` and are copied
+onto every ``logging.LogRecord`` created in that context, so tenant-scoped log
+searches work without each call site passing ``extra=``. Unbound fields are set
+to ``"-"`` so format strings such as ``%(org_id)s`` never fail.
+
+When ``opentelemetry-api`` is installed, records also carry the active span's
+``trace_id`` and ``span_id`` so logs can be joined to traces.
+"""
+
+from __future__ import annotations
+
+import logging
+from contextvars import ContextVar, Token
+from typing import Any
+
+try:
+    from opentelemetry import trace as otel_trace
+except ImportError:  # pragma: no cover - tracing is optional
+    otel_trace = None  # type: ignore[assignment]
+
+LOG_CONTEXT_FIELDS = ("request_id", "org_id", "user_id", "session_id")
+TRACE_FIELDS = ("trace_id", "span_id")
+
+_log_context: ContextVar[dict[str, str]] = ContextVar("atoms_log_context", default={})
+_installed = False
+
+
+def get_log_context() -> dict[str, str]:
+    return dict(_log_context.get())
+
+
+def bind_log_context(**fields: Any) -> Token[dict[str, str]]:
+    """Merge ``fields`` into the current context; ``None`` values are ignored."""
+    unknown = set(fields) - set(LOG_CONTEXT_FIELDS)
+    if unknown:
+        raise ValueError(f"Unknown log context fields: {', '.join(sorted(unknown))}")
+    merged = {
+        **_log_context.get(),
+        **{key: str(value) for key, value in fields.items() if value is not None and value != ""},
+    }
+    return _log_context.set(merged)
+
+
+def reset_log_context(token: Token[dict[str, str]]) -> None:
+    _log_context.reset(token)
+
+
+def current_trace_ids() -> tuple[str, str] | None:
+    """Return the active OpenTelemetry ``(trace_id, span_id)`` as hex, if any."""
+    if otel_trace is None:
+        return None
+    span_context = otel_trace.get_current_span().get_span_context()
+    if not span_context.is_valid:
+        return None
+    return f"{span_context.trace_id:032x}", f"{span_context.span_id:016x}"
+
+
+def install_log_context() -> None:
+    """Wrap the log record factory so every record carries the bound context."""
+    global _installed
+    if _installed:
+        return
+    base_factory = logging.getLogRecordFactory()
+
+    def factory(*args: Any, **kwargs: Any) -> logging.LogRecord:
+        record = base_factory(*args, **kwargs)
+        context = _log_context.get()
+        for field in LOG_CONTEXT_FIELDS:
+            setattr(record, field, context.get(field, "-"))
+        trace_ids = current_trace_ids()
+        record.trace_id, record.span_id = trace_ids or ("-", "-")
+        return record
+
+    logging.setLogRecordFactory(factory)
+    _installed = True
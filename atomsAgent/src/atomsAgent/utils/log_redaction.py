@@ -0,0 +1,99 @@
+"""Scrub credentials and PII from log records before any sink formats them.
+
+``RedactionFilter`` is attached to every handler installed by
+``configure_logging``. It masks values under denylisted keys anywhere in
+``extra=`` payloads and in dict-style ``%`` arguments, replaces bearer tokens,
+URL credentials, ``key=value`` pairs under denylisted keys and anything
+matching the configured ``patterns`` in the rendered message, and optionally
+masks email addresses. ``api.errors`` applies the same scrubbing to error
+responses.
+"""
+
+from __future__ import annotations
+
+import logging
+import re
+from collections.abc import Iterable, Mapping
+from typing import Any
+
+from atomsAgent.utils.diffing import SECRET_MASK as MASK
+
+DEFAULT_REDACT_KEYS = (
+    "authorization",
+    "cookie",
+    "password",
+    "secret",
+    "token",
+    "api_key",
+    "apikey",
+)
+
+_BEARER_RE = re.compile(r"(?i)\b(bearer)\s+[A-Za-z0-9._~+/=-]+")
+_URL_CREDENTIALS_RE = re.compile(r"\b([A-Za-z][A-Za-z0-9+.-]*://)[^/\s:@]+:[^/\s@]+@")
+_KEY_VALUE_RE = re.compile(r"\b([A-Za-z0-9_.-]+)=([^&\s#\"',;]+)")
+_EMAIL_RE = re.compile(r"\b([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})\b")
+_STANDARD_ATTRS = frozenset(vars(logging.LogRecord("", 0, "", 0, "", (), None)))
+
+
+class RedactionFilter(logging.Filter):
+    def __init__(
+        self,
+        keys: Iterable[str] = DEFAULT_REDACT_KEYS,
+        *,
+        mask_emails: bool = True,
+        patterns: Iterable[str] = (),
+        preserve: Iterable[str] = (),
+    ) -> None:
+        super().__init__()
+        self.keys = tuple(key.lower() for key in keys)
+        self.mask_emails = mask_emails
+        self.patterns = tuple(re.compile(pattern) for pattern in patterns)
+        # Record extras passed through untouched.
+        self.preserve = frozenset(preserve)
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        if isinstance(record.args, Mapping):
+            record.args = self.redact(record.args)
+        message = self.redact_text(record.getMessage())
+        record.msg, record.args = message, None
+        for key, value in list(vars(record).items()):
+            if key in _STANDARD_ATTRS or key in self.preserve or key.startswith("_"):
+                continue
+            if self.is_sensitive(key):
+                setattr(record, key, MASK)
+            elif isinstance(value, (Mapping, list, tuple, str)):
+                setattr(record, key, self.redact(value))
+        return True
+
+    def is_sensitive(self, key: str) -> bool:
+        # Suffix matching keeps "auth_token" masked without hiding "prompt_tokens".
+        lowered = key.lower()
+        return any(
+            lowered == name or lowered.endswith(("_" + name, "-" + name)) for name in self.keys
+        )
+
+    def redact(self, value: Any) -> Any:
+        if isinstance(value, Mapping):
+            return {
+                key: MASK if self.is_sensitive(str(key)) else self.redact(item)
+                for key, item in value.items()
+            }
+        if isinstance(value, list):
+            return [self.redact(item) for item in value]
+        if isinstance(value, tuple):
+            return tuple(self.redact(item) for item in value)
+        if isinstance(value, str):
+            return self.redact_text(value)
+        return value
+
+    def redact_text(self, text: str) -> str:
+        text = _BEARER_RE.sub(rf"\1 {MASK}", text)
+        text = _URL_CREDENTIALS_RE.sub(rf"\1{MASK}@", text)
+        text = _KEY_VALUE_RE.sub(
+            lambda match: f"{match[1]}={MASK}" if self.is_sensitive(match[1]) else match[0], text
+        )
+        for pattern in self.patterns:
+            text = pattern.sub(MASK, text)
+        if self.mask_emails:
+            text = _EMAIL_RE.sub(rf"\1{MASK}@\2", text)
+        return text
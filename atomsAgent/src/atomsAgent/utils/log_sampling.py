@@ -0,0 +1,78 @@
+"""Throttle repetitive log lines during outages.
+
+``SamplingFilter`` fingerprints records by logger, level, unformatted message
+and exception type, lets the first ``first`` records of each fingerprint
+through per window, then only every ``every``-th one. A loop logging the same
+failure per stream chunk or per breaker check therefore costs a bounded number
+of lines. Each kept record carries ``sampling_suppressed``, the number of
+records of its fingerprint dropped since the previous kept one, when non-zero;
+``suppressed`` is the running total.
+"""
+
+from __future__ import annotations
+
+import logging
+import threading
+import time
+
+_DECISION_ATTR = "_atoms_sampled"
+
+
+class SamplingFilter(logging.Filter):
+    def __init__(
+        self,
+        *,
+        first: int = 10,
+        every: int = 100,
+        window_seconds: float = 60.0,
+        max_fingerprints: int = 10_000,
+    ) -> None:
+        super().__init__()
+        self.first = first
+        self.every = every
+        self.window_seconds = window_seconds
+        self.max_fingerprints = max_fingerprints
+        self.suppressed = 0
+        self._counts: dict[tuple[str, int, str, str], int] = {}
+        self._skipped: dict[tuple[str, int, str, str], int] = {}
+        self._window_started = time.monotonic()
+        self._lock = threading.Lock()
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        # One shared instance is attached to every sink; decide once per record.
+        decision = getattr(record, _DECISION_ATTR, None)
+        if decision is None:
+            decision = self._decide(record)
+            setattr(record, _DECISION_ATTR, decision)
+        return decision
+
+    def _decide(self, record: logging.LogRecord) -> bool:
+        exc_type = record.exc_info[0] if record.exc_info else None
+        fingerprint = (
+            record.name,
+            record.levelno,
+            str(record.msg),
+            exc_type.__name__ if exc_type else "",
+        )
+        with self._lock:
+            now = time.monotonic()
+            if (
+                now - self._window_started >= self.window_seconds
+                or len(self._counts) >= self.max_fingerprints
+            ):
+                self._counts.clear()
+                self._window_started = now
+                # Skipped counts survive the window so they are reported on the
+                # fingerprint's next kept record, unless there are too many.
+                if len(self._skipped) >= self.max_fingerprints:
+                    self._skipped.clear()
+            count = self._counts.get(fingerprint, 0) + 1
+            self._counts[fingerprint] = count
+            if count <= self.first or (count - self.first) % self.every == 0:
+                skipped = self._skipped.pop(fingerprint, 0)
+                if skipped:
+                    record.sampling_suppressed = skipped
+                return True
+            self.suppressed += 1
+            self._skipped[fingerprint] = self._skipped.get(fingerprint, 0) + 1
+            return False
@@ -0,0 +1,558 @@
+"""Log sinks for deployments without a sidecar log shipper.
+
+``configure_logging`` attaches one handler per entry in the ``log_sinks`` setting:
+
+* ``{"type": "stdout", "format": "json" | "text"}``
+* ``{"type": "file", "path": ..., "max_bytes": ... | "when": "midnight", "backup_count": 7}``
+* ``{"type": "syslog", "address": "/dev/log" | "host:514", "facility": "user"}``
+* ``{"type": "loki", "url": ..., "labels": {...}, "headers": {...}}``
+* ``{"type": "cloudwatch", "log_group": ..., "log_stream": ..., "region": ...}``
+
+Every sink fans out from the root logger. Local sinks (stdout, file, syslog)
+also accept ``format``; remote sinks always send JSON, buffering records in
+memory and shipping them in batches from a background thread, retrying with
+exponential backoff before dropping a batch.
+
+With ``log_async`` enabled, the root logger gets a single ``AsyncLogHandler``
+that enqueues records on a bounded queue and a background thread fans them
+out to the sinks, so request handlers never wait on sink I/O.
+
+``fatal`` and ``panic`` are for unrecoverable errors: both log a final
+CRITICAL record with a stack trace and flush every sink first; ``fatal`` then
+runs the ``register_shutdown_hook`` hooks and exits the process.
+"""
+
+from __future__ import annotations
+
+import collections
+import copy
+import json
+import logging
+import logging.handlers
+import os
+import queue
+import sys
+import threading
+import time
+from collections.abc import Callable, Iterable, Mapping
+from datetime import datetime, timezone
+from typing import Any, Literal, NoReturn
+
+import httpx
+
+from atomsAgent.utils.log_context import LOG_CONTEXT_FIELDS, TRACE_FIELDS, install_log_context
+from atomsAgent.utils.log_redaction import DEFAULT_REDACT_KEYS, RedactionFilter
+from atomsAgent.utils.log_sampling import SamplingFilter
+
+TEXT_FORMAT = (
+    "%(asctime)s %(levelname)s %(name)s [request_id=%(request_id)s org_id=%(org_id)s "
+    "user_id=%(user_id)s session_id=%(session_id)s] %(message)s"
+)
+
+_configured_handlers: list[logging.Handler] = []
+_STOP = object()
+_crash_logger = logging.getLogger("atomsAgent.crash")
+_shutdown_hooks: list[Callable[[], None]] = []
+_fatal_exit_code = 1
+_STANDARD_RECORD_ATTRS = frozenset(
+    vars(logging.LogRecord("", 0, "", 0, "", (), None))
+) | {"message", "asctime", *LOG_CONTEXT_FIELDS, *TRACE_FIELDS}
+
+
+class JSONFormatter(logging.Formatter):
+    """One JSON object per line, including the bound request and trace context."""
+
+    def format(self, record: logging.LogRecord) -> str:
+        payload: dict[str, Any] = {
+            "timestamp": datetime.fromtimestamp(record.created, tz=timezone.utc).isoformat(),
+            "level": record.levelname,
+            "logger": record.name,
+            "message": record.getMessage(),
+        }
+        for field in (*LOG_CONTEXT_FIELDS, *TRACE_FIELDS):
+            value = getattr(record, field, "-")
+            if value != "-":
+                payload[field] = value
+        # Attributes passed via ``extra=`` (e.g. the access log's ``http``).
+        for key, value in vars(record).items():
+            if key not in _STANDARD_RECORD_ATTRS and not key.startswith("_"):
+                payload[key] = value
+        if record.exc_info:
+            payload["exception"] = self.formatException(record.exc_info)
+        return json.dumps(payload, default=str)
+
+
+class BufferedSinkHandler(logging.Handler):
+    """Buffers formatted records and ships them in batches from a worker thread.
+
+    Records logged by the sink's own client libraries (``client_loggers``) or
+    from its worker thread are dropped: shipping them would log another request
+    for every batch, forever.
+    """
+
+    client_loggers: tuple[str, ...] = ()
+
+    def __init__(
+        self,
+        *,
+        batch_size: int = 100,
+        flush_interval_seconds: float = 2.0,
+        max_buffer: int = 10_000,
+        max_retries: int = 3,
+        retry_backoff_seconds: float = 0.5,
+    ) -> None:
+        super().__init__()
+        self.batch_size = batch_size
+        self.flush_interval_seconds = flush_interval_seconds
+        self.max_retries = max_retries
+        self.retry_backoff_seconds = retry_backoff_seconds
+        self.dropped = 0
+        self._buffer: collections.deque[tuple[float, str]] = collections.deque(maxlen=max_buffer)
+        self._flush_lock = threading.Lock()
+        self._wake = threading.Event()
+        self._closed = threading.Event()
+        self._worker = threading.Thread(
+            target=self._run, name=f"{type(self).__name__}-worker", daemon=True
+        )
+        self._worker.start()
+
+    def emit(self, record: logging.LogRecord) -> None:
+        if record.thread == self._worker.ident or self._is_client_record(record):
+            return
+        try:
+            line = self.format(record)
+        except Exception:
+            self.handleError(record)
+            return
+        if len(self._buffer) == self._buffer.maxlen:
+            self.dropped += 1
+        self._buffer.append((record.created, line))
+        if len(self._buffer) >= self.batch_size:
+            self._wake.set()
+
+    def flush(self) -> None:
+        with self._flush_lock:
+            while self._buffer:
+                batch = [
+                    self._buffer.popleft()
+                    for _ in range(min(self.batch_size, len(self._buffer)))
+                ]
+                self._send_with_retry(batch)
+
+    def close(self) -> None:
+        self._closed.set()
+        self._wake.set()
+        self._worker.join(timeout=self.flush_interval_seconds + 5)
+        self.flush()
+        super().close()
+
+    def send(self, batch: list[tuple[float, str]]) -> None:
+        """Ship ``(created_timestamp, formatted_line)`` pairs to the sink."""
+        raise NotImplementedError
+
+    def _is_client_record(self, record: logging.LogRecord) -> bool:
+        return any(
+            record.name == name or record.name.startswith(f"{name}.")
+            for name in self.client_loggers
+        )
+
+    def _send_with_retry(self, batch: list[tuple[float, str]]) -> None:
+        for attempt in range(self.max_retries + 1):
+            try:
+                self.send(batch)
+                return
+            except Exception as exc:
+                if attempt == self.max_retries:
+                    self.dropped += len(batch)
+                    # Logging here could recurse into this handler.
+                    print(
+                        f"{type(self).__name__}: dropping {len(batch)} log records: {exc}",
+                        file=sys.stderr,
+                    )
+                    return
+                time.sleep(self.retry_backoff_seconds * 2**attempt)
+
+    def _run(self) -> None:
+        while not self._closed.is_set():
+            self._wake.wait(self.flush_interval_seconds)
+            self._wake.clear()
+            self.flush()
+
+
+class LokiHandler(BufferedSinkHandler):
+    """Pushes records to Grafana Loki's ``/loki/api/v1/push`` endpoint."""
+
+    client_loggers = ("httpx", "httpcore")
+
+    def __init__(
+        self,
+        url: str,
+        *,
+        labels: Mapping[str, str] | None = None,
+        headers: Mapping[str, str] | None = None,
+        timeout_seconds: float = 10.0,
+        **options: Any,
+    ) -> None:
+        self.url = url.rstrip("/")
+        if not self.url.endswith("/loki/api/v1/push"):
+            self.url += "/loki/api/v1/push"
+        self.labels = dict(labels or {"service": "atomsAgent"})
+        self._client = httpx.Client(headers=dict(headers or {}), timeout=timeout_seconds)
+        super().__init__(**options)
+
+    def send(self, batch: list[tuple[float, str]]) -> None:
+        values = [[str(int(created * 1_000_000_000)), line] for created, line in batch]
+        response = self._client.post(
+            self.url, json={"streams": [{"stream": self.labels, "values": values}]}
+        )
+        response.raise_for_status()
+
+    def close(self) -> None:
+        super().close()
+        self._client.close()
+
+
+class CloudWatchHandler(BufferedSinkHandler):
+    """Writes records to a CloudWatch Logs stream (requires ``boto3``)."""
+
+    client_loggers = ("boto3", "botocore", "urllib3")
+
+    def __init__(
+        self,
+        log_group: str,
+        log_stream: str,
+        *,
+        region: str | None = None,
+        **options: Any,
+    ) -> None:
+        try:
+            import boto3
+        except ImportError as exc:
+            raise RuntimeError(
+                "boto3 is required for the cloudwatch log sink. "
+                "Install with `pip install atoms-agent[cloudwatch]`."
+            ) from exc
+        self.log_group = log_group
+        self.log_stream = log_stream
+        self._client = boto3.client("logs", region_name=region)
+        self._stream_ready = False
+        super().__init__(**options)
+
+    def send(self, batch: list[tuple[float, str]]) -> None:
+        if not self._stream_ready:
+            try:
+                self._client.create_log_stream(
+                    logGroupName=self.log_group, logStreamName=self.log_stream
+                )
+            except self._client.exceptions.ResourceAlreadyExistsException:
+                pass
+            self._stream_ready = True
+        self._client.put_log_events(
+            logGroupName=self.log_group,
+            logStreamName=self.log_stream,
+            logEvents=[
+                {"timestamp": int(created * 1000), "message": line} for created, line in batch
+            ],
+        )
+
+
+class AsyncLogHandler(logging.handlers.QueueHandler):
+    """Queue records for ``handlers`` and write them from a background thread.
+
+    When the queue is full, ``drop_oldest`` discards the oldest queued record
+    (counted in ``dropped``) and ``block`` waits for the writer to catch up.
+    """
+
+    def __init__(
+        self,
+        handlers: Iterable[logging.Handler],
+        *,
+        max_queue: int = 10_000,
+        policy: Literal["drop_oldest", "block"] = "drop_oldest",
+    ) -> None:
+        if policy not in ("drop_oldest", "block"):
+            raise ValueError(f"Unknown log queue policy '{policy}'")
+        super().__init__(queue.Queue(maxsize=max_queue))
+        self.policy = policy
+        self.dropped = 0
+        self.handlers = list(handlers)
+        self._worker = threading.Thread(
+            target=self._run, name="AsyncLogHandler-worker", daemon=True
+        )
+        self._worker.start()
+
+    def prepare(self, record: logging.LogRecord) -> logging.LogRecord:
+        # Render the message now (args may be mutated later) but keep exc_info
+        # and extras so the sinks' own formatters still see them.
+        record = copy.copy(record)
+        record.msg, record.args = record.getMessage(), None
+        return record
+
+    def enqueue(self, record: logging.LogRecord) -> None:
+        if self.policy == "block":
+            self.queue.put(record)
+            return
+        while True:
+            try:
+                self.queue.put_nowait(record)
+                return
+            except queue.Full:
+                try:
+                    self.queue.get_nowait()
+                    self.queue.task_done()
+                    self.dropped += 1
+                except queue.Empty:
+                    pass
+
+    def flush(self) -> None:
+        # The writer can't wait for itself (e.g. a sink that calls ``fatal``).
+        if self._worker.is_alive() and threading.current_thread() is not self._worker:
+            self.queue.join()
+        for handler in self.handlers:
+            handler.flush()
+
+    def close(self) -> None:
+        if self._worker.is_alive():
+            # Blocks until the writer has drained everything queued before it.
+            self.queue.put(_STOP)
+            self._worker.join()
+        for handler in self.handlers:
+            handler.close()
+        super().close()
+
+    def _run(self) -> None:
+        while (record := self.queue.get()) is not _STOP:
+            try:
+                for handler in self.handlers:
+                    if record.levelno >= handler.level:
+                        handler.handle(record)
+            finally:
+                self.queue.task_done()
+
+
+def build_sink(config: Mapping[str, Any]) -> logging.Handler:
+    options = dict(config)
+    sink_type = options.pop("type", "stdout")
+    if sink_type in ("stdout", "file", "syslog"):
+        handler = _build_local_sink(sink_type, options)
+        if options.get("format", "text") == "json":
+            handler.setFormatter(JSONFormatter())
+        else:
+            handler.setFormatter(logging.Formatter(TEXT_FORMAT))
+        return handler
+    if sink_type == "loki":
+        handler = LokiHandler(options.pop("url"), **options)
+    elif sink_type == "cloudwatch":
+        handler = CloudWatchHandler(options.pop("log_group"), options.pop("log_stream"), **options)
+    else:
+        raise ValueError(f"Unknown log sink type '{sink_type}'")
+    handler.setFormatter(JSONFormatter())
+    return handler
+
+
+def _build_local_sink(sink_type: str, options: Mapping[str, Any]) -> logging.Handler:
+    if sink_type == "stdout":
+        return logging.StreamHandler(sys.stdout)
+    if sink_type == "file":
+        backup_count = int(options.get("backup_count", 5))
+        if "when" in options:
+            return logging.handlers.TimedRotatingFileHandler(
+                options["path"], when=options["when"], backupCount=backup_count, utc=True
+            )
+        return logging.handlers.RotatingFileHandler(
+            options["path"],
+            maxBytes=int(options.get("max_bytes", 10 * 1024 * 1024)),
+            backupCount=backup_count,
+        )
+    address = options.get("address", "/dev/log")
+    if ":" in address:
+        host, port = address.rsplit(":", 1)
+        address = (host, int(port))
+    facility = logging.handlers.SysLogHandler.facility_names[options.get("facility", "user")]
+    return logging.handlers.SysLogHandler(address=address, facility=facility)
+
+
+def configure_logging(
+    level: str,
+    sinks: Iterable[Mapping[str, Any]],
+    *,
+    redact_keys: Iterable[str] = (),
+    redact_patterns: Iterable[str] = (),
+    mask_emails: bool = True,
+    async_queue_size: int | None = None,
+    async_policy: Literal["drop_oldest", "block"] = "drop_oldest",
+    levels: str = "",
+    sampling: Mapping[str, Any] | None = None,
+) -> None:
+    """Replace previously configured sinks on the root logger.
+
+    ``redact_keys`` extends ``DEFAULT_REDACT_KEYS`` and ``redact_patterns`` are
+    regexes masked wherever they match; every sink gets the same
+    ``RedactionFilter`` so no sink can see unredacted records. Passing
+    ``async_queue_size`` routes all sinks through one ``AsyncLogHandler``.
+    ``levels`` is a ``parse_log_levels`` spec applied after ``level``.
+    ``sampling`` holds ``SamplingFilter`` options; ``None`` disables sampling.
+    """
+    per_logger = parse_log_levels(levels)
+    install_log_context()
+    redaction = RedactionFilter(
+        (*DEFAULT_REDACT_KEYS, *redact_keys), mask_emails=mask_emails, patterns=redact_patterns
+    )
+    sampler = SamplingFilter(**sampling) if sampling is not None else None
+    root = logging.getLogger()
+    for handler in _configured_handlers:
+        root.removeHandler(handler)
+        handler.close()
+    _configured_handlers.clear()
+    handlers = [build_sink(config) for config in sinks]
+    if async_queue_size is not None:
+        # Redact before enqueueing: ``prepare`` renders the message from args.
+        handlers = [AsyncLogHandler(handlers, max_queue=async_queue_size, policy=async_policy)]
+    for handler in handlers:
+        if sampler is not None:
+            # Before redaction, which replaces the fingerprinted format string.
+            handler.addFilter(sampler)
+        handler.addFilter(redaction)
+        root.addHandler(handler)
+        _configured_handlers.append(handler)
+    root.setLevel(level.upper())
+    for name, logger_level in per_logger.items():
+        set_log_level(name, logger_level)
+
+
+class PanicError(RuntimeError):
+    """Raised by ``panic`` after the failure has been logged and flushed."""
+
+
+def install_crash_logging(*, exit_code: int = 1) -> Callable[[], None]:
+    """Log uncaught exceptions as structured CRITICAL records before the process dies.
+
+    Without this they only reach stderr as a bare traceback, bypassing the
+    configured sinks. An uncaught exception in the main thread also flushes the
+    sinks and runs the shutdown hooks; the interpreter's exit status is
+    unchanged. ``exit_code`` becomes the default for ``fatal``.
+
+    Returns a callable that restores the previous hooks.
+    """
+    global _fatal_exit_code
+    previous = (sys.excepthook, threading.excepthook, _fatal_exit_code)
+    _fatal_exit_code = exit_code
+
+    def log_uncaught(exc_type, exc, tb) -> None:
+        if issubclass(exc_type, KeyboardInterrupt):
+            sys.__excepthook__(exc_type, exc, tb)
+            return
+        _crash_logger.critical("Uncaught exception", exc_info=(exc_type, exc, tb))
+        _shut_down()
+
+    def log_uncaught_in_thread(args: threading.ExceptHookArgs) -> None:
+        if args.exc_type is SystemExit:
+            return
+        _crash_logger.critical(
+            "Uncaught exception in thread %s",
+            getattr(args.thread, "name", "?"),
+            exc_info=(args.exc_type, args.exc_value, args.exc_traceback),
+        )
+
+    def uninstall() -> None:
+        global _fatal_exit_code
+        sys.excepthook, threading.excepthook, _fatal_exit_code = previous
+
+    sys.excepthook = log_uncaught
+    threading.excepthook = log_uncaught_in_thread
+    return uninstall
+
+
+def register_shutdown_hook(hook: Callable[[], None]) -> Callable[[], None]:
+    """Run ``hook`` before ``fatal`` exits or after an uncaught exception.
+
+    Hooks run in reverse registration order; exceptions they raise are
+    printed to stderr and do not stop the remaining hooks. Returns a callable
+    that unregisters the hook.
+    """
+    _shutdown_hooks.append(hook)
+    return lambda: _shutdown_hooks.remove(hook) if hook in _shutdown_hooks else None
+
+
+def flush_logging() -> None:
+    """Block until every configured sink has written what it was given so far."""
+    for handler in _configured_handlers:
+        handler.flush()
+
+
+def fatal(
+    message: str,
+    *args: Any,
+    exit_code: int | None = None,
+    logger: logging.Logger | None = None,
+) -> NoReturn:
+    """Log a final CRITICAL record with a stack trace, shut down and exit the process.
+
+    The record includes the exception being handled, if any. Sinks are
+    flushed and shutdown hooks run before ``os._exit``, which (unlike
+    ``sys.exit``) cannot be caught and ends the process from any thread.
+    ``exit_code`` defaults to the one given to ``install_crash_logging``.
+    """
+    (logger or _crash_logger).critical(
+        message, *args, exc_info=sys.exc_info()[0] is not None, stack_info=True, stacklevel=2
+    )
+    _shut_down()
+    os._exit(_fatal_exit_code if exit_code is None else exit_code)
+
+
+def panic(message: str, *args: Any, logger: logging.Logger | None = None) -> NoReturn:
+    """Log a CRITICAL record with a stack trace, flush the sinks and raise ``PanicError``.
+
+    Unlike ``fatal`` the caller's stack unwinds normally, so callers up the
+    stack may still recover; if nothing does, the crash hook shuts down.
+    """
+    (logger or _crash_logger).critical(message, *args, stack_info=True, stacklevel=2)
+    flush_logging()
+    raise PanicError(message % args if args else message)
+
+
+def _shut_down() -> None:
+    for hook in reversed(list(_shutdown_hooks)):
+        try:
+            hook()
+        except Exception as exc:
+            # The sinks may be what failed; don't log through them.
+            print(f"Shutdown hook {hook!r} failed: {exc}", file=sys.stderr)
+    flush_logging()
+
+
+def parse_log_levels(spec: str) -> dict[str, str]:
+    """Parse ``"atomsAgent.api=debug,httpx=warn,default=info"`` into logger levels.
+
+    Logger names are used verbatim; ``default`` (or ``root``) sets the root
+    level. Raises ``ValueError`` for malformed entries or unknown levels.
+    """
+    levels: dict[str, str] = {}
+    for entry in filter(None, (part.strip() for part in spec.split(","))):
+        name, sep, level = (item.strip() for item in entry.partition("="))
+        if not sep or not name or not level:
+            raise ValueError(f"Invalid log level entry '{entry}', expected name=level")
+        level = level.upper()
+        if not isinstance(logging.getLevelName(level), int):
+            raise ValueError(f"Unknown log level '{level}' for '{name}'")
+        levels["root" if name == "default" else name] = level
+    return levels
+
+
+def set_log_level(logger_name: str, level: str) -> None:
+    """Change a logger's level at runtime; ``"root"`` or ``""`` targets the root logger."""
+    name = None if logger_name in ("", "root") else logger_name
+    logging.getLogger(name).setLevel(level.upper())
+
+
+def log_levels() -> dict[str, str]:
+    """Return explicitly configured levels for the root and ``atomsAgent`` loggers."""
+    levels = {"root": logging.getLevelName(logging.getLogger().level)}
+    for name, logger in sorted(logging.Logger.manager.loggerDict.items()):
+        if (
+            isinstance(logger, logging.Logger)
+            and logger.level != logging.NOTSET
+            and (name == "atomsAgent" or name.startswith("atomsAgent."))
+        ):
+            levels[name] = logging.getLevelName(logger.level)
+    return levels
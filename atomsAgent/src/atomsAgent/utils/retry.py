@@ -0,0 +1,136 @@
+"""Retry transient upstream failures with jittered exponential backoff.
+
+``retry`` runs a call under tenacity, like the SDK retries elsewhere, but only
+retries errors ``is_retryable`` classifies as transient (connection failures,
+timeouts and 408/425/429/5xx responses) and never waits less than the
+``Retry-After`` the upstream asked for. A ``Retry-After`` longer than the
+policy's ``max_delay`` ends the retries instead of stalling the caller.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import email.utils
+import logging
+import time
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass
+from typing import Any, TypeVar
+
+import httpx
+from tenacity import (
+    AsyncRetrying,
+    RetryCallState,
+    retry_if_exception,
+    stop_after_attempt,
+    wait_random_exponential,
+)
+
+T = TypeVar("T")
+
+logger = logging.getLogger(__name__)
+
+RETRYABLE_STATUS_CODES = frozenset({408, 425, 429, 500, 502, 503, 504})
+
+
+@dataclass(frozen=True, slots=True)
+class RetryPolicy:
+    max_attempts: int = 3
+    initial_delay: float = 0.5
+    max_delay: float = 10.0
+
+
+def status_code_of(exc: BaseException) -> int | None:
+    if isinstance(exc, httpx.HTTPStatusError):
+        return exc.response.status_code
+    status_code = getattr(exc, "status_code", None)
+    return status_code if isinstance(status_code, int) else None
+
+
+def is_retryable(exc: BaseException) -> bool:
+    """Whether ``exc`` is a transient failure worth retrying."""
+    if isinstance(exc, httpx.TransportError):
+        return True
+    return status_code_of(exc) in RETRYABLE_STATUS_CODES
+
+
+def retry_after(exc: BaseException) -> float | None:
+    """Seconds the upstream asked us to wait, from ``exc.retry_after`` or the response."""
+    value: Any = getattr(exc, "retry_after", None)
+    if value is None and isinstance(exc, httpx.HTTPStatusError):
+        value = exc.response.headers.get("retry-after")
+    return parse_retry_after(value)
+
+
+def parse_retry_after(value: Any) -> float | None:
+    """Parse a ``Retry-After`` value given in seconds or as an HTTP date."""
+    if value is None:
+        return None
+    try:
+        return max(float(value), 0.0)
+    except (TypeError, ValueError):
+        pass
+    try:
+        when = email.utils.parsedate_to_datetime(value)
+    except (TypeError, ValueError):
+        return None
+    return max(when.timestamp() - time.time(), 0.0)
+
+
+async def retry(
+    fn: Callable[[], Awaitable[T]],
+    policy: RetryPolicy = RetryPolicy(),
+    *,
+    operation: str = "request",
+    sleep: Callable[[float], Awaitable[None]] = asyncio.sleep,
+) -> T:
+    """Call ``fn`` until it succeeds, fails permanently or ``policy`` runs out.
+
+    Each retry is logged with a ``retry`` extra holding the operation, attempt
+    number, error and delay. The last error is re-raised unchanged.
+    """
+    retrying = AsyncRetrying(
+        retry=retry_if_exception(is_retryable)
+        & retry_if_exception(lambda exc: (retry_after(exc) or 0.0) <= policy.max_delay),
+        stop=stop_after_attempt(policy.max_attempts),
+        # "Full jitter": anywhere up to the exponential ceiling.
+        wait=_wait_at_least_retry_after(
+            wait_random_exponential(multiplier=policy.initial_delay, max=policy.max_delay)
+        ),
+        before_sleep=_log_retry(operation, policy),
+        sleep=sleep,
+        reraise=True,
+    )
+    return await retrying(fn)
+
+
+def _wait_at_least_retry_after(
+    backoff: Callable[[RetryCallState], float],
+) -> Callable[[RetryCallState], float]:
+    def wait(retry_state: RetryCallState) -> float:
+        error = retry_state.outcome.exception() if retry_state.outcome else None
+        hinted = retry_after(error) if error is not None else None
+        return max(backoff(retry_state), hinted or 0.0)
+
+    return wait
+
+
+def _log_retry(operation: str, policy: RetryPolicy) -> Callable[[RetryCallState], None]:
+    def log(retry_state: RetryCallState) -> None:
+        error = retry_state.outcome.exception() if retry_state.outcome else None
+        delay = retry_state.next_action.sleep if retry_state.next_action else 0.0
+        details = {
+            "operation": operation,
+            "attempt": retry_state.attempt_number,
+            "max_attempts": policy.max_attempts,
+            "error": str(error),
+            "delay_seconds": round(delay, 3),
+        }
+        logger.warning(
+            "%(operation)s failed (attempt %(attempt)s/%(max_attempts)s), "
+            "retrying in %(delay_seconds)ss: %(error)s",
+            details,
+            extra={"retry": details},
+        )
+
+    return log
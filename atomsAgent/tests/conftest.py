@@ -1,3 +1,4 @@
+import logging
 import sys
 from pathlib import Path
 
@@ -5,3 +6,40 @@ ROOT = Path(__file__).resolve().parents[1]
 SRC = ROOT / "src"
 if str(SRC) not in sys.path:
     sys.path.insert(0, str(SRC))
+
+from atomsAgent.db.repositories import MCPConfigRecord  # noqa: E402
+
+
+def make_mcp_record(**overrides) -> MCPConfigRecord:
+    """An organization-scoped ``mcp_configurations`` row; keyword arguments override fields."""
+    fields = {
+        "id": "00000000-0000-0000-0000-000000000001",
+        "org_id": "00000000-0000-0000-0000-000000000002",
+        "user_id": None,
+        "name": "search",
+        "type": "http",
+        "endpoint": "https://search.example.com/mcp",
+        "auth_type": "none",
+        "auth_token": None,
+        "auth_header": None,
+        "config": None,
+        "scope": "organization",
+        "description": None,
+        "created_at": None,
+        "updated_at": None,
+        "created_by": None,
+        "updated_by": None,
+        "enabled": True,
+    }
+    return MCPConfigRecord(**{**fields, **overrides})
+
+
+class CollectingHandler(logging.Handler):
+    """Keeps every record it handles in ``records``."""
+
+    def __init__(self) -> None:
+        super().__init__()
+        self.records: list[logging.LogRecord] = []
+
+    def emit(self, record: logging.LogRecord) -> None:
+        self.records.append(record)
@@ -1,14 +1,24 @@
 import asyncio
+import json
+import logging
+from dataclasses import replace
 from types import SimpleNamespace
 from uuid import UUID
 
+import pytest
+from conftest import make_mcp_record
+from fastapi import HTTPException
 from pydantic import HttpUrl
 
 from atomsAgent.api.routes.chat import get_chat_session, list_chat_sessions
 from atomsAgent.api.routes.mcp import (
     create_mcp_server,
+    create_mcp_server_from_template,
     delete_mcp_server,
+    list_mcp_catalog,
+    list_mcp_server_versions,
     list_mcp_servers,
+    rollback_mcp_server,
     update_mcp_server,
 )
 from atomsAgent.api.routes.openai import create_chat_completion
@@ -16,18 +26,41 @@ from atomsAgent.api.routes.platform import (
     create_platform_admin,
     delete_platform_admin,
     get_audit_logs,
+    get_log_levels,
     get_platform_stats,
+    list_agent_sessions,
     list_platform_admins,
+    terminate_agent_session,
+    update_log_level,
 )
+from atomsAgent.api.routes.scim import (
+    create_scim_user,
+    delete_scim_user,
+    get_scim_group,
+    list_scim_users,
+    patch_scim_group,
+    patch_scim_user,
+    require_scim_token,
+)
+from atomsAgent.api.routes.sessions import session_heartbeat
+from atomsAgent.db.repositories import (
+    AuditLogRecord,
+    MCPConfigRecord,
+    MCPRepository,
+    SCIMUserRecord,
+)
+from atomsAgent.db.supabase import UNIQUE_VIOLATION, SupabaseError, SupabaseResponse
 from atomsAgent.schemas.mcp import (
     MCPConfiguration,
     MCPCreateRequest,
     MCPListResponse,
     MCPMetadata,
     MCPScope,
+    MCPTemplateCreateRequest,
     MCPUpdateRequest,
 )
 from atomsAgent.schemas.openai import ChatCompletionRequest, ChatMessage
+from atomsAgent.schemas.scim import SCIMPatchRequest, SCIMUser
 from atomsAgent.schemas.platform import (
     AddAdminRequest,
     AdminInfo,
@@ -35,15 +68,21 @@ from atomsAgent.schemas.platform import (
     AdminResponse,
     AuditEntry,
     AuditLogResponse,
+    LogLevelUpdateRequest,
     PlatformStats,
+    TerminateSessionRequest,
 )
 from atomsAgent.services import (
     CompletionChunk,
+    ConcurrencyLimiter,
+    MCPCatalog,
+    MCPRegistryService,
     CompletionResult,
     PlatformService,
     PromptOrchestrator,
     UsageStats,
 )
+from atomsAgent.services.scim import SCIMService
 
 
 class FakeHistoryService:
@@ -155,6 +194,37 @@ def test_chat_completion_streaming():
     assert "Hello" in text
 
 
+def test_streaming_rejects_a_foreign_resume_token_before_streaming():
+    class RejectingClaudeClient(FakeClaudeClient):
+        def check_resume_token(self, session_id, resume_token, *, user_id, organization_id):
+            raise ValueError("session resume token was issued to a different user")
+
+        async def stream_complete(self, **kwargs):  # pragma: no cover - must not run
+            raise AssertionError("stream started with a rejected resume token")
+            yield
+
+    request = ChatCompletionRequest(
+        model="claude-4.5-haiku",
+        messages=[ChatMessage(role="user", content="Say hello")],
+        stream=True,
+        metadata={"session_id": "session-1", "resume_token": "token", "user_id": "user-2"},
+    )
+
+    with pytest.raises(HTTPException) as exc_info:
+        asyncio.run(
+            create_chat_completion(
+                request,
+                authorization=None,
+                claude_client=RejectingClaudeClient(),
+                prompt_orchestrator=FakePromptOrchestrator(),
+                history_service=FakeHistoryService(),
+                concurrency_limiter=ConcurrencyLimiter(),
+                tool_policies=None,
+            )
+        )
+    assert exc_info.value.status_code == 400
+
+
 class FakeMCPService:
     def __init__(self):
         self.list_called_with = None
@@ -176,7 +246,7 @@ class FakeMCPService:
         )
         return MCPListResponse(items=[item])
 
-    async def create(self, payload: MCPCreateRequest):
+    async def create(self, payload: MCPCreateRequest, **kwargs):
         self.create_called = payload
         scope = payload.scope
         return MCPConfiguration(
@@ -189,7 +259,7 @@ class FakeMCPService:
             enabled=payload.enabled,
         )
 
-    async def update(self, config_id: UUID, payload):
+    async def update(self, config_id: UUID, payload, **kwargs):
         self.update_called = (config_id, payload)
         scope = MCPScope(
             type="organization", organization_id=UUID("00000000-0000-0000-0000-000000000003")
@@ -204,7 +274,7 @@ class FakeMCPService:
             enabled=payload.enabled if payload.enabled is not None else True,
         )
 
-    async def delete(self, config_id: UUID):
+    async def delete(self, config_id: UUID, **kwargs):
         self.delete_called = config_id
 
 
@@ -245,6 +315,459 @@ def test_mcp_flows():
     asyncio.run(_run())
 
 
+def test_mcp_catalog_create_from_template():
+    async def _run() -> None:
+        service = FakeMCPService()
+        catalog = MCPCatalog()
+        org_id = UUID("00000000-0000-0000-0000-000000000004")
+
+        listing = await list_mcp_catalog(catalog=catalog)
+        assert {"github", "jira", "slack", "postgres"} <= {t.key for t in listing.items}
+
+        created = await create_mcp_server_from_template(
+            MCPTemplateCreateRequest(
+                credentials={"token": "ghp_secret"},
+                scope=MCPScope(type="organization", organization_id=org_id),
+            ),
+            "github",
+            catalog=catalog,
+            service=service,
+        )
+        assert created.name == "github"
+        assert service.create_called.bearer_token == "ghp_secret"
+        assert service.create_called.auth_type == "bearer"
+
+        await create_mcp_server_from_template(
+            MCPTemplateCreateRequest(
+                credentials={"database_url": "postgres://localhost/app"},
+                scope=MCPScope(type="organization", organization_id=org_id),
+            ),
+            "postgres",
+            catalog=catalog,
+            service=service,
+        )
+        assert service.create_called.metadata.env == {"DATABASE_URL": "postgres://localhost/app"}
+
+        with pytest.raises(HTTPException) as missing:
+            await create_mcp_server_from_template(
+                MCPTemplateCreateRequest(
+                    scope=MCPScope(type="organization", organization_id=org_id),
+                ),
+                "github",
+                catalog=catalog,
+                service=service,
+            )
+        assert missing.value.status_code == 400
+
+        with pytest.raises(HTTPException) as unknown:
+            await create_mcp_server_from_template(
+                MCPTemplateCreateRequest(
+                    scope=MCPScope(type="organization", organization_id=org_id),
+                ),
+                "nope",
+                catalog=catalog,
+                service=service,
+            )
+        assert unknown.value.status_code == 404
+
+    asyncio.run(_run())
+
+
+def test_mcp_list_filters_sorts_and_pages_in_the_database():
+    async def _run() -> None:
+        org_id = "00000000-0000-0000-0000-000000000004"
+        user_id = "00000000-0000-0000-0000-000000000002"
+        row = {
+            "id": "00000000-0000-0000-0000-000000000011",
+            "org_id": org_id,
+            "user_id": None,
+            "name": "search",
+            "type": "http",
+            "endpoint": "https://search.example.com/mcp",
+            "auth_type": "bearer",
+            "scope": "org",
+            "enabled": True,
+            "created_at": "2025-01-01T00:00:00Z",
+        }
+
+        class _Client:
+            def __init__(self) -> None:
+                self.calls: list[dict] = []
+
+            async def select(self, table, **kwargs):
+                if table != "mcp_configurations":  # platform opt-out lookup
+                    return SupabaseResponse(data=[])
+                self.calls.append(kwargs)
+                return SupabaseResponse(data=[row], count=3)
+
+        client = _Client()
+        service = MCPRegistryService(MCPRepository(client))
+
+        page = await service.list(
+            organization_id=UUID(org_id),
+            user_id=UUID(user_id),
+            include_platform=True,
+            auth_type="bearer",
+            name_prefix="s_1%",
+            sort="name",
+            descending=True,
+            limit=1,
+            offset=1,
+        )
+        assert [item.name for item in page.items] == ["search"]
+        assert (page.total, page.next_offset) == (3, 2)
+
+        (call,) = client.calls
+        assert call["filters"] == {
+            "enabled": "eq.true",
+            "or": (
+                f"(and(org_id.eq.{org_id},user_id.is.null),"
+                f"and(org_id.eq.{org_id},user_id.eq.{user_id}),"
+                "org_id.is.null)"
+            ),
+            "auth_type": "eq.bearer",
+            "name": "ilike.s\\_1\\%*",
+        }
+        assert call["order"] == ["name.desc", "id.asc"]
+        assert (call["limit"], call["offset"], call["count"]) == (1, 1, True)
+
+        client.calls.clear()
+        await service.list(organization_id=UUID(org_id), include_platform=True, scope="platform")
+        (call,) = client.calls
+        assert call["filters"]["or"] == "(org_id.is.null)"
+        assert call["order"] == ["created_at.asc", "id.asc"]
+
+        client.calls.clear()
+        nothing = await service.list(organization_id=UUID(org_id), scope="user")
+        assert nothing.items == [] and nothing.total == 0
+        assert client.calls == []
+
+    asyncio.run(_run())
+
+
+def test_mcp_update_records_masked_audit_diff():
+    async def _run() -> None:
+        record = make_mcp_record(
+            id="00000000-0000-0000-0000-000000000007",
+            org_id="00000000-0000-0000-0000-000000000004",
+            auth_type="bearer",
+            auth_token="old-token",
+            config=json.dumps({"args": [], "env": {"REGION": "us"}}),
+        )
+
+        class _Repository:
+            async def get_config(self, config_id):
+                return record
+
+            async def update_config(self, config_id, payload):
+                return replace(record, **payload)
+
+        class _AuditRepository:
+            def __init__(self) -> None:
+                self.entries: list[dict] = []
+
+            async def insert_audit_log(self, payload):
+                self.entries.append(payload)
+
+            async def list_resource_audit_logs(self, resource_type, resource_id, **kwargs):
+                return []
+
+        audit = _AuditRepository()
+        service = MCPRegistryService(_Repository(), audit_repository=audit)
+        await service.update(
+            UUID(record.id),
+            MCPUpdateRequest(
+                name="search-v2",
+                bearer_token="new-token",
+                metadata=MCPMetadata(env={"REGION": "eu"}),
+            ),
+        )
+
+        (entry,) = audit.entries
+        assert entry["action"] == "mcp_config.update"
+        changes = entry["details"]["changes"]
+        assert changes["name"] == {"old": "search", "new": "search-v2"}
+        assert changes["auth_token"] == {"old": "***", "new": "***"}
+        assert changes["config.env.REGION"] == {"old": "***", "new": "***"}
+        assert "endpoint" not in changes
+        assert entry["details"]["version"] == 1
+        assert "auth_token" not in entry["details"]["snapshot"]
+
+    asyncio.run(_run())
+
+
+def test_mcp_update_survives_audit_failures():
+    async def _run() -> None:
+        record = make_mcp_record(
+            id="00000000-0000-0000-0000-000000000007",
+            org_id="00000000-0000-0000-0000-000000000004",
+        )
+
+        class _Repository:
+            async def get_config(self, config_id):
+                return record
+
+            async def update_config(self, config_id, payload):
+                return replace(record, **payload)
+
+        class _FailingAuditRepository:
+            async def insert_audit_log(self, payload):
+                raise RuntimeError("audit_logs unavailable")
+
+            async def list_resource_audit_logs(self, resource_type, resource_id, **kwargs):
+                return []
+
+        service = MCPRegistryService(_Repository(), audit_repository=_FailingAuditRepository())
+        updated = await service.update(UUID(record.id), MCPUpdateRequest(name="search-v2"))
+        assert updated.name == "search-v2"
+
+    asyncio.run(_run())
+
+
+def test_mcp_version_numbers_are_retried_when_taken_concurrently():
+    async def _run() -> None:
+        record = make_mcp_record(
+            id="00000000-0000-0000-0000-000000000007",
+            org_id="00000000-0000-0000-0000-000000000004",
+        )
+
+        class _Repository:
+            async def get_config(self, config_id):
+                return record
+
+            async def update_config(self, config_id, payload):
+                return replace(record, **payload)
+
+        class _RacingAuditRepository:
+            """Another writer claims version 1 just before our first insert."""
+
+            def __init__(self) -> None:
+                self.entries: list[AuditLogRecord] = []
+
+            async def insert_audit_log(self, payload):
+                if not self.entries:
+                    self.entries.append(
+                        AuditLogRecord(
+                            id="other",
+                            timestamp="t",
+                            action="mcp_config.update",
+                            resource_type="mcp_configuration",
+                            resource_id=record.id,
+                            details={"version": 1},
+                            success=True,
+                        )
+                    )
+                taken = {entry.details["version"] for entry in self.entries}
+                if payload["details"]["version"] in taken:
+                    raise SupabaseError("duplicate key", status_code=409, code=UNIQUE_VIOLATION)
+                self.entries.insert(0, AuditLogRecord(id="ours", timestamp="t", **payload))
+
+            async def list_resource_audit_logs(self, resource_type, resource_id, **kwargs):
+                return self.entries[: kwargs.get("limit", 100)]
+
+        audit = _RacingAuditRepository()
+        service = MCPRegistryService(_Repository(), audit_repository=audit)
+        await service.update(UUID(record.id), MCPUpdateRequest(name="search-v2"))
+
+        assert [entry.details["version"] for entry in audit.entries] == [2, 1]
+
+    asyncio.run(_run())
+
+
+def test_mcp_versions_and_rollback():
+    async def _run() -> None:
+        config_id = "00000000-0000-0000-0000-000000000008"
+        stored: dict[str, MCPConfigRecord] = {}
+
+        class _Repository:
+            async def create_config(self, payload):
+                stored[config_id] = make_mcp_record(
+                    id=config_id,
+                    org_id=payload.get("org_id"),
+                    user_id=payload.get("user_id"),
+                    name=payload["name"],
+                    type=payload["type"],
+                    endpoint=payload["endpoint"],
+                    auth_type=payload["auth_type"],
+                    auth_token=payload.get("auth_token"),
+                    config=payload.get("config"),
+                    scope=payload["scope"],
+                    created_by="admin@example.com",
+                    enabled=payload["enabled"],
+                )
+                return stored[config_id]
+
+            async def get_config(self, config_id):
+                return stored[str(config_id)]
+
+            async def update_config(self, config_id, payload):
+                stored[str(config_id)] = replace(stored[str(config_id)], **payload)
+                return stored[str(config_id)]
+
+        class _AuditRepository:
+            def __init__(self) -> None:
+                self.entries: list[AuditLogRecord] = []
+
+            async def insert_audit_log(self, payload):
+                self.entries.insert(
+                    0, AuditLogRecord(id=str(len(self.entries)), timestamp="t", **payload)
+                )
+
+            async def list_resource_audit_logs(self, resource_type, resource_id, **kwargs):
+                matches = [e for e in self.entries if e.action in kwargs["actions"]]
+                return matches[: kwargs.get("limit", 100)]
+
+        service = MCPRegistryService(_Repository(), audit_repository=_AuditRepository())
+        org_id = UUID("00000000-0000-0000-0000-000000000004")
+        await service.create(
+            MCPCreateRequest(
+                name="search",
+                endpoint=HttpUrl("https://search.example.com/mcp"),
+                auth_type="bearer",
+                bearer_token="token-1",
+                metadata=MCPMetadata(args=["--fast"], env={"REGION": "us"}),
+                scope=MCPScope(type="organization", organization_id=org_id),
+            )
+        )
+        await service.update(
+            UUID(config_id),
+            MCPUpdateRequest(
+                endpoint=HttpUrl("https://broken.example.com/mcp"),
+                bearer_token="token-2",
+                metadata=MCPMetadata(args=[], env={"REGION": "eu"}),
+            ),
+        )
+
+        versions = (
+            await list_mcp_server_versions(
+                UUID(config_id),
+                organization_id=org_id,
+                is_platform_admin=False,
+                service=service,
+            )
+        ).items
+        assert [(v.version, v.action) for v in versions] == [
+            (2, "mcp_config.update"),
+            (1, "mcp_config.create"),
+        ]
+        assert versions[1].changed_by == "admin@example.com"
+        assert "env" not in versions[1].snapshot["config"]
+
+        restored = await rollback_mcp_server(UUID(config_id), 1, service=service)
+        assert str(restored.endpoint) == "https://search.example.com/mcp"
+        assert restored.metadata.args == ["--fast"]
+        # Secrets are not versioned and keep their current values.
+        assert restored.metadata.env == {"REGION": "eu"}
+        assert stored[config_id].auth_token == "token-2"
+
+        latest = (await service.list_versions(UUID(config_id), organization_id=org_id))[0]
+        assert (latest.version, latest.action) == (3, "mcp_config.rollback")
+
+        with pytest.raises(HTTPException) as forbidden:
+            await list_mcp_server_versions(
+                UUID(config_id),
+                organization_id=UUID("00000000-0000-0000-0000-000000000005"),
+                is_platform_admin=False,
+                service=service,
+            )
+        assert forbidden.value.status_code == 403
+        assert await service.list_versions(
+            UUID(config_id), organization_id=None, platform_admin=True
+        )
+
+        with pytest.raises(HTTPException) as missing:
+            await rollback_mcp_server(UUID(config_id), 9, service=service)
+        assert missing.value.status_code == 404
+
+    asyncio.run(_run())
+
+
+def test_platform_mcp_is_admin_managed_with_org_opt_out():
+    async def _run() -> None:
+        config_id = "00000000-0000-0000-0000-000000000009"
+        org_id = UUID("00000000-0000-0000-0000-000000000004")
+        stored: dict[str, MCPConfigRecord] = {}
+        org_opt_outs: dict[str, list[str]] = {}
+
+        class _Repository:
+            async def create_config(self, payload):
+                stored[config_id] = make_mcp_record(
+                    id=config_id,
+                    org_id=None,
+                    name=payload["name"],
+                    type=payload["type"],
+                    endpoint=payload["endpoint"],
+                    auth_type=payload["auth_type"],
+                    config=payload.get("config"),
+                    scope=payload["scope"],
+                    enabled=payload["enabled"],
+                )
+                return stored[config_id]
+
+            async def get_config(self, config_id):
+                return stored[str(config_id)]
+
+            async def update_config(self, config_id, payload):
+                raise AssertionError("non-admins must not reach the repository")
+
+            async def list_configs(self, **kwargs):
+                return list(stored.values()), len(stored)
+
+            async def get_platform_opt_outs(self, organization_id):
+                return org_opt_outs.get(str(organization_id), [])
+
+            async def set_platform_opt_outs(self, organization_id, config_ids):
+                org_opt_outs[str(organization_id)] = config_ids
+
+        service = MCPRegistryService(_Repository())
+        payload = MCPCreateRequest(
+            name="docs",
+            endpoint=HttpUrl("https://docs.example.com/mcp"),
+            scope=MCPScope(type="platform"),
+        )
+        with pytest.raises(HTTPException) as forbidden:
+            await create_mcp_server(payload, is_platform_admin=False, service=service)
+        assert forbidden.value.status_code == 403
+
+        created = await create_mcp_server(payload, is_platform_admin=True, service=service)
+        assert created.scope.type == "platform"
+
+        # Organizations see the configuration but cannot change it.
+        with pytest.raises(HTTPException) as read_only:
+            await update_mcp_server(
+                MCPUpdateRequest(name="mine"),
+                UUID(config_id),
+                is_platform_admin=False,
+                service=service,
+            )
+        assert read_only.value.status_code == 403
+
+        await service.opt_out_of_platform(UUID(config_id), org_id)
+        await service.opt_out_of_platform(UUID(config_id), org_id)
+        assert org_opt_outs[str(org_id)] == [config_id]
+        listing = await service.list(organization_id=org_id, include_platform=True)
+        assert [item.opted_out for item in listing.items] == [True]
+
+        await service.opt_in_to_platform(UUID(config_id), org_id)
+        listing = await service.list(organization_id=org_id, include_platform=True)
+        assert [item.opted_out for item in listing.items] == [False]
+
+    asyncio.run(_run())
+
+
+def test_platform_admin_requires_the_configured_token(monkeypatch):
+    from atomsAgent.api import auth
+
+    monkeypatch.setattr(auth, "settings", SimpleNamespace(platform_admin_token=None))
+    assert not asyncio.run(auth.platform_admin("Bearer anything"))
+
+    monkeypatch.setattr(auth, "settings", SimpleNamespace(platform_admin_token="s3cret"))
+    assert asyncio.run(auth.platform_admin("Bearer s3cret"))
+    assert not asyncio.run(auth.platform_admin(None))
+    assert not asyncio.run(auth.platform_admin("Bearer wrong"))
+    assert not asyncio.run(auth.platform_admin("Bearer s3crét"))
+
+
 class FakePlatformService(PlatformService):
     async def get_stats(self):  # type: ignore[override]
         return PlatformStats(
@@ -372,6 +895,35 @@ class FakeHistoryServiceForRoutes(FakeHistoryService):
         return self.sample_session, self.sample_messages
 
 
+def test_log_level_endpoints():
+    async def _run() -> None:
+        logger = logging.getLogger("atomsAgent.mcp.test_levels")
+        try:
+            with pytest.raises(HTTPException) as exc_info:
+                await update_log_level(
+                    LogLevelUpdateRequest(logger="atomsAgent.mcp.test_levels", level="DEBUG"),
+                    is_platform_admin=False,
+                )
+            assert exc_info.value.status_code == 403
+            assert logger.level == logging.NOTSET
+
+            updated = await update_log_level(
+                LogLevelUpdateRequest(logger="atomsAgent.mcp.test_levels", level="DEBUG"),
+                is_platform_admin=True,
+            )
+            assert updated.level == "DEBUG"
+            assert logger.isEnabledFor(logging.DEBUG)
+
+            listing = await get_log_levels(is_platform_admin=True)
+            levels = {item.logger: item.level for item in listing.loggers}
+            assert levels["atomsAgent.mcp.test_levels"] == "DEBUG"
+            assert "root" in levels
+        finally:
+            logger.setLevel(logging.NOTSET)
+
+    asyncio.run(_run())
+
+
 def test_list_chat_sessions_route():
     async def _run() -> None:
         response = await list_chat_sessions(
@@ -398,3 +950,361 @@ def test_get_chat_session_route():
         assert len(response.messages) == 2
 
     asyncio.run(_run())
+
+
+class FakeSessionManager:
+    def __init__(self) -> None:
+        self.session = SimpleNamespace(
+            session_id="atoms_session_1",
+            state="thinking",
+            connected=True,
+            interrupted=False,
+            mcp_status={"github": "connected", "jira": "failed"},
+            last_used=1_700_000_000.0,
+            idle_timeout=600.0,
+            idle_expires_at=1_700_000_600.0,
+        )
+
+    async def heartbeat(self, session_id: str, *, user_id=None, organization_id=None):
+        if session_id != self.session.session_id:
+            return None
+        return self.session
+
+
+def test_session_heartbeat_route():
+    async def _run() -> None:
+        response = await session_heartbeat(
+            "atoms_session_1",
+            user_id=None,
+            organization_id=None,
+            session_manager=FakeSessionManager(),
+        )
+        assert response.state == "thinking"
+        assert response.agent_alive is True
+        assert response.mcp_healthy is False
+        assert [server.name for server in response.mcp_servers] == ["github", "jira"]
+        assert response.idle_timeout_seconds == 600
+
+    asyncio.run(_run())
+
+
+def test_session_heartbeat_unknown_session():
+    async def _run() -> None:
+        with pytest.raises(HTTPException) as exc_info:
+            await session_heartbeat(
+                "missing",
+                user_id=None,
+                organization_id=None,
+                session_manager=FakeSessionManager(),
+            )
+        assert exc_info.value.status_code == 404
+
+    asyncio.run(_run())
+
+
+def _admin_session(session_id: str, organization_id: str) -> SimpleNamespace:
+    return SimpleNamespace(
+        session_id=session_id,
+        state="idle",
+        config=SimpleNamespace(
+            user_id="user-1", organization_id=organization_id, model="claude-4.5-sonnet"
+        ),
+        created_at=1_700_000_000.0,
+        last_used=1_700_000_100.0,
+        idle_expires_at=1_700_003_700.0,
+        tool_usage_count=3,
+        permission_requests=[],
+        prompt_tokens=40,
+        completion_tokens=60,
+        mcp_status={},
+    )
+
+
+class FakeAdminSessionManager:
+    def __init__(self) -> None:
+        self.sessions = {
+            "s1": _admin_session("s1", "org-1"),
+            "s2": _admin_session("s2", "org-2"),
+        }
+        self.terminated: list[tuple[str, str]] = []
+
+    def list_sessions(self, *, organization_id=None, user_id=None, model=None):
+        return [
+            session
+            for session in self.sessions.values()
+            if organization_id is None or session.config.organization_id == organization_id
+        ]
+
+    async def terminate_session(self, session_id: str, *, reason: str):
+        self.terminated.append((session_id, reason))
+        return self.sessions.pop(session_id, None)
+
+
+class AuditRecordingPlatformService(FakePlatformService):
+    def __init__(self) -> None:
+        super().__init__(repository=None)  # type: ignore[arg-type]
+        self.audit_events: list[dict] = []
+
+    async def record_audit(self, **kwargs):  # type: ignore[override]
+        self.audit_events.append(kwargs)
+
+
+def test_admin_session_endpoints():
+    async def _run() -> None:
+        manager = FakeAdminSessionManager()
+        service = AuditRecordingPlatformService()
+
+        listing = await list_agent_sessions(
+            organization_id="org-1",
+            user_id=None,
+            model=None,
+            state=None,
+            is_platform_admin=True,
+            session_manager=manager,
+        )
+        assert listing.count == 1
+        assert listing.sessions[0].prompt_tokens == 40
+
+        response = await terminate_agent_session(
+            TerminateSessionRequest(reason="runaway tool loop"),
+            "s1",
+            is_platform_admin=True,
+            session_manager=manager,
+            service=service,
+        )
+        assert response.session_id == "s1"
+        assert manager.terminated == [("s1", "runaway tool loop")]
+        assert service.audit_events[0]["action"] == "session.terminate"
+        assert service.audit_events[0]["details"]["organization_id"] == "org-1"
+        assert service.audit_events[0]["details"]["requested_by"] == "platform_admin"
+
+        with pytest.raises(HTTPException) as exc_info:
+            await terminate_agent_session(
+                TerminateSessionRequest(),
+                "s1",
+                is_platform_admin=True,
+                session_manager=manager,
+                service=service,
+            )
+        assert exc_info.value.status_code == 404
+
+        with pytest.raises(HTTPException) as exc_info:
+            await terminate_agent_session(
+                TerminateSessionRequest(),
+                "s1",
+                is_platform_admin=False,
+                session_manager=manager,
+                service=service,
+            )
+        assert exc_info.value.status_code == 403
+        assert len(manager.terminated) == 2
+
+    asyncio.run(_run())
+
+
+class FakeSCIMRepository:
+    def __init__(self) -> None:
+        self.users: dict[str, SCIMUserRecord] = {}
+        self.roles: dict[str, str] = {}
+        self.platform_admins: set[str] = set()
+
+    async def list_users(self, *, filters=None, limit=None, offset=None):
+        users = [u for u in self.users.values() if not u.is_deleted]
+        for column, expr in (filters or {}).items():
+            if expr.startswith("in.("):
+                values = json.loads(f"[{expr[4:-1]}]")
+                users = [u for u in users if getattr(u, column) in values]
+            else:
+                users = [u for u in users if expr == f"eq.{getattr(u, column)}"]
+        return users, len(users)
+
+    async def get_user(self, user_id):
+        user = self.users.get(user_id)
+        return None if user is None or user.is_deleted else user
+
+    async def find_deleted_user(self, email):
+        for user in self.users.values():
+            if user.is_deleted and user.email == email:
+                return user
+        return None
+
+    async def create_user(self, payload):
+        if any(u.email == payload["email"] for u in self.users.values()):
+            raise RuntimeError("duplicate key value violates unique constraint")
+        record = SCIMUserRecord(
+            id=payload["id"],
+            email=payload["email"],
+            full_name=payload.get("full_name"),
+            workos_id=payload.get("workos_id"),
+            status=payload.get("status"),
+            is_deleted=False,
+            created_at=None,
+            updated_at=None,
+        )
+        self.users[record.id] = record
+        return record
+
+    async def update_user(self, user_id, payload):
+        record = self.users[user_id]
+        for key, value in payload.items():
+            setattr(record, key, value)
+        return record
+
+    async def list_role_member_ids(self, organization_id, role):
+        return [user_id for user_id, r in self.roles.items() if r == role]
+
+    async def set_member_role(self, organization_id, user_id, role):
+        self.roles[user_id] = role
+
+    async def remove_member(self, organization_id, user_id):
+        self.roles.pop(user_id, None)
+
+    async def list_platform_admin_emails(self):
+        return sorted(self.platform_admins)
+
+    async def add_platform_admin(self, user):
+        self.platform_admins.add(user.email)
+
+    async def remove_platform_admin(self, email):
+        self.platform_admins.discard(email)
+
+
+def test_scim_user_and_group_provisioning():
+    async def _run() -> None:
+        repository = FakeSCIMRepository()
+        service = SCIMService(
+            repository,
+            organization_id="org-1",
+            group_role_map={"atoms-admins": "admin", "platform-ops": "platform_admin"},
+        )
+
+        created = await create_scim_user(
+            SCIMUser(userName="ada@example.com", displayName="Ada", externalId="idp-1"),
+            service=service,
+        )
+        assert created.status_code == 201
+        user_id = json.loads(created.body)["id"]
+        assert repository.roles[user_id] == "member"
+
+        duplicate = await create_scim_user(SCIMUser(userName="ada@example.com"), service=service)
+        assert duplicate.status_code == 409
+        assert json.loads(duplicate.body)["scimType"] == "uniqueness"
+
+        listing = await list_scim_users(
+            filter='userName eq "ada@example.com"', start_index=1, count=10, service=service
+        )
+        assert json.loads(listing.body)["totalResults"] == 1
+
+        await patch_scim_group(
+            "atoms-admins",
+            SCIMPatchRequest(
+                Operations=[{"op": "add", "path": "members", "value": [{"value": user_id}]}]
+            ),
+            service=service,
+        )
+        assert repository.roles[user_id] == "admin"
+
+        await patch_scim_group(
+            "platform-ops",
+            SCIMPatchRequest(
+                Operations=[{"op": "add", "path": "members", "value": [{"value": user_id}]}]
+            ),
+            service=service,
+        )
+        ops = json.loads((await get_scim_group("platform-ops", service=service)).body)
+        assert ops["members"] == [{"value": user_id}]
+
+        await patch_scim_group(
+            "atoms-admins",
+            SCIMPatchRequest(
+                Operations=[{"op": "remove", "path": f'members[value eq "{user_id}"]'}]
+            ),
+            service=service,
+        )
+        assert repository.roles[user_id] == "member"
+
+        deactivated = await patch_scim_user(
+            user_id,
+            SCIMPatchRequest(Operations=[{"op": "replace", "value": {"active": False}}]),
+            service=service,
+        )
+        assert json.loads(deactivated.body)["active"] is False
+
+        missing = await get_scim_group("unknown", service=service)
+        assert missing.status_code == 404
+
+    asyncio.run(_run())
+
+
+def test_scim_token_rejects_non_ascii_tokens(monkeypatch):
+    from atomsAgent.api.routes import scim
+
+    monkeypatch.setattr(scim, "settings", SimpleNamespace(scim_bearer_token="s3cret"))
+    assert asyncio.run(require_scim_token("Bearer s3cret")) is None
+    with pytest.raises(HTTPException) as exc_info:
+        asyncio.run(require_scim_token("Bearer s3crét"))
+    assert exc_info.value.status_code == 401
+
+
+def test_scim_reprovisioning_reactivates_a_deleted_user():
+    async def _run() -> None:
+        repository = FakeSCIMRepository()
+        service = SCIMService(repository, organization_id="org-1", group_role_map={})
+
+        created = await create_scim_user(SCIMUser(userName="ada@example.com"), service=service)
+        user_id = json.loads(created.body)["id"]
+        deleted = await delete_scim_user(user_id, service=service)
+        assert deleted.status_code == 204
+        assert user_id not in repository.roles
+
+        again = await create_scim_user(
+            SCIMUser(userName="ada@example.com", displayName="Ada"), service=service
+        )
+        assert again.status_code == 201
+        body = json.loads(again.body)
+        assert body["id"] == user_id
+        assert body["active"] is True
+        assert body["displayName"] == "Ada"
+        assert repository.roles[user_id] == "member"
+
+    asyncio.run(_run())
+
+
+def test_scim_patch_user_applies_remove_operations():
+    async def _run() -> None:
+        service = SCIMService(FakeSCIMRepository(), organization_id=None, group_role_map={})
+        created = await create_scim_user(
+            SCIMUser(userName="ada@example.com", displayName="Ada", externalId="idp-1"),
+            service=service,
+        )
+        user_id = json.loads(created.body)["id"]
+
+        patched = await patch_scim_user(
+            user_id,
+            SCIMPatchRequest(
+                Operations=[
+                    {"op": "remove", "path": "displayName"},
+                    {"op": "remove", "path": "externalId"},
+                ]
+            ),
+            service=service,
+        )
+        body = json.loads(patched.body)
+        assert "displayName" not in body
+        assert "externalId" not in body
+
+        no_target = await patch_scim_user(
+            user_id, SCIMPatchRequest(Operations=[{"op": "remove"}]), service=service
+        )
+        assert no_target.status_code == 400
+        assert json.loads(no_target.body)["scimType"] == "noTarget"
+
+        required = await patch_scim_user(
+            user_id,
+            SCIMPatchRequest(Operations=[{"op": "remove", "path": "userName"}]),
+            service=service,
+        )
+        assert required.status_code == 400
+        assert json.loads(required.body)["scimType"] == "mutability"
+
+    asyncio.run(_run())
@@ -0,0 +1,64 @@
+from __future__ import annotations
+
+import asyncio
+
+from atomsAgent.utils.caching import (
+    AsyncCacheAdapter,
+    SizedLRUCache,
+    approximate_size,
+    cache_stats,
+)
+
+
+def test_sized_lru_cache_evicts_by_bytes():
+    cache = SizedLRUCache(10, name="test-sized", sizeof=len)
+    cache.set("a", "aaaa")
+    cache.set("b", "bbbb")
+    assert cache.get("a") == "aaaa"  # refresh "a" so "b" is least recently used
+
+    cache.set("c", "cccc")
+    assert cache.get("b") is None
+    assert cache.get("a") == "aaaa"
+    assert cache.get("c") == "cccc"
+
+    assert cache.set("huge", "x" * 11) is False
+    stats = cache.stats()
+    assert stats.current_bytes == 8
+    assert stats.evictions == 1
+    assert stats.hits == 3
+    assert stats.misses == 1
+    assert "test-sized" in {s.name for s in cache_stats()}
+
+
+def test_sized_lru_cache_expires_entries():
+    cache = SizedLRUCache(100, sizeof=len)
+    cache.set("a", "value", ttl=-1)
+    assert cache.get("a", "missing") == "missing"
+    assert cache.stats().current_bytes == 0
+
+    assert cache.set("b", "value", ttl=60) is True
+    assert cache.set("b", "value", ttl=0) is False  # 0 means "don't cache"
+    assert cache.get("b", "missing") == "missing"
+    assert cache.set("c", "value") is True
+    assert cache.get("c") == "value"
+
+
+def test_sized_lru_cache_sizes_values_without_serializing():
+    cache = SizedLRUCache(100, sizeof=lambda value: 1 / 0)
+    assert cache.set("known", object(), size=40) is True
+    assert cache.stats().current_bytes == 40
+
+    small = approximate_size({"tools": ["a"]})
+    large = approximate_size({"tools": ["a" * 1000, "b" * 1000]})
+    assert large - small > 2000
+    assert approximate_size(cache) > 0  # arbitrary objects are sized too
+
+
+def test_async_adapter():
+    adapter = AsyncCacheAdapter(SizedLRUCache(1024))
+
+    async def _run() -> None:
+        await adapter.set("key", {"models": []}, ttl=60)
+        assert await adapter.get("key") == {"models": []}
+
+    asyncio.run(_run())
@@ -127,3 +127,50 @@ def test_server_run(monkeypatch):
     result = runner.invoke(app, ["server", "run", "--host", "0.0.0.0", "--port", "9000"])
     assert result.exit_code == 0
     assert "value" in called
+
+
+def test_server_selftest_exits_nonzero_on_failed_check(monkeypatch):
+    class _FakeResponse:
+        def __init__(self, payload):
+            self._payload = payload
+
+        def raise_for_status(self) -> None:
+            return None
+
+        def json(self):
+            return self._payload
+
+    class _FakeHTTP:
+        def get(self, url, headers=None):
+            if url.endswith("/health"):
+                return _FakeResponse({"status": "healthy"})
+            return _FakeResponse({"data": [{"id": "model-ok"}, {"id": "model-broken"}]})
+
+    class _FakeChatClient:
+        def __init__(self, base_url=None, timeout=60.0) -> None:
+            self.base_url = base_url or "http://localhost:3284"
+            self.client = _FakeHTTP()
+
+        def _get_headers(self):
+            return {}
+
+        def chat(self, messages, model, **kwargs):
+            if model == "model-broken":
+                raise RuntimeError("upstream error")
+            return {"choices": [{"message": {"content": "OK"}}]}
+
+        def __enter__(self):
+            return self
+
+        def __exit__(self, *args) -> None:
+            return None
+
+    monkeypatch.setattr("atomsAgent.cli.main.ChatClient", _FakeChatClient)
+    monkeypatch.setattr("atomsAgent.cli.main._supabase_round_trip", lambda: "ok")
+
+    result = runner.invoke(app, ["server", "selftest"])
+    assert result.exit_code == 1
+    assert "completion:model-broken" in result.stdout
+
+    result = runner.invoke(app, ["server", "selftest", "--model", "model-ok"])
+    assert result.exit_code == 0
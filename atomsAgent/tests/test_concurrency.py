@@ -0,0 +1,119 @@
+from __future__ import annotations
+
+import asyncio
+
+import pytest
+from fastapi import HTTPException
+
+from atomsAgent.api.routes.openai import create_chat_completion
+from atomsAgent.schemas.openai import ChatCompletionRequest, ChatMessage
+from atomsAgent.services import CompletionChunk
+from atomsAgent.services.concurrency import ConcurrencyLimiter, ConcurrencyLimitExceeded
+
+
+def test_limiter_caps_in_flight_requests_per_user_and_org():
+    limiter = ConcurrencyLimiter(max_per_user=2, max_per_org=3)
+
+    first = limiter.acquire(user_id="alice", organization_id="org")
+    second = limiter.acquire(user_id="alice", organization_id="org")
+    with pytest.raises(ConcurrencyLimitExceeded) as exc_info:
+        limiter.acquire(user_id="alice", organization_id="org")
+    assert exc_info.value.scope == "user"
+
+    third = limiter.acquire(user_id="bob", organization_id="org")
+    with pytest.raises(ConcurrencyLimitExceeded) as exc_info:
+        limiter.acquire(user_id="carol", organization_id="org")
+    assert exc_info.value.scope == "organization"
+    # A rejected request must not consume a slot.
+    assert limiter.in_flight("user", "carol") == 0
+
+    first.release()
+    first.release()  # releasing twice is a no-op
+    assert limiter.in_flight("organization", "org") == 2
+    with limiter.acquire(user_id="alice", organization_id="org"):
+        assert limiter.in_flight("user", "alice") == 2
+    assert limiter.in_flight("user", "alice") == 1
+
+    second.release()
+    third.release()
+    assert limiter.in_flight("organization", "org") == 0
+
+
+def test_limiter_ignores_disabled_limits_and_anonymous_requests():
+    limiter = ConcurrencyLimiter(max_per_user=1, max_per_org=None)
+    limiter.acquire(user_id=None, organization_id="org")
+    limiter.acquire(user_id=None, organization_id="org")
+    limiter.acquire(user_id="alice", organization_id="org")
+    with pytest.raises(ConcurrencyLimitExceeded):
+        limiter.acquire(user_id="alice", organization_id="other-org")
+
+
+class _RecordingHistory:
+    def __init__(self) -> None:
+        self.calls: list[str] = []
+
+    async def ensure_session(self, **kwargs):
+        self.calls.append("ensure_session")
+
+    async def sync_user_messages(self, **kwargs):
+        self.calls.append("sync_user_messages")
+
+    async def record_assistant_message(self, **kwargs):
+        self.calls.append("record_assistant_message")
+
+
+class _StreamingClient:
+    async def stream_complete(self, **kwargs):
+        yield CompletionChunk(delta="Hello")
+        yield CompletionChunk(done=True)
+
+
+def _chat_request(**kwargs):
+    return ChatCompletionRequest(
+        model="claude-test",
+        messages=[ChatMessage(role="user", content="hi")],
+        system_prompt="Be brief",
+        metadata={"user_id": "alice", "mcp_servers": {}},
+        **kwargs,
+    )
+
+
+def test_rejected_completion_has_no_side_effects():
+    limiter = ConcurrencyLimiter(max_per_user=1)
+    held = limiter.acquire(user_id="alice")
+    history = _RecordingHistory()
+    with pytest.raises(HTTPException) as exc_info:
+        asyncio.run(
+            create_chat_completion(
+                _chat_request(),
+                authorization=None,
+                claude_client=_StreamingClient(),
+                prompt_orchestrator=None,
+                history_service=history,
+                concurrency_limiter=limiter,
+                tool_policies=None,
+            )
+        )
+    assert exc_info.value.status_code == 429
+    assert history.calls == []
+    held.release()
+
+
+def test_streaming_slot_is_released_when_the_stream_never_starts():
+    async def _run() -> None:
+        limiter = ConcurrencyLimiter(max_per_user=1)
+        response = await create_chat_completion(
+            _chat_request(stream=True),
+            authorization=None,
+            claude_client=_StreamingClient(),
+            prompt_orchestrator=None,
+            history_service=_RecordingHistory(),
+            concurrency_limiter=limiter,
+            tool_policies=None,
+        )
+        assert limiter.in_flight("user", "alice") == 1
+        # The client went away before the first chunk: only the background task runs.
+        await response.background()
+        assert limiter.in_flight("user", "alice") == 0
+
+    asyncio.run(_run())
@@ -0,0 +1,53 @@
+from __future__ import annotations
+
+from atomsAgent.services.error_budget import ErrorBudgetTracker
+
+
+class FakeClock:
+    def __init__(self) -> None:
+        self.now = 1_000.0
+
+    def __call__(self) -> float:
+        return self.now
+
+
+def test_error_budget_is_exhausted_over_the_sliding_window():
+    clock = FakeClock()
+    tracker = ErrorBudgetTracker(
+        window_seconds=600, threshold=0.05, min_events=20, buckets=60, clock=clock
+    )
+
+    for _ in range(18):
+        tracker.record("mcp.tool_call")
+    tracker.record("mcp.tool_call", error_code="MCP_TOOL_EXECUTION_ERROR")
+    tracker.record("mcp.tool_call", error_code="MCP_TIMEOUT")
+    budget = tracker.budget("mcp.tool_call")
+    assert (budget.events, budget.errors, budget.error_rate) == (20, 2, 0.1)
+    assert budget.errors_by_code == {"MCP_TOOL_EXECUTION_ERROR": 1, "MCP_TIMEOUT": 1}
+    assert round(budget.burn_rate, 6) == 2.0
+    assert budget.exhausted is True
+    assert tracker.exhausted() == ["mcp.tool_call"]
+
+    # Half the window later the failures still count...
+    clock.now += 300
+    for _ in range(20):
+        tracker.record("mcp.tool_call")
+    assert tracker.budget("mcp.tool_call").error_rate == 2 / 40
+    assert tracker.exhausted() == []
+
+    # ...and once they age out only the recent successes remain.
+    clock.now += 301
+    assert tracker.budget("mcp.tool_call").events == 20
+    assert tracker.budget("mcp.tool_call").errors == 0
+
+    clock.now += 600
+    assert tracker.summary() == {}
+
+
+def test_error_budget_needs_enough_events_before_it_trips():
+    tracker = ErrorBudgetTracker(min_events=20, clock=FakeClock())
+    for _ in range(5):
+        tracker.record("mcp.tool_call", error_code="MCP_TOOL_ERROR")
+    budget = tracker.budget("mcp.tool_call")
+    assert budget.error_rate == 1.0
+    assert budget.exhausted is False
@@ -0,0 +1,98 @@
+from __future__ import annotations
+
+import asyncio
+import logging
+
+from atomsAgent.utils.log_audit import (
+    AuditLogHandler,
+    install_audit_handler,
+    log_security_event,
+    security_extra,
+)
+from atomsAgent.utils.log_context import bind_log_context, install_log_context, reset_log_context
+from atomsAgent.utils.log_redaction import RedactionFilter
+
+
+def test_audit_bridge_records_only_security_tagged_logs():
+    recorded: list[dict] = []
+
+    async def record_audit(**entry) -> None:
+        recorded.append(entry)
+
+    async def _run() -> None:
+        install_log_context()
+        handler = AuditLogHandler(record_audit, asyncio.get_running_loop())
+        logger = logging.getLogger("atomsAgent.tests.audit_bridge")
+        logger.propagate = False
+        logger.addHandler(handler)
+        token = bind_log_context(request_id="req-9", org_id="org-9")
+        try:
+            logger.warning("plain warning")
+            logger.warning(
+                "Rejected token",
+                extra=security_extra("auth.failure", "scim", reason="mismatch"),
+            )
+            await asyncio.sleep(0)
+            await asyncio.sleep(0)
+        finally:
+            reset_log_context(token)
+            logger.removeHandler(handler)
+
+    asyncio.run(_run())
+
+    assert recorded == [
+        {
+            "action": "auth.failure",
+            "resource_type": "scim",
+            "resource_id": None,
+            "details": {
+                "reason": "mismatch",
+                "message": "Rejected token",
+                "logger": "atomsAgent.tests.audit_bridge",
+                "level": "WARNING",
+                "request_id": "req-9",
+                "organization_id": "org-9",
+            },
+            "success": False,
+        }
+    ]
+
+
+def test_security_events_are_audited_and_redacted_whatever_the_log_level():
+    recorded: list[dict] = []
+
+    async def record_audit(**entry) -> None:
+        recorded.append(entry)
+
+    async def _run() -> None:
+        handler = AuditLogHandler(record_audit, asyncio.get_running_loop())
+        handler.addFilter(RedactionFilter(mask_emails=False, preserve=("audit_resource_id",)))
+        install_audit_handler(handler)
+        logger = logging.getLogger("atomsAgent.tests.audit_levels")
+        logger.setLevel(logging.CRITICAL)
+        try:
+            log_security_event(
+                logger,
+                logging.INFO,
+                "Added platform admin %s with Bearer %s",
+                "ops@example.com",
+                "sk-live-123",
+                extra=security_extra(
+                    "platform_admin.add", "platform_admin", "ops@example.com", token="sk-live-123"
+                ),
+            )
+            await asyncio.sleep(0)
+            await asyncio.sleep(0)
+        finally:
+            install_audit_handler(None)
+            logger.setLevel(logging.NOTSET)
+
+    asyncio.run(_run())
+
+    assert len(recorded) == 1
+    assert recorded[0]["action"] == "platform_admin.add"
+    # Audit rows keep who and what was changed but never credentials.
+    assert recorded[0]["resource_id"] == "ops@example.com"
+    assert "ops@example.com" in recorded[0]["details"]["message"]
+    assert "sk-live-123" not in recorded[0]["details"]["message"]
+    assert recorded[0]["details"]["token"] == "***"
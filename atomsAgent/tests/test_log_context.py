@@ -0,0 +1,117 @@
+from __future__ import annotations
+
+import asyncio
+import json
+import logging
+from types import SimpleNamespace
+
+from conftest import CollectingHandler
+
+from atomsAgent.api.middleware import AccessLogMiddleware, LogContextMiddleware
+from atomsAgent.utils.log_context import bind_log_context, install_log_context
+from atomsAgent.utils.log_sinks import JSONFormatter
+
+
+def test_log_records_carry_request_context():
+    install_log_context()
+    logger = logging.getLogger("atomsAgent.tests.log_context")
+    logger.setLevel(logging.INFO)
+    handler = CollectingHandler()
+    logger.addHandler(handler)
+
+    async def app(scope, receive, send) -> None:
+        bind_log_context(session_id="session-1")
+        logger.info("handling request")
+        await send({"type": "http.response.start", "status": 200, "headers": []})
+        await send({"type": "http.response.body", "body": b""})
+
+    sent: list[dict] = []
+
+    async def send(message) -> None:
+        sent.append(message)
+
+    async def _run() -> None:
+        middleware = LogContextMiddleware(app)
+        scope = {
+            "type": "http",
+            "headers": [(b"x-request-id", b"req-123")],
+            "query_string": b"organization_id=org-1&user_id=user-1",
+        }
+        await middleware(scope, None, send)
+
+    try:
+        asyncio.run(_run())
+        logger.info("after request")
+    finally:
+        logger.removeHandler(handler)
+
+    during, after = handler.records
+    assert (during.request_id, during.org_id, during.user_id, during.session_id) == (
+        "req-123",
+        "org-1",
+        "user-1",
+        "session-1",
+    )
+    assert (after.request_id, after.org_id, after.session_id) == ("-", "-", "-")
+    assert (b"x-request-id", b"req-123") in sent[0]["headers"]
+
+
+def test_records_carry_active_trace_ids(monkeypatch):
+    install_log_context()
+    monkeypatch.setattr(
+        "atomsAgent.utils.log_context.current_trace_ids", lambda: ("a" * 32, "b" * 16)
+    )
+    record = logging.getLogger("atomsAgent.tests.tracing").makeRecord(
+        "atomsAgent.tests.tracing", logging.INFO, __file__, 1, "traced", (), None
+    )
+    payload = json.loads(JSONFormatter().format(record))
+    assert payload["trace_id"] == "a" * 32
+    assert payload["span_id"] == "b" * 16
+
+    monkeypatch.setattr("atomsAgent.utils.log_context.current_trace_ids", lambda: None)
+    record = logging.getLogger("atomsAgent.tests.tracing").makeRecord(
+        "atomsAgent.tests.tracing", logging.INFO, __file__, 1, "untraced", (), None
+    )
+    assert "trace_id" not in json.loads(JSONFormatter().format(record))
+
+
+def test_access_log_records_one_structured_line_per_request():
+    install_log_context()
+    access_logger = logging.getLogger("atomsAgent.access")
+    access_logger.setLevel(logging.INFO)
+    handler = CollectingHandler()
+    access_logger.addHandler(handler)
+
+    async def app(scope, receive, send) -> None:
+        scope["route"] = SimpleNamespace(path="/v1/sessions/{session_id}/heartbeat")
+        bind_log_context(user_id="user-1")
+        await send({"type": "http.response.start", "status": 404, "headers": []})
+        await send({"type": "http.response.body", "body": b'{"detail":"session not found"}'})
+
+    async def send(message) -> None:
+        return None
+
+    async def _run() -> None:
+        middleware = LogContextMiddleware(AccessLogMiddleware(app))
+        scope = {
+            "type": "http",
+            "method": "POST",
+            "path": "/v1/sessions/abc/heartbeat",
+            "headers": [(b"x-request-id", b"req-access")],
+            "query_string": b"",
+        }
+        await middleware(scope, None, send)
+
+    try:
+        asyncio.run(_run())
+    finally:
+        access_logger.removeHandler(handler)
+
+    (record,) = handler.records
+    assert record.http["path"] == "/v1/sessions/{session_id}/heartbeat"
+    assert record.http["status"] == 404
+    assert record.http["bytes"] == 30
+    assert (record.request_id, record.user_id) == ("req-access", "user-1")
+    payload = json.loads(JSONFormatter().format(record))
+    assert payload["http"]["method"] == "POST"
+    assert payload["message"].startswith("POST /v1/sessions/{session_id}/heartbeat 404")
@@ -0,0 +1,32 @@
+from __future__ import annotations
+
+import json
+import logging
+
+from atomsAgent.utils.log_context import install_log_context
+from atomsAgent.utils.log_redaction import DEFAULT_REDACT_KEYS, RedactionFilter
+from atomsAgent.utils.log_sinks import JSONFormatter
+
+
+def test_redaction_filter_masks_credentials_and_emails():
+    install_log_context()
+    redaction = RedactionFilter(DEFAULT_REDACT_KEYS)
+    record = logging.getLogger("atomsAgent.tests.redaction").makeRecord(
+        "atomsAgent.tests.redaction",
+        logging.INFO,
+        __file__,
+        1,
+        "calling %s with Authorization: Bearer abc.def-123",
+        ("alice@example.com",),
+        None,
+        extra={
+            "request": {"headers": {"Authorization": "Bearer abc"}, "auth_token": "t0k"},
+            "usage": {"prompt_tokens": 12},
+        },
+    )
+    assert redaction.filter(record)
+
+    payload = json.loads(JSONFormatter().format(record))
+    assert payload["message"] == "calling a***@example.com with Authorization: Bearer ***"
+    assert payload["request"] == {"headers": {"Authorization": "***"}, "auth_token": "***"}
+    assert payload["usage"] == {"prompt_tokens": 12}
@@ -0,0 +1,58 @@
+from __future__ import annotations
+
+import logging
+
+from conftest import CollectingHandler
+
+from atomsAgent.utils.log_sampling import SamplingFilter
+
+
+def test_sampling_filter_keeps_first_then_one_in_every():
+    sampler = SamplingFilter(first=2, every=3)
+    logger = logging.getLogger("atomsAgent.tests.sampling")
+    logger.propagate = False
+    sinks = [CollectingHandler(), CollectingHandler()]
+    for sink in sinks:
+        sink.addFilter(sampler)
+        logger.addHandler(sink)
+    try:
+        for index in range(8):
+            logger.error("stream chunk %d failed", index)
+        logger.error("breaker opened")
+    finally:
+        for sink in sinks:
+            logger.removeHandler(sink)
+
+    for sink in sinks:
+        assert [record.getMessage() for record in sink.records] == [
+            "stream chunk 0 failed",
+            "stream chunk 1 failed",
+            "stream chunk 4 failed",
+            "stream chunk 7 failed",
+            "breaker opened",
+        ]
+    assert sampler.suppressed == 4
+    kept = {record.getMessage(): record for record in sinks[0].records}
+    assert not hasattr(kept["stream chunk 1 failed"], "sampling_suppressed")
+    assert kept["stream chunk 4 failed"].sampling_suppressed == 2
+    assert kept["stream chunk 7 failed"].sampling_suppressed == 2
+
+
+def test_sampling_fingerprints_errors_by_exception_type():
+    sampler = SamplingFilter(first=1, every=100)
+
+    def record(exc: BaseException) -> logging.LogRecord:
+        return logging.getLogger("atomsAgent.tests.sampling").makeRecord(
+            "atomsAgent.tests.sampling",
+            logging.ERROR,
+            __file__,
+            1,
+            "Unhandled error in %s %s",
+            ("GET", "/v1/models"),
+            (type(exc), exc, None),
+        )
+
+    assert sampler.filter(record(TimeoutError("slow")))
+    assert sampler.filter(record(KeyError("bug")))
+    assert not sampler.filter(record(TimeoutError("slow again")))
+    assert sampler.suppressed == 1
@@ -0,0 +1,245 @@
+from __future__ import annotations
+
+import json
+import logging
+import sys
+import threading
+from types import SimpleNamespace
+
+import pytest
+from conftest import CollectingHandler
+
+from atomsAgent.utils import log_sinks
+from atomsAgent.utils.log_context import bind_log_context, install_log_context, reset_log_context
+from atomsAgent.utils.log_sinks import (
+    AsyncLogHandler,
+    BufferedSinkHandler,
+    JSONFormatter,
+    LokiHandler,
+    PanicError,
+    build_sink,
+    fatal,
+    install_crash_logging,
+    panic,
+    parse_log_levels,
+    register_shutdown_hook,
+)
+
+
+class FlakySink(BufferedSinkHandler):
+    def __init__(self, failures: int, **options) -> None:
+        self.failures = failures
+        self.batches: list[list[str]] = []
+        super().__init__(**options)
+
+    def send(self, batch: list[tuple[float, str]]) -> None:
+        if self.failures:
+            self.failures -= 1
+            raise ConnectionError("sink unavailable")
+        self.batches.append([line for _, line in batch])
+
+
+def test_buffered_sink_batches_json_and_retries():
+    install_log_context()
+    sink = FlakySink(
+        failures=1,
+        batch_size=2,
+        flush_interval_seconds=60,
+        max_retries=1,
+        retry_backoff_seconds=0,
+    )
+    sink.setFormatter(JSONFormatter())
+    logger = logging.getLogger("atomsAgent.tests.log_sinks")
+    logger.propagate = False
+    logger.setLevel(logging.INFO)
+    logger.addHandler(sink)
+    token = bind_log_context(org_id="org-1")
+    try:
+        logger.info("first")
+        logger.warning("second")
+        logger.info("third")
+        sink.flush()
+    finally:
+        reset_log_context(token)
+        logger.removeHandler(sink)
+        sink.close()
+
+    lines = [json.loads(line) for batch in sink.batches for line in batch]
+    assert [line["message"] for line in lines] == ["first", "second", "third"]
+    assert lines[1]["level"] == "WARNING"
+    assert lines[0]["org_id"] == "org-1"
+    assert "user_id" not in lines[0]
+    assert sink.dropped == 0
+
+
+def test_loki_sink_drops_its_own_http_client_logs(monkeypatch):
+    http_logger = logging.getLogger("httpx")
+
+    class FakeClient:
+        def __init__(self) -> None:
+            self.pushed: list[dict] = []
+
+        def post(self, url: str, *, json: dict) -> SimpleNamespace:
+            self.pushed.append(json)
+            # What httpx logs at INFO for every request.
+            http_logger.info('HTTP Request: POST %s "HTTP/1.1 204 No Content"', url)
+            return SimpleNamespace(raise_for_status=lambda: None)
+
+        def close(self) -> None:
+            return None
+
+    client = FakeClient()
+    monkeypatch.setattr(log_sinks.httpx, "Client", lambda **kwargs: client)
+    sink = LokiHandler("http://loki.example.com", flush_interval_seconds=60)
+    logger = logging.getLogger("atomsAgent.tests.loki")
+    logger.propagate = False
+    logger.setLevel(logging.INFO)
+    http_level = http_logger.level
+    http_logger.setLevel(logging.INFO)
+    for source in (logger, http_logger):
+        source.addHandler(sink)
+    try:
+        logger.info("shipped")
+        sink.flush()
+        sink.flush()
+    finally:
+        for source in (logger, http_logger):
+            source.removeHandler(sink)
+        http_logger.setLevel(http_level)
+        sink.close()
+
+    [push] = client.pushed
+    assert [line for _, line in push["streams"][0]["values"]] == ["shipped"]
+
+
+def test_file_sink_rotates_by_size(tmp_path):
+    path = tmp_path / "atoms.log"
+    sink = build_sink(
+        {"type": "file", "path": str(path), "max_bytes": 200, "backup_count": 2, "format": "json"}
+    )
+    logger = logging.getLogger("atomsAgent.tests.file_sink")
+    logger.propagate = False
+    logger.setLevel(logging.INFO)
+    logger.addHandler(sink)
+    try:
+        for index in range(10):
+            logger.info("message %d", index)
+    finally:
+        logger.removeHandler(sink)
+        sink.close()
+
+    assert json.loads(path.read_text().splitlines()[-1])["message"] == "message 9"
+    assert sorted(p.name for p in tmp_path.iterdir()) == ["atoms.log", "atoms.log.1", "atoms.log.2"]
+
+
+def test_async_handler_drops_oldest_when_queue_is_full():
+    first_taken = threading.Event()
+    release = threading.Event()
+
+    class SlowHandler(CollectingHandler):
+        def emit(self, record: logging.LogRecord) -> None:
+            first_taken.set()
+            release.wait(5)
+            super().emit(record)
+
+    sink = SlowHandler()
+    handler = AsyncLogHandler([sink], max_queue=2)
+    logger = logging.getLogger("atomsAgent.tests.async_logging")
+    logger.propagate = False
+    logger.addHandler(handler)
+    try:
+        logger.warning("record %d", 0)
+        assert first_taken.wait(5)
+        for index in range(1, 4):
+            logger.warning("record %d", index)
+        release.set()
+    finally:
+        logger.removeHandler(handler)
+        handler.close()
+
+    assert handler.dropped == 1
+    assert [record.getMessage() for record in sink.records] == [
+        "record 0",
+        "record 2",
+        "record 3",
+    ]
+
+
+def test_parse_log_levels_spec():
+    assert parse_log_levels(" atomsAgent.api=debug, httpx=warn,default=info ") == {
+        "atomsAgent.api": "DEBUG",
+        "httpx": "WARN",
+        "root": "INFO",
+    }
+    assert parse_log_levels("") == {}
+    for spec in ("atomsAgent.api", "api=loud", "=debug"):
+        with pytest.raises(ValueError):
+            parse_log_levels(spec)
+
+
+def test_uncaught_thread_exceptions_are_logged(monkeypatch):
+    original = threading.excepthook
+    uninstall = install_crash_logging()
+    monkeypatch.setattr(sys, "excepthook", sys.excepthook)
+    handler = CollectingHandler()
+    crash_logger = logging.getLogger("atomsAgent.crash")
+    crash_logger.addHandler(handler)
+
+    def explode() -> None:
+        raise RuntimeError("worker died")
+
+    try:
+        worker = threading.Thread(target=explode, name="doomed")
+        worker.start()
+        worker.join()
+    finally:
+        crash_logger.removeHandler(handler)
+        uninstall()
+
+    assert threading.excepthook is original
+
+    [record] = handler.records
+    assert record.levelno == logging.CRITICAL
+    assert record.getMessage() == "Uncaught exception in thread doomed"
+    assert record.exc_info[0] is RuntimeError
+
+
+def test_fatal_logs_a_stack_runs_shutdown_hooks_and_exits(monkeypatch):
+    exits: list[int] = []
+    calls: list[str] = []
+
+    def fake_exit(code: int) -> None:
+        calls.append("exit")
+        exits.append(code)
+
+    monkeypatch.setattr(log_sinks.os, "_exit", fake_exit)
+    uninstall = install_crash_logging(exit_code=3)
+    unregister = [
+        register_shutdown_hook(lambda: calls.append("first")),
+        register_shutdown_hook(lambda: calls.append("second")),
+    ]
+    handler = CollectingHandler()
+    crash_logger = logging.getLogger("atomsAgent.crash")
+    crash_logger.addHandler(handler)
+    try:
+        try:
+            raise ConnectionError("database unreachable")
+        except ConnectionError:
+            fatal("Cannot start: %s", "no database")
+        fatal("Explicit exit code", exit_code=70)
+        with pytest.raises(PanicError, match="bad state 7"):
+            panic("bad state %d", 7)
+    finally:
+        crash_logger.removeHandler(handler)
+        for callback in unregister:
+            callback()
+        uninstall()
+
+    assert exits == [3, 70]
+    assert calls == ["second", "first", "exit"] * 2
+    fatal_record, explicit, panicked = handler.records
+    assert fatal_record.getMessage() == "Cannot start: no database"
+    assert fatal_record.exc_info[0] is ConnectionError
+    assert fatal_record.stack_info and fatal_record.funcName.startswith("test_fatal")
+    assert not explicit.exc_info
+    assert panicked.levelno == logging.CRITICAL and panicked.stack_info
@@ -0,0 +1,268 @@
+from __future__ import annotations
+
+import asyncio
+from dataclasses import replace
+from uuid import UUID
+
+import pytest
+from conftest import make_mcp_record
+from fastapi import HTTPException
+
+from atomsAgent.api.routes.mcp import drain_mcp_server
+from atomsAgent.db.repositories import MCPConfigRecord
+from atomsAgent.schemas.mcp import MCPUpdateRequest
+from atomsAgent.services.mcp_connections import (
+    MCPConnectionDrainingError,
+    MCPConnectionManager,
+)
+from atomsAgent.services.mcp_registry import MCPRegistryService
+
+
+class FakeClient:
+    def __init__(self) -> None:
+        self.connected = False
+        self.healthy = True
+
+    async def __aenter__(self) -> FakeClient:
+        self.connected = True
+        return self
+
+    async def __aexit__(self, *exc) -> None:
+        self.connected = False
+
+    async def ping(self) -> bool:
+        if not self.healthy:
+            raise ConnectionError("server went away")
+        return True
+
+
+def _record(config_id: str) -> MCPConfigRecord:
+    return make_mcp_record(
+        id=config_id,
+        org_id=None,
+        name=f"mcp-{config_id}",
+        endpoint="https://mcp.example.com/mcp",
+        scope="platform",
+    )
+
+
+def test_reaper_disconnects_idle_and_unresponsive_connections():
+    async def _run() -> None:
+        clients: dict[str, FakeClient] = {}
+
+        def factory(record: MCPConfigRecord) -> FakeClient:
+            clients[record.id] = FakeClient()
+            return clients[record.id]
+
+        manager = MCPConnectionManager(
+            client_factory=factory, idle_timeout_seconds=60, max_failed_pings=2
+        )
+        for config_id in ("idle", "dead", "healthy"):
+            await manager.release(await manager.acquire(_record(config_id)))
+        idle = manager.connections()[0]
+        assert all(client.connected for client in clients.values())
+
+        idle.last_activity -= 120
+        clients["dead"].healthy = False
+
+        assert await manager.reap() == ["idle"]
+        assert not clients["idle"].connected
+        assert clients["dead"].connected  # one failed ping is tolerated
+
+        assert await manager.reap() == ["dead"]
+        assert not clients["dead"].connected
+        assert [c.config_id for c in manager.connections()] == ["healthy"]
+
+        await manager.shutdown()
+        assert not clients["healthy"].connected
+        assert manager.connections() == []
+
+    asyncio.run(_run())
+
+
+def test_drain_waits_for_in_flight_calls_then_disconnects():
+    async def _run() -> None:
+        client = FakeClient()
+        manager = MCPConnectionManager(client_factory=lambda record: client)
+        record = _record("rotating")
+        release = asyncio.Event()
+
+        async def in_flight_call() -> None:
+            async with manager.session(record):
+                await release.wait()
+
+        call = asyncio.create_task(in_flight_call())
+        await asyncio.sleep(0)
+        drain = asyncio.create_task(manager.drain(record.id, timeout_seconds=5))
+        await asyncio.sleep(0)
+
+        with pytest.raises(MCPConnectionDrainingError):
+            await manager.acquire(record)
+        assert client.connected
+
+        release.set()
+        assert await drain is True
+        await call
+        assert not client.connected
+
+        # Once drained, the configuration accepts calls again on a fresh connection.
+        async with manager.session(record):
+            assert client.connected
+
+    asyncio.run(_run())
+
+
+def test_drain_times_out_while_calls_are_running():
+    async def _run() -> None:
+        client = FakeClient()
+        manager = MCPConnectionManager(client_factory=lambda record: client)
+        record = _record("busy")
+        async with manager.session(record):
+            with pytest.raises(asyncio.TimeoutError):
+                await manager.drain(record.id, timeout_seconds=0.01)
+            assert client.connected
+        assert await manager.drain(record.id) is True
+
+    asyncio.run(_run())
+
+
+def test_concurrent_sessions_are_spread_across_the_pool():
+    async def _run() -> None:
+        clients: list[FakeClient] = []
+
+        def factory(record: MCPConfigRecord) -> FakeClient:
+            clients.append(FakeClient())
+            return clients[-1]
+
+        manager = MCPConnectionManager(client_factory=factory, pool_size=2)
+        record = _record("shared")
+
+        async with manager.session(record) as first:
+            async with manager.session(record) as second:
+                async with manager.session(record) as third:
+                    assert first is not second
+                    assert third in (first, second)
+        assert len(manager.connections()) == 2
+
+        # Sequential calls reuse an idle pooled client instead of connecting again.
+        async with manager.session(record):
+            pass
+        assert len(clients) == 2
+
+        stale, fresh = manager.connections()
+        stale.last_activity -= 600
+        assert await manager.reap() == ["shared"]
+        assert manager.connections() == [fresh]
+        assert [client.connected for client in clients] == [False, True]
+
+        assert await manager.drain(record.id) is True
+        assert manager.connections() == []
+
+    asyncio.run(_run())
+
+
+def test_reaper_keeps_connections_reserved_during_a_slow_ping():
+    async def _run() -> None:
+        client = FakeClient()
+        pinging = asyncio.Event()
+        answer = asyncio.Event()
+
+        async def slow_failing_ping() -> bool:
+            pinging.set()
+            await answer.wait()
+            raise ConnectionError("timed out")
+
+        client.ping = slow_failing_ping
+        manager = MCPConnectionManager(client_factory=lambda _: client, max_failed_pings=1)
+        record = _record("busy")
+        async with manager.session(record):
+            pass
+
+        reaping = asyncio.create_task(manager.reap())
+        await pinging.wait()
+        async with manager.session(record) as session_client:
+            answer.set()
+            assert await reaping == []
+            assert session_client.connected
+        assert len(manager.connections()) == 1
+
+    asyncio.run(_run())
+
+
+def test_slow_connect_does_not_block_other_configurations():
+    async def _run() -> None:
+        connecting = asyncio.Event()
+        finish = asyncio.Event()
+
+        class SlowClient(FakeClient):
+            async def __aenter__(self) -> SlowClient:
+                connecting.set()
+                await finish.wait()
+                return await super().__aenter__()
+
+        def factory(record: MCPConfigRecord) -> FakeClient:
+            return SlowClient() if record.id == "slow" else FakeClient()
+
+        manager = MCPConnectionManager(client_factory=factory)
+        slow = asyncio.create_task(manager.acquire(_record("slow")))
+        await connecting.wait()
+
+        fast = await asyncio.wait_for(manager.acquire(_record("fast")), timeout=1)
+        await manager.release(fast)
+        assert await asyncio.wait_for(manager.disconnect("fast"), timeout=1)
+
+        finish.set()
+        await manager.release(await slow)
+        assert [item.config_id for item in manager.connections()] == ["slow"]
+
+    asyncio.run(_run())
+
+
+def test_drain_route_requires_a_platform_admin():
+    async def _run() -> None:
+        manager = MCPConnectionManager(client_factory=lambda record: FakeClient())
+        record = _record("00000000-0000-0000-0000-000000000001")
+        await manager.release(await manager.acquire(record))
+
+        with pytest.raises(HTTPException) as exc_info:
+            await drain_mcp_server(None, record.id, is_platform_admin=False, connections=manager)
+        assert exc_info.value.status_code == 403
+        assert [item.config_id for item in manager.connections()] == [record.id]
+
+        response = await drain_mcp_server(
+            None, record.id, is_platform_admin=True, connections=manager
+        )
+        assert response.disconnected is True
+        assert manager.connections() == []
+
+    asyncio.run(_run())
+
+
+def test_registry_update_closes_pooled_connections():
+    async def _run() -> None:
+        clients: list[FakeClient] = []
+
+        def factory(record: MCPConfigRecord) -> FakeClient:
+            clients.append(FakeClient())
+            return clients[-1]
+
+        record = make_mcp_record(endpoint="https://mcp.example.com/mcp")
+
+        class _Repository:
+            async def get_config(self, config_id):
+                return record
+
+            async def update_config(self, config_id, payload):
+                return replace(record, endpoint=payload["endpoint"])
+
+        manager = MCPConnectionManager(client_factory=factory)
+        await manager.release(await manager.acquire(record))
+        service = MCPRegistryService(_Repository(), connections=manager)
+
+        await service.update(
+            UUID(record.id), MCPUpdateRequest(endpoint="https://mcp.example.org/mcp")
+        )
+        assert manager.connections() == []
+        assert clients[0].connected is False
+
+    asyncio.run(_run())
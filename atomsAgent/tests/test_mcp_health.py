@@ -0,0 +1,107 @@
+from __future__ import annotations
+
+import asyncio
+from uuid import UUID
+
+from conftest import make_mcp_record
+
+from atomsAgent.api.routes.mcp import get_mcp_health
+from atomsAgent.db.repositories import MCPConfigRecord
+from atomsAgent.services.mcp_breakers import MCPCircuitBreakers
+from atomsAgent.services.mcp_health import MCPHealthMonitor
+
+
+class FakeClient:
+    def __init__(self, healthy: bool) -> None:
+        self.healthy = healthy
+
+    async def __aenter__(self) -> FakeClient:
+        if not self.healthy:
+            raise ConnectionError("connection refused")
+        return self
+
+    async def __aexit__(self, *exc) -> None:
+        return None
+
+    async def ping(self) -> bool:
+        return True
+
+
+def _record(config_id: str, name: str) -> MCPConfigRecord:
+    return make_mcp_record(
+        id=config_id,
+        org_id=None,
+        name=name,
+        endpoint=f"https://{name}.example.com/mcp",
+        scope="platform",
+    )
+
+
+class FakeRepository:
+    def __init__(self, records: list[MCPConfigRecord]) -> None:
+        self.records = records
+        self.health: dict[str, dict] = {}
+
+    async def list_enabled_configs(self) -> list[MCPConfigRecord]:
+        return self.records
+
+    async def get_health(self, config_id: str) -> dict | None:
+        return self.health.get(config_id)
+
+    async def save_health(self, row: dict) -> None:
+        self.health[row["mcp_id"]] = dict(row)
+
+    async def delete_health(self, config_id: str) -> None:
+        self.health.pop(config_id, None)
+
+
+GOOD_ID = "00000000-0000-0000-0000-000000000031"
+BAD_ID = "00000000-0000-0000-0000-000000000032"
+
+
+def test_monitor_marks_servers_unhealthy_after_repeated_failures():
+    async def _run() -> None:
+        repository = FakeRepository([_record(GOOD_ID, "docs"), _record(BAD_ID, "flaky")])
+        monitor = MCPHealthMonitor(
+            repository,
+            client_factory=lambda record: FakeClient(healthy=record.id == GOOD_ID),
+            failure_threshold=2,
+        )
+
+        await monitor.check_all()
+        assert monitor.get(GOOD_ID).status == "healthy"
+        assert monitor.get(GOOD_ID).latency_ms is not None
+        assert monitor.get(BAD_ID).status == "unknown"
+        assert monitor.summary() == {"healthy": 1, "unhealthy": 0, "unknown": 1}
+
+        await monitor.check_all()
+        bad = monitor.get(BAD_ID)
+        assert (bad.status, bad.consecutive_failures) == ("unhealthy", 2)
+        assert bad.last_error == "connection refused"
+        assert repository.health[BAD_ID]["status"] == "unhealthy"
+
+        # Another replica (or a restarted process) sees the persisted state
+        # and keeps counting failures from it.
+        replica = MCPHealthMonitor(
+            repository,
+            client_factory=lambda record: FakeClient(healthy=False),
+            failure_threshold=2,
+        )
+        persisted = await replica.fetch(BAD_ID)
+        assert (persisted.status, persisted.consecutive_failures) == ("unhealthy", 2)
+        assert abs(persisted.last_error_at - bad.last_error_at) < 1e-3
+        assert (await replica.check(_record(BAD_ID, "flaky"))).consecutive_failures == 3
+
+        breakers = MCPCircuitBreakers()
+        response = await get_mcp_health(UUID(BAD_ID), monitor=monitor, breakers=breakers)
+        assert response.status == "unhealthy"
+        assert response.last_error_at is not None
+
+        repository.records = [_record(GOOD_ID, "docs")]
+        await monitor.check_all()
+        assert monitor.get(BAD_ID) is None
+        assert BAD_ID not in repository.health
+        unknown = await get_mcp_health(UUID(BAD_ID), monitor=monitor, breakers=breakers)
+        assert unknown.status == "unknown"
+
+    asyncio.run(_run())
@@ -61,3 +61,123 @@ def test_compose_servers_injects_oauth_header(monkeypatch):
     assert "user_drive" in servers
     drive_config = servers["user_drive"]
     assert drive_config["headers"]["Authorization"] == "Bearer ACCESS"
+
+
+def test_compose_servers_attaches_org_defaults_respecting_opt_out(monkeypatch):
+    user_uuid = str(UUID(int=6))
+    org_uuid = str(UUID(int=7))
+
+    async def _empty(_id: str):  # pragma: no cover - simple stub
+        return []
+
+    async def _fake_defaults(org_id: str):
+        return [
+            {
+                "id": "cfg-1",
+                "name": "search",
+                "type": "http",
+                "endpoint": "https://search.example.com/mcp",
+                "auth_type": "bearer",
+                "auth_token": "TOKEN",
+            },
+            {
+                "id": "cfg-2",
+                "name": "wiki",
+                "type": "http",
+                "endpoint": "https://wiki.example.com/mcp",
+                "auth_type": "none",
+            },
+        ]
+
+    async def _fake_opt_outs(user_id: str):
+        return {"cfg-2"}
+
+    monkeypatch.setattr("atomsAgent.mcp.database.get_user_mcp_servers", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_org_mcp_servers", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_project_mcp_servers", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_org_default_mcp_configurations", _fake_defaults)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_default_mcp_opt_outs", _fake_opt_outs)
+
+    from atomsAgent.mcp.integration import compose_mcp_servers
+
+    servers = asyncio.run(compose_mcp_servers(user_id=user_uuid, org_id=org_uuid))
+
+    assert servers["org_search"]["headers"]["Authorization"] == "Bearer TOKEN"
+    assert "org_wiki" not in servers
+
+
+def test_compose_servers_attaches_platform_servers_unless_org_opted_out(monkeypatch):
+    org_uuid = str(UUID(int=8))
+
+    async def _empty(_id: str):  # pragma: no cover - simple stub
+        return []
+
+    async def _fake_platform():
+        return [
+            {
+                "id": "cfg-docs",
+                "name": "docs",
+                "type": "http",
+                "endpoint": "https://docs.example.com/mcp",
+                "auth_type": "none",
+            },
+            {
+                "id": "cfg-status",
+                "name": "status",
+                "type": "http",
+                "endpoint": "https://status.example.com/mcp",
+                "auth_type": "none",
+            },
+        ]
+
+    async def _fake_org_opt_outs(org_id: str):
+        return {"cfg-status"} if org_id == org_uuid else set()
+
+    monkeypatch.setattr("atomsAgent.mcp.database.get_org_mcp_servers", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_org_default_mcp_configurations", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_platform_mcp_configurations", _fake_platform)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_platform_mcp_opt_outs", _fake_org_opt_outs)
+
+    from atomsAgent.mcp.integration import compose_mcp_servers
+
+    servers = asyncio.run(compose_mcp_servers(org_id=org_uuid))
+    assert servers["platform_docs"]["url"] == "https://docs.example.com/mcp"
+    assert "platform_status" not in servers
+
+    everyone = asyncio.run(compose_mcp_servers(org_id=str(UUID(int=9))))
+    assert {"platform_docs", "platform_status"} <= set(everyone)
+
+
+def test_org_policy_applies_to_composed_platform_servers(monkeypatch):
+    from atomsAgent.schemas.mcp import MCPToolPolicy
+    from atomsAgent.services.mcp_policy import restrict_chat_servers
+
+    async def _empty(_id: str):  # pragma: no cover - simple stub
+        return []
+
+    async def _fake_platform():
+        return [
+            {
+                "id": "cfg-github",
+                "name": "github",
+                "type": "http",
+                "endpoint": "https://github.example.com/mcp",
+                "auth_type": "none",
+            }
+        ]
+
+    async def _no_opt_outs(_org_id: str):
+        return set()
+
+    monkeypatch.setattr("atomsAgent.mcp.database.get_org_mcp_servers", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_org_default_mcp_configurations", _empty)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_platform_mcp_configurations", _fake_platform)
+    monkeypatch.setattr("atomsAgent.mcp.database.get_platform_mcp_opt_outs", _no_opt_outs)
+
+    from atomsAgent.mcp.integration import compose_mcp_servers
+
+    servers = asyncio.run(compose_mcp_servers(org_id=str(UUID(int=8))))
+    assert "platform_github" in servers
+    for deny in ("github", "github/*"):
+        allowed, _ = restrict_chat_servers(MCPToolPolicy(deny=[deny]), servers)
+        assert "platform_github" not in allowed
@@ -0,0 +1,72 @@
+from __future__ import annotations
+
+import os
+import sys
+
+from atomsAgent.mcp.sandbox import (
+    EXIT_RESTART_LIMIT,
+    EXIT_TIMEOUT,
+    StdioSandboxPolicy,
+    main,
+    sandbox_stdio_config,
+)
+
+
+def test_sandbox_config_wraps_command_in_launcher():
+    policy = StdioSandboxPolicy(workdir_root="/srv/mcp", env_allowlist=["PATH"], memory_mb=None)
+    config = sandbox_stdio_config(
+        {"command": "npx", "args": ["-y", "server-github"], "env": {"GITHUB_TOKEN": "x"}},
+        policy,
+        server_id="org/github",
+    )
+    assert config["command"] == sys.executable
+    args = config["args"]
+    assert args[:4] == ["-m", "atomsAgent.mcp.sandbox", "--workdir", "/srv/mcp/org_github"]
+    assert "--memory-mb" not in args
+    assert args[args.index("--cpu-seconds") + 1] == "300"
+    assert [args[i + 1] for i, arg in enumerate(args) if arg == "--allow-env"] == [
+        "PATH",
+        "GITHUB_TOKEN",
+    ]
+    assert args[args.index("--") + 1 :] == ["npx", "-y", "server-github"]
+    assert config["env"] == {"GITHUB_TOKEN": "x"}
+
+
+def test_sandbox_workdir_is_scoped_to_user_and_session():
+    policy = StdioSandboxPolicy(workdir_root="/srv/mcp")
+    alice = sandbox_stdio_config(
+        {"command": "npx"}, policy, server_id="github", scope=["alice", "session/1"]
+    )
+    bob = sandbox_stdio_config({"command": "npx"}, policy, server_id="github", scope=["bob"])
+
+    assert alice["args"][3] == "/srv/mcp/alice/session_1/github"
+    assert bob["args"][3] == "/srv/mcp/bob/github"
+    # Long-lived servers are neither memory-capped nor killed by default.
+    assert "--memory-mb" not in alice["args"]
+    assert "--timeout-seconds" not in alice["args"]
+
+
+def test_launcher_filters_environment_and_limits_restarts(tmp_path, monkeypatch):
+    workdir = str(tmp_path / "server")
+    output = tmp_path / "seen.txt"
+    monkeypatch.setenv("ALLOWED", "yes")
+    monkeypatch.setenv("LEAKED", "no")
+    script = (
+        "import os, sys; "
+        f"open({str(output)!r}, 'w').write("
+        "os.getcwd() + '|' + os.environ.get('ALLOWED', '') + '|' + os.environ.get('LEAKED', '')); "
+        "sys.exit(3)"
+    )
+    argv = ["--workdir", workdir, "--max-restarts", "2", "--allow-env", "ALLOWED", "--"]
+    argv += [sys.executable, "-c", script]
+
+    assert main(argv) == 3
+    assert output.read_text() == f"{os.path.realpath(workdir)}|yes|"
+    assert main(argv) == 3
+    assert main(argv) == EXIT_RESTART_LIMIT
+
+    slow = ["--workdir", str(tmp_path / "slow"), "--timeout-seconds", "0.2", "--max-restarts"]
+    slow += ["1", "--", sys.executable, "-c", "import time; time.sleep(30)"]
+    assert main(slow) == EXIT_TIMEOUT
+    # Hitting the wall-clock limit does not count as an abnormal exit.
+    assert main(slow) == EXIT_TIMEOUT
@@ -0,0 +1,653 @@
+from __future__ import annotations
+
+import asyncio
+from datetime import datetime, timezone
+from types import SimpleNamespace
+from uuid import UUID
+
+import pytest
+from conftest import make_mcp_record
+from fastapi import HTTPException
+
+from atomsAgent.api.routes.mcp import call_mcp_tool, get_mcp_usage, update_mcp_tool_policy
+from atomsAgent.db.repositories import MCPConfigRecord, MCPUsageRecord
+from atomsAgent.schemas.mcp import MCPToolCallRequest, MCPToolPolicy
+from atomsAgent.services.error_budget import ErrorBudgetTracker
+from atomsAgent.services.mcp_breakers import MCPCircuitBreakers
+from atomsAgent.services.mcp_connections import MCPConnectionManager
+from atomsAgent.services.mcp_policy import (
+    MCPToolPolicyService,
+    chat_tool_hook,
+    is_tool_allowed,
+    restrict_chat_servers,
+)
+from atomsAgent.services.mcp_server_events import MCPSamplingError, MCPServerEvents
+from atomsAgent.services.mcp_tools import MCPToolError, MCPToolService, _schema_violations
+from atomsAgent.services.mcp_usage import MCPUsageMeter
+from atomsAgent.utils.caching import SizedLRUCache
+
+ORG_ID = UUID("00000000-0000-0000-0000-000000000004")
+MCP_ID = UUID("00000000-0000-0000-0000-000000000021")
+
+
+class FakeBlock:
+    def __init__(self, text: str) -> None:
+        self.text = text
+
+    def model_dump(self, **kwargs) -> dict:
+        return {"type": "text", "text": self.text}
+
+
+class FakeToolClient:
+    def __init__(self) -> None:
+        self.calls: list[tuple[str, dict]] = []
+        self.list_calls = 0
+
+    async def __aenter__(self) -> FakeToolClient:
+        return self
+
+    async def __aexit__(self, *exc) -> None:
+        return None
+
+    async def list_tools(self) -> list[SimpleNamespace]:
+        self.list_calls += 1
+        return [
+            SimpleNamespace(
+                name="search",
+                description="Search documents",
+                inputSchema={
+                    "type": "object",
+                    "properties": {"query": {"type": "string"}},
+                    "required": ["query"],
+                },
+            )
+        ]
+
+    async def call_tool_mcp(
+        self, name: str, arguments: dict, progress_handler=None
+    ) -> SimpleNamespace:
+        self.calls.append((name, arguments))
+        if progress_handler is not None:
+            await progress_handler(0.5, 1.0, "indexing")
+        return SimpleNamespace(
+            isError=False,
+            content=[FakeBlock(f"results for {arguments['query']}")],
+            structuredContent={"hits": 1},
+        )
+
+
+    async def list_resources(self) -> list[SimpleNamespace]:
+        return [
+            SimpleNamespace(
+                uri="docs://runbooks/rotation",
+                name="rotation",
+                description=None,
+                mimeType="text/markdown",
+            )
+        ]
+
+    async def read_resource(self, uri: str) -> list[FakeBlock]:
+        return [FakeBlock(f"contents of {uri}")]
+
+    async def list_prompts(self) -> list[SimpleNamespace]:
+        argument = SimpleNamespace(name="topic", description="What to summarise", required=True)
+        return [SimpleNamespace(name="summarise", description=None, arguments=[argument])]
+
+    async def get_prompt(self, name: str, arguments: dict) -> SimpleNamespace:
+        return SimpleNamespace(
+            description="Summary prompt", messages=[FakeBlock(f"Summarise {arguments['topic']}")]
+        )
+
+
+class FakeRepository:
+    def __init__(self, org_id: str | None, policy: dict | None = None) -> None:
+        self.org_id = org_id
+        self.policy = policy or {}
+        self.platform_opt_outs: list[str] = []
+
+    async def get_tool_policy(self, organization_id: UUID) -> dict:
+        return self.policy
+
+    async def set_tool_policy(self, organization_id: UUID, policy: dict | None) -> None:
+        self.policy = policy or {}
+
+    async def get_platform_opt_outs(self, organization_id: UUID) -> list[str]:
+        return self.platform_opt_outs
+
+    async def get_config(self, config_id: UUID) -> MCPConfigRecord:
+        if config_id != MCP_ID:
+            raise ValueError(f"MCP configuration not found: {config_id}")
+        return make_mcp_record(
+            id=str(MCP_ID),
+            org_id=self.org_id,
+            name="docs",
+            endpoint="https://docs.example.com/mcp",
+            scope="organization" if self.org_id else "platform",
+        )
+
+
+def test_call_tool_returns_structured_result():
+    async def _run() -> None:
+        client = FakeToolClient()
+        service = MCPToolService(
+            FakeRepository(str(ORG_ID)), MCPConnectionManager(client_factory=lambda _: client)
+        )
+
+        tools = await service.list_tools(MCP_ID, organization_id=ORG_ID)
+        assert [tool.name for tool in tools] == ["search"]
+
+        result = await service.call_tool(
+            MCP_ID, "search", {"query": "rotation"}, organization_id=ORG_ID
+        )
+        assert client.calls == [("search", {"query": "rotation"})]
+        assert result.content == [{"type": "text", "text": "results for rotation"}]
+        assert result.structured_content == {"hits": 1}
+        assert result.is_error is False
+
+    asyncio.run(_run())
+
+
+def test_call_tool_streams_progress_and_chunks_over_sse():
+    async def _run() -> None:
+        service = MCPToolService(
+            FakeRepository(None),
+            MCPConnectionManager(client_factory=lambda _: FakeToolClient()),
+        )
+        response = await call_mcp_tool(
+            MCPToolCallRequest(arguments={"query": "rotation"}, stream=True),
+            MCP_ID,
+            "search",
+            organization_id=ORG_ID,
+            tools=service,
+        )
+        assert response.media_type == "text/event-stream"
+        body = "".join([chunk async for chunk in response.body_iterator])
+        events = [
+            (block.split("\n")[0].removeprefix("event: "), block.split("\n")[1])
+            for block in body.strip().split("\n\n")
+        ]
+        assert [name for name, _ in events] == ["progress", "chunk", "done"]
+        assert '"message": "indexing"' in events[0][1]
+        assert '"text": "results for rotation"' in events[1][1]
+        assert '"structured_content": {"hits": 1}' in events[2][1]
+
+        # Argument problems are still reported as HTTP errors before streaming.
+        with pytest.raises(HTTPException) as invalid:
+            await call_mcp_tool(
+                MCPToolCallRequest(stream=True), MCP_ID, "search", ORG_ID, tools=service
+            )
+        assert invalid.value.status_code == 422
+
+    asyncio.run(_run())
+
+
+def test_call_tool_rejects_other_orgs_unknown_tools_and_missing_arguments():
+    async def _run() -> None:
+        client = FakeToolClient()
+        connections = MCPConnectionManager(client_factory=lambda _: client)
+
+        other_org = "00000000-0000-0000-0000-000000000099"
+        foreign = MCPToolService(FakeRepository(other_org), connections)
+        with pytest.raises(MCPToolError) as forbidden:
+            await foreign.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+        assert forbidden.value.status == 403
+
+        service = MCPToolService(FakeRepository(None), connections)
+        with pytest.raises(MCPToolError) as missing_mcp:
+            await service.call_tool(ORG_ID, "search", {}, organization_id=ORG_ID)
+        assert missing_mcp.value.code == "MCP_NOT_FOUND"
+
+        with pytest.raises(MCPToolError) as unknown:
+            await service.call_tool(MCP_ID, "delete_all", {}, organization_id=ORG_ID)
+        assert unknown.value.status == 404
+
+        with pytest.raises(MCPToolError) as invalid:
+            await service.call_tool(MCP_ID, "search", {}, organization_id=ORG_ID)
+        assert (invalid.value.status, invalid.value.code) == (422, "MCP_INVALID_ARGUMENTS")
+        assert invalid.value.violations == [{"field": "query", "message": "is required"}]
+
+        opted_out = FakeRepository(None)
+        opted_out.platform_opt_outs = [str(MCP_ID)]
+        with pytest.raises(MCPToolError) as declined:
+            await MCPToolService(opted_out, connections).call_tool(
+                MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID
+            )
+        assert declined.value.code == "MCP_OPTED_OUT"
+        assert client.calls == []
+
+    asyncio.run(_run())
+
+
+def test_schema_violations_list_every_offending_field():
+    schema = {
+        "type": "object",
+        "properties": {
+            "query": {"type": "string", "minLength": 1},
+            "limit": {"type": "integer", "minimum": 1, "maximum": 50},
+            "filters": {
+                "type": "object",
+                "properties": {"tags": {"type": "array", "items": {"type": "string"}}},
+                "additionalProperties": False,
+            },
+            "order": {"enum": ["asc", "desc"]},
+        },
+        "required": ["query"],
+    }
+    assert _schema_violations(schema, {"query": "x", "limit": 10.0, "order": "asc"}) == []
+    assert _schema_violations(
+        schema,
+        {"limit": True, "filters": {"tags": ["a", 2], "owner": "me"}, "order": "up"},
+    ) == [
+        {"field": "query", "message": "is required"},
+        {"field": "limit", "message": "expected integer, got boolean"},
+        {"field": "filters.tags[1]", "message": "expected string, got integer"},
+        {"field": "filters.owner", "message": "is not an accepted argument"},
+        {"field": "order", "message": "must be one of ['asc', 'desc']"},
+    ]
+    assert _schema_violations(schema, {"query": "", "limit": 99}) == [
+        {"field": "query", "message": "must be at least 1 characters"},
+        {"field": "limit", "message": "must be <= 50"},
+    ]
+
+
+def test_resources_and_prompts():
+    async def _run() -> None:
+        service = MCPToolService(
+            FakeRepository(None), MCPConnectionManager(client_factory=lambda _: FakeToolClient())
+        )
+
+        [resource] = await service.list_resources(MCP_ID, organization_id=ORG_ID)
+        assert (resource.uri, resource.mime_type) == ("docs://runbooks/rotation", "text/markdown")
+        read = await service.read_resource(MCP_ID, resource.uri, organization_id=ORG_ID)
+        assert read.contents == [{"type": "text", "text": "contents of docs://runbooks/rotation"}]
+
+        [prompt] = await service.list_prompts(MCP_ID, organization_id=ORG_ID)
+        assert [(arg.name, arg.required) for arg in prompt.arguments] == [("topic", True)]
+        rendered = await service.render_prompt(
+            MCP_ID, "summarise", {"topic": "outages"}, organization_id=ORG_ID
+        )
+        assert rendered.description == "Summary prompt"
+        assert rendered.messages == [{"type": "text", "text": "Summarise outages"}]
+
+    asyncio.run(_run())
+
+
+def test_tool_list_is_cached_until_refresh_or_disconnect():
+    async def _run() -> None:
+        client = FakeToolClient()
+        cache = SizedLRUCache(65_536)
+        connections = MCPConnectionManager(
+            client_factory=lambda _: client, on_disconnect=cache.delete
+        )
+        service = MCPToolService(FakeRepository(None), connections, tool_cache=cache)
+
+        await service.list_tools(MCP_ID, organization_id=ORG_ID)
+        await service.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+        assert client.list_calls == 1
+
+        await service.list_tools(MCP_ID, organization_id=ORG_ID, refresh=True)
+        assert client.list_calls == 2
+
+        assert await connections.disconnect(str(MCP_ID))
+        await service.list_tools(MCP_ID, organization_id=ORG_ID)
+        assert client.list_calls == 3
+        assert cache.stats().hits == 1
+
+    asyncio.run(_run())
+
+
+def test_tool_policy_matching():
+    policy = MCPToolPolicy(allow=["docs", "github/list_*"], deny=["docs/delete_*"])
+    assert is_tool_allowed(policy, "docs", "search")
+    assert not is_tool_allowed(policy, "docs", "delete_page")
+    assert is_tool_allowed(policy, "github", "list_issues")
+    assert not is_tool_allowed(policy, "github", "create_issue")
+    assert not is_tool_allowed(policy, "filesystem", "read")
+    assert is_tool_allowed(MCPToolPolicy(), "filesystem", "write")
+
+    servers, disallowed = restrict_chat_servers(
+        MCPToolPolicy(deny=["filesystem", "docs/delete_page", "docs/purge_*"]),
+        {"org_docs": {}, "user_filesystem": {}, "atoms": {}},
+    )
+    assert sorted(servers) == ["atoms", "org_docs"]
+    assert disallowed == ["mcp__org_docs__delete_page"]
+
+
+def test_tool_policy_writes_require_a_platform_admin():
+    class UnusedPolicies:
+        async def set_policy(self, organization_id, policy):
+            raise AssertionError("policy must not be written")
+
+    with pytest.raises(HTTPException) as excinfo:
+        asyncio.run(
+            update_mcp_tool_policy(
+                MCPToolPolicy(deny=[]),
+                organization_id=ORG_ID,
+                is_platform_admin=False,
+                policies=UnusedPolicies(),
+            )
+        )
+    assert excinfo.value.status_code == 403
+
+
+def test_chat_tool_hook_enforces_tool_globs_and_allowlists():
+    async def decision(policy: MCPToolPolicy, tool_name: str) -> str | None:
+        policies = MCPToolPolicyService(FakeRepository(None, policy=policy.model_dump()))
+        result = await chat_tool_hook(policies, ORG_ID)({"tool_name": tool_name}, None, None)
+        return result.get("hookSpecificOutput", {}).get("permissionDecision")
+
+    async def _run() -> None:
+        deny_writes = MCPToolPolicy(deny=["fs/write_*"])
+        assert await decision(deny_writes, "mcp__user_fs__write_file") == "deny"
+        assert await decision(deny_writes, "mcp__user_fs__read_file") is None
+        # Allowing one github tool attaches the server but not its other tools.
+        read_only = MCPToolPolicy(allow=["github/read_*"])
+        servers, _ = restrict_chat_servers(read_only, {"org_github": {}})
+        assert list(servers) == ["org_github"]
+        assert await decision(read_only, "mcp__org_github__read_issue") is None
+        assert await decision(read_only, "mcp__org_github__delete_repo") == "deny"
+        assert await decision(read_only, "Bash") is None
+
+    asyncio.run(_run())
+
+
+def test_chat_tool_hook_reads_the_current_policy():
+    async def _run() -> None:
+        repository = FakeRepository(None)
+        cache = SizedLRUCache(1 << 16)
+        policies = MCPToolPolicyService(repository, cache=cache)
+        hook = chat_tool_hook(policies, ORG_ID)
+        call = {"tool_name": "mcp__org_docs__delete_page"}
+        assert await hook(call, None, None) == {}
+
+        # A policy set through the service applies to a hook built before it.
+        await policies.set_policy(ORG_ID, MCPToolPolicy(deny=["docs/delete_*"]))
+        denied = await hook(call, None, None)
+        assert denied["hookSpecificOutput"]["permissionDecision"] == "deny"
+
+        # Changes made elsewhere show up once the cached policy expires.
+        repository.policy = {}
+        assert (await hook(call, None, None))["hookSpecificOutput"]
+        cache.clear()
+        assert await hook(call, None, None) == {}
+
+    asyncio.run(_run())
+
+
+def test_call_tool_enforces_org_policy():
+    async def _run() -> None:
+        client = FakeToolClient()
+        repository = FakeRepository(None, policy={"deny": ["docs/search"]})
+        service = MCPToolService(
+            repository,
+            MCPConnectionManager(client_factory=lambda _: client),
+            policy_service=MCPToolPolicyService(repository),
+        )
+        with pytest.raises(MCPToolError) as denied:
+            await service.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+        assert (denied.value.status, denied.value.code) == (403, "MCP_TOOL_DENIED")
+        assert client.calls == []
+
+    asyncio.run(_run())
+
+
+def test_circuit_breaker_is_per_operation_and_mcp():
+    async def _run() -> None:
+        client = FakeToolClient()
+        broken = {"list_resources"}
+
+        async def flaky_list_resources() -> list:
+            if "list_resources" in broken:
+                raise ConnectionError("upstream reset")
+            return []
+
+        client.list_resources = flaky_list_resources
+        breakers = MCPCircuitBreakers(failure_threshold=2, reset_timeout_seconds=60)
+        service = MCPToolService(
+            FakeRepository(None),
+            MCPConnectionManager(client_factory=lambda _: client),
+            breakers=breakers,
+        )
+
+        for _ in range(2):
+            with pytest.raises(ConnectionError):
+                await service.list_resources(MCP_ID, organization_id=ORG_ID)
+        with pytest.raises(MCPToolError) as paused:
+            await service.list_resources(MCP_ID, organization_id=ORG_ID)
+        assert (paused.value.status, paused.value.code) == (503, "MCP_CIRCUIT_OPEN")
+
+        # Other operations on the same server are unaffected.
+        await service.list_tools(MCP_ID, organization_id=ORG_ID)
+        states = {op: b.state for op, b in breakers.for_config(str(MCP_ID)).items()}
+        assert states == {"list_resources": "open", "list_tools": "closed"}
+
+        # After the reset timeout a trial call closes the breaker again.
+        broken.clear()
+        breakers.get("list_resources", str(MCP_ID)).opened_at -= 61
+        assert await service.list_resources(MCP_ID, organization_id=ORG_ID) == []
+        assert breakers.summary() == {"closed": 2, "open": 0, "half_open": 0}
+
+    asyncio.run(_run())
+
+
+def test_breakers_evict_least_recently_used():
+    breakers = MCPCircuitBreakers(max_breakers=2)
+    first = breakers.get("call_tool", "a")
+    breakers.get("call_tool", "b")
+    breakers.get("call_tool", "a")
+    breakers.get("call_tool", "c")
+    assert breakers.for_config("b") == {}
+    assert breakers.get("call_tool", "a") is first
+
+
+def test_server_notifications_and_sampling_policy():
+    async def _run() -> None:
+        cache = SizedLRUCache(65_536)
+        cache.set(str(MCP_ID), {"search": None})
+        org_record = await FakeRepository(str(ORG_ID)).get_config(MCP_ID)
+        completions: list[dict] = []
+
+        class FakeClaude:
+            async def complete(self, **kwargs):
+                completions.append(kwargs)
+                return SimpleNamespace(text="a summary")
+
+        repository = FakeRepository(None, policy={"sampling": ["do*"]})
+        events = MCPServerEvents(
+            tool_cache=cache,
+            policy_service=MCPToolPolicyService(repository),
+            claude_client=FakeClaude(),
+            sampling_model="claude-test",
+            sampling_max_tokens=256,
+        )
+
+        notify = events.message_handler(org_record)
+        changed = SimpleNamespace(method="notifications/tools/list_changed")
+        await notify(SimpleNamespace(root=changed))
+        assert cache.get(str(MCP_ID)) is None
+        updated = SimpleNamespace(
+            method="notifications/resources/updated", params=SimpleNamespace(uri="docs://a")
+        )
+        await notify(SimpleNamespace(root=updated))
+        assert list(events.resource_updates(str(MCP_ID))) == ["docs://a"]
+
+        sample = events.sampling_handler(org_record)
+        message = SimpleNamespace(role="user", content=SimpleNamespace(type="text", text="hi"))
+        params = SimpleNamespace(maxTokens=4000, temperature=None, systemPrompt="Be brief")
+        assert await sample([message], params) == "a summary"
+        assert completions[0]["max_tokens"] == 256
+        assert completions[0]["messages"] == [{"role": "user", "content": "hi"}]
+        # The prompt comes from the server, so the session gets no tools at all.
+        assert completions[0]["mcp_servers"] == {}
+        assert {"Bash", "Read", "Write", "Edit", "Skill"} <= set(
+            completions[0]["disallowed_tools"]
+        )
+        for tool in ("Bash", "mcp__other__delete"):
+            decision = await completions[0]["can_use_tool"](tool, {}, {})
+            assert decision["behavior"] == "deny"
+
+        repository.policy = {}
+        with pytest.raises(MCPSamplingError):
+            await sample([message], params)
+        platform_record = await FakeRepository(None).get_config(MCP_ID)
+        with pytest.raises(MCPSamplingError):
+            await events.sampling_handler(platform_record)([message], params)
+        assert len(completions) == 1
+
+    asyncio.run(_run())
+
+
+class FakeUsageRepository:
+    def __init__(self) -> None:
+        self.rows: list[dict] = []
+        self.fail = False
+
+    async def insert_usage(self, rows: list[dict]) -> None:
+        if self.fail:
+            raise ConnectionError("database unavailable")
+        self.rows.extend(rows)
+
+    async def list_usage(self, *, since, until, organization_id=None, mcp_id=None, **page):
+        matches = [
+            MCPUsageRecord(**row)
+            for row in self.rows
+            if since <= row["created_at"] < until
+            and (organization_id is None or row["org_id"] == str(organization_id))
+        ]
+        return matches[page["offset"] : page["offset"] + page["limit"]]
+
+
+def test_tool_calls_are_metered_and_summarized():
+    async def _run() -> None:
+        usage_repository = FakeUsageRepository()
+        meter = MCPUsageMeter(usage_repository, max_buffer=100)
+        client = FakeToolClient()
+        service = MCPToolService(
+            FakeRepository(str(ORG_ID)),
+            MCPConnectionManager(client_factory=lambda _: client),
+            usage=meter,
+        )
+        user_id = UUID("00000000-0000-0000-0000-000000000031")
+        for query in ("rotation", "pager"):
+            await service.call_tool(
+                MCP_ID, "search", {"query": query}, organization_id=ORG_ID, user_id=user_id
+            )
+
+        async def broken_call(name, arguments, progress_handler=None):
+            raise ConnectionError("server went away")
+
+        client.call_tool_mcp = broken_call
+        with pytest.raises(ConnectionError):
+            await service.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+
+        # Rows stay buffered until a flush.
+        assert usage_repository.rows == []
+        assert await meter.flush() == 3
+        first, _, failed = usage_repository.rows
+        assert (first["mcp_name"], first["tool"], first["success"]) == ("docs", "search", True)
+        assert first["user_id"] == str(user_id) and first["org_id"] == str(ORG_ID)
+        assert first["request_bytes"] == len('{"query":"rotation"}')
+        assert first["response_bytes"] > 0
+        assert (failed["success"], failed["error_code"]) == (False, "MCP_TOOL_ERROR")
+
+        since = datetime(2000, 1, 1, tzinfo=timezone.utc)
+        until = datetime.now(timezone.utc)
+        by_tool = await meter.summarize(group_by="tool", since=since, until=until)
+        [bucket] = by_tool.items
+        assert (bucket.key, bucket.mcp_id) == ("docs/search", str(MCP_ID))
+        assert (bucket.calls, bucket.failures, bucket.users) == (3, 1, 1)
+        assert not by_tool.truncated
+
+        by_day = await get_mcp_usage(
+            organization_id=ORG_ID,
+            group_by="day",
+            since=None,
+            until=None,
+            mcp_id=None,
+            is_platform_admin=False,
+            usage=meter,
+        )
+        assert [item.key for item in by_day.items] == [until.date().isoformat()]
+
+        capped = MCPUsageMeter(usage_repository, query_max_rows=2)
+        assert (await capped.summarize(group_by="organization", since=since, until=until)).truncated
+
+        usage_repository.fail = True
+        meter.record(
+            await FakeRepository(None).get_config(MCP_ID),
+            "search",
+            organization_id=ORG_ID,
+            user_id=None,
+            latency_ms=1.0,
+            success=True,
+            request_bytes=2,
+            response_bytes=2,
+        )
+        assert await meter.flush() == 0
+        assert len(usage_repository.rows) == 3
+
+    asyncio.run(_run())
+
+
+def test_error_budget_counts_failed_calls_per_configuration():
+    async def _run() -> None:
+        client = FakeToolClient()
+        budgets = ErrorBudgetTracker(min_events=1)
+        service = MCPToolService(
+            FakeRepository(str(ORG_ID)),
+            MCPConnectionManager(client_factory=lambda _: client),
+            error_budget=budgets,
+        )
+        await service.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+
+        async def tool_error(name, arguments, progress_handler=None):
+            return SimpleNamespace(isError=True, content=[], structuredContent=None)
+
+        client.call_tool_mcp = tool_error
+        await service.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+
+        async def broken_call(name, arguments, progress_handler=None):
+            raise ConnectionError("server went away")
+
+        client.call_tool_mcp = broken_call
+        with pytest.raises(ConnectionError):
+            await service.call_tool(MCP_ID, "search", {"query": "x"}, organization_id=ORG_ID)
+
+        [(operation, budget)] = budgets.summary().items()
+        assert operation == f"mcp.tool_call:{MCP_ID}"
+        assert (budget.events, budget.errors_by_code) == (3, {"MCP_TOOL_ERROR": 1})
+
+    asyncio.run(_run())
+
+
+def test_usage_across_organizations_requires_platform_admin():
+    async def _run() -> None:
+        meter = MCPUsageMeter(FakeUsageRepository())
+        kwargs = dict(group_by="organization", since=None, until=None, mcp_id=None)
+        with pytest.raises(HTTPException) as forbidden:
+            await get_mcp_usage(
+                organization_id=None, is_platform_admin=False, usage=meter, **kwargs
+            )
+        assert forbidden.value.status_code == 403
+
+        report = await get_mcp_usage(
+            organization_id=None,
+            is_platform_admin=True,
+            usage=meter,
+            **kwargs,
+        )
+        assert report.items == []
+
+        with pytest.raises(HTTPException) as backwards:
+            await get_mcp_usage(
+                organization_id=ORG_ID,
+                group_by="day",
+                since=datetime(2025, 2, 1),
+                until=datetime(2025, 1, 1),
+                mcp_id=None,
+                is_platform_admin=False,
+                usage=meter,
+            )
+        assert backwards.value.status_code == 400
+
+    asyncio.run(_run())
@@ -0,0 +1,58 @@
+from __future__ import annotations
+
+import asyncio
+
+import httpx
+import pytest
+
+from atomsAgent.db.supabase import SupabaseError
+from atomsAgent.utils.retry import RetryPolicy, is_retryable, parse_retry_after, retry
+
+
+def test_retry_backs_off_and_honours_retry_after():
+    delays: list[float] = []
+    calls: list[int] = []
+
+    async def record_sleep(delay: float) -> None:
+        delays.append(delay)
+
+    async def flaky() -> str:
+        calls.append(1)
+        if len(calls) == 1:
+            raise httpx.TimeoutException("read timed out")
+        if len(calls) == 2:
+            raise SupabaseError("Supabase error 503", status_code=503, retry_after=2.0)
+        return "ok"
+
+    policy = RetryPolicy(max_attempts=3, initial_delay=0.1, max_delay=5.0)
+    assert asyncio.run(retry(flaky, policy, sleep=record_sleep)) == "ok"
+    assert len(calls) == 3
+    assert 0 <= delays[0] <= 0.1
+    assert delays[1] == 2.0
+
+
+def test_retry_gives_up_on_permanent_errors_and_long_retry_after():
+    calls: list[int] = []
+
+    async def never_sleep(delay: float) -> None:
+        raise AssertionError("should not retry")
+
+    async def not_found() -> None:
+        calls.append(1)
+        raise SupabaseError("Supabase error 404", status_code=404)
+
+    async def throttled() -> None:
+        calls.append(1)
+        raise SupabaseError("Supabase error 429", status_code=429, retry_after=60.0)
+
+    with pytest.raises(SupabaseError, match="404"):
+        asyncio.run(retry(not_found, sleep=never_sleep))
+    with pytest.raises(SupabaseError, match="429"):
+        asyncio.run(retry(throttled, RetryPolicy(max_delay=10.0), sleep=never_sleep))
+    assert len(calls) == 2
+
+    assert is_retryable(SupabaseError("conflict", status_code=409)) is False
+    assert is_retryable(httpx.TransportError("connection reset")) is True
+    assert parse_retry_after("7") == 7.0
+    assert parse_retry_after("Thu, 01 Jan 1970 00:00:00 GMT") == 0.0
+    assert parse_retry_after("soon") is None
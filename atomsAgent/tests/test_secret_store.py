@@ -0,0 +1,209 @@
+from __future__ import annotations
+
+import asyncio
+from dataclasses import replace
+from types import SimpleNamespace
+from uuid import UUID
+
+import pytest
+from conftest import make_mcp_record
+from pydantic import HttpUrl
+
+from atomsAgent.db.repositories import MCPConfigRecord
+from atomsAgent.db.supabase import SupabaseError
+from atomsAgent.schemas.mcp import MCPCreateRequest, MCPScope, MCPUpdateRequest
+from atomsAgent.services.mcp_connections import MCPConnectionManager
+from atomsAgent.services.mcp_registry import MCPRegistryService
+from atomsAgent.services.secret_store import (
+    DatabaseSecretStore,
+    SecretStoreError,
+    VaultSecretStore,
+    create_secret_store,
+)
+from atomsAgent.services.session_tokens import (
+    InvalidResumeTokenError,
+    SessionResumeCodec,
+    SessionResumeState,
+)
+from atomsAgent.utils.crypto import legacy_fernet
+
+
+def _record(auth_token: str | None) -> MCPConfigRecord:
+    return make_mcp_record(auth_type="bearer", auth_token=auth_token)
+
+
+class FakeVault(VaultSecretStore):
+    def __init__(self) -> None:
+        super().__init__("https://vault.example.com/", "vault-token")
+        self.data: dict[str, dict] = {}
+        self.calls: list[tuple[str, str]] = []
+
+    async def _request(self, method, path, payload=None):
+        self.calls.append((method, path))
+        if method == "POST":
+            self.data[path] = payload
+            return {}
+        if method == "DELETE":
+            self.data.pop(path.replace("metadata/", "data/", 1), None)
+            return {}
+        return {"data": self.data[path]}
+
+
+def test_database_store_encrypts_and_passes_through_plaintext():
+    async def _run() -> None:
+        store = DatabaseSecretStore("encryption-key")
+        reference = await store.put("key", "token-1")
+        assert reference.startswith("enc:")
+        assert "token-1" not in reference
+        assert await store.resolve(reference) == "token-1"
+        # Values written before encryption was enabled are used as-is.
+        assert await store.resolve("legacy-token") == "legacy-token"
+        assert await store.resolve(None) is None
+
+        with pytest.raises(SecretStoreError):
+            await DatabaseSecretStore("other-key").resolve(reference)
+
+        unkeyed = DatabaseSecretStore(None)
+        assert await unkeyed.put("key", "token-1") == "token-1"
+
+    asyncio.run(_run())
+
+
+def test_database_store_key_is_separate_from_resume_tokens():
+    async def _run() -> None:
+        store = DatabaseSecretStore("encryption-key")
+        reference = await store.put("key", "token-1")
+        codec = SessionResumeCodec("encryption-key")
+        with pytest.raises(InvalidResumeTokenError):
+            codec.decode(reference.removeprefix("enc:"))
+        with pytest.raises(SecretStoreError):
+            await store.resolve(f"enc:{codec.issue(SessionResumeState('s1', None, 'm'))}")
+
+        # Rows encrypted under the previously shared key still decrypt.
+        legacy = legacy_fernet("encryption-key").encrypt(b"token-0").decode("ascii")
+        assert await store.resolve(f"enc:{legacy}") == "token-0"
+
+    asyncio.run(_run())
+
+
+def test_vault_store_roundtrip():
+    async def _run() -> None:
+        store = FakeVault()
+        reference = await store.put("abc", "token-1")
+        assert reference == "vault:atoms/mcp/abc"
+        assert await store.resolve(reference) == "token-1"
+        await store.delete(reference)
+        assert store.calls[-1] == ("DELETE", "metadata/atoms/mcp/abc")
+        assert store.data == {}
+
+    asyncio.run(_run())
+
+
+def test_create_secret_store_requires_vault_credentials():
+    settings = SimpleNamespace(secret_store_backend="vault", vault_url=None, vault_token=None)
+    with pytest.raises(SecretStoreError):
+        create_secret_store(settings)
+    with pytest.raises(SecretStoreError):
+        create_secret_store(SimpleNamespace(secret_store_backend="keyring"))
+    store = create_secret_store(
+        SimpleNamespace(secret_store_backend="database", token_encryption_key="k")
+    )
+    assert isinstance(store, DatabaseSecretStore)
+
+
+def test_registry_stores_reference_and_connections_resolve_it():
+    async def _run() -> None:
+        store = DatabaseSecretStore("encryption-key")
+        stored: dict[str, MCPConfigRecord] = {}
+
+        class _Repository:
+            async def create_config(self, payload):
+                record = replace(_record(payload.get("auth_token")), name=payload["name"])
+                stored[record.id] = record
+                return record
+
+            async def get_config(self, config_id):
+                return stored[str(config_id)]
+
+            async def delete_config(self, config_id):
+                stored.pop(str(config_id))
+
+        service = MCPRegistryService(_Repository(), secret_store=store)
+        await service.create(
+            MCPCreateRequest(
+                name="search",
+                endpoint=HttpUrl("https://search.example.com/mcp"),
+                auth_type="bearer",
+                bearer_token="token-1",
+                scope=MCPScope(
+                    type="organization", organization_id=UUID(_record(None).org_id)
+                ),
+            )
+        )
+        record = next(iter(stored.values()))
+        assert record.auth_token.startswith("enc:")
+
+        seen: list[str | None] = []
+
+        class _Client:
+            async def __aenter__(self):
+                return self
+
+            async def __aexit__(self, *exc):
+                return None
+
+        def factory(config: MCPConfigRecord) -> _Client:
+            seen.append(config.auth_token)
+            return _Client()
+
+        manager = MCPConnectionManager(client_factory=factory, secret_store=store)
+        async with manager.session(record):
+            pass
+        assert seen == ["token-1"]
+        await manager.shutdown()
+
+    asyncio.run(_run())
+
+
+def test_registry_update_writes_a_fresh_reference_only_for_valid_updates():
+    async def _run() -> None:
+        vault = FakeVault()
+        platform = replace(_record(None), org_id=None, scope="platform")
+        organization = _record(None)
+
+        class _Repository:
+            fail = False
+
+            async def get_config(self, config_id):
+                return platform if str(config_id) == "platform" else organization
+
+            async def update_config(self, config_id, payload):
+                if self.fail:
+                    raise SupabaseError("Supabase error 503", status_code=503)
+                return replace(organization, auth_token=payload.get("auth_token"))
+
+        repository = _Repository()
+        service = MCPRegistryService(repository, secret_store=vault)
+        config_id = UUID(organization.id)
+
+        with pytest.raises(ValueError):
+            await service.update(
+                "platform",  # type: ignore[arg-type]
+                MCPUpdateRequest(bearer_token="token-1", is_default=True),
+                platform_admin=True,
+            )
+        assert vault.calls == []
+
+        repository.fail = True
+        with pytest.raises(SupabaseError):
+            await service.update(config_id, MCPUpdateRequest(bearer_token="token-2"))
+        assert vault.data == {}
+        assert vault.calls[-1][0] == "DELETE"
+
+        repository.fail = False
+        updated = await service.update(config_id, MCPUpdateRequest(bearer_token="token-3"))
+        assert updated.id == config_id
+        (path,) = vault.data
+        assert organization.id not in path
+
+    asyncio.run(_run())
@@ -0,0 +1,95 @@
+from __future__ import annotations
+
+import asyncio
+import time
+from pathlib import Path
+
+from atomsAgent.services import claude_client
+from atomsAgent.services.claude_client import ClaudeSession, ClaudeSessionManager, SessionConfig
+from atomsAgent.services.sandbox import SandboxContext
+
+
+class _FakeClient:
+    def __init__(self) -> None:
+        self.disconnected = False
+
+    async def disconnect(self) -> None:
+        self.disconnected = True
+
+
+def _manager(monkeypatch) -> ClaudeSessionManager:
+    monkeypatch.setattr(claude_client, "_IMPORT_ERROR", None)
+    return ClaudeSessionManager(sandbox_manager=None, idle_timeout_seconds=60.0)
+
+
+def _add_session(
+    manager: ClaudeSessionManager,
+    session_id: str,
+    *,
+    last_used: float,
+    user_id: str | None = None,
+    organization_id: str | None = None,
+) -> ClaudeSession:
+    session = ClaudeSession(
+        session_id=session_id,
+        sandbox=SandboxContext(sandbox_id=session_id, workspace_path=Path("/tmp")),
+        config=SessionConfig(
+            system_prompt="",
+            model="claude-sonnet",
+            allowed_tools=[],
+            user_id=user_id,
+            organization_id=organization_id,
+        ),
+        client=_FakeClient(),
+        last_used=last_used,
+        connected=True,
+        idle_timeout=60.0,
+    )
+    manager._sessions[session_id] = session
+    return session
+
+
+def test_reaper_releases_only_sessions_idle_past_their_timeout(monkeypatch):
+    async def _run() -> None:
+        manager = _manager(monkeypatch)
+        stale = _add_session(manager, "stale", last_used=time.time() - 120)
+        fresh = _add_session(manager, "fresh", last_used=time.time())
+
+        assert await manager.reap_idle() == ["stale"]
+        assert stale.client.disconnected is True
+        assert manager.find_session("stale") is None
+        assert manager.find_session("fresh") is fresh
+        assert fresh.client.disconnected is False
+
+    asyncio.run(_run())
+
+
+def test_reaper_skips_busy_sessions(monkeypatch):
+    async def _run() -> None:
+        manager = _manager(monkeypatch)
+        busy = _add_session(manager, "busy", last_used=time.time() - 120)
+        async with busy.lock:
+            assert await manager.reap_idle() == []
+        assert manager.find_session("busy") is busy
+
+    asyncio.run(_run())
+
+
+def test_heartbeat_only_reaches_the_owner(monkeypatch):
+    async def _run() -> None:
+        manager = _manager(monkeypatch)
+        session = _add_session(
+            manager, "owned", last_used=0.0, user_id="user-1", organization_id="org-1"
+        )
+
+        assert await manager.heartbeat("owned") is None
+        assert await manager.heartbeat("owned", user_id="user-2", organization_id="org-1") is None
+        assert await manager.heartbeat("owned", user_id="user-1", organization_id="org-2") is None
+        assert session.last_used == 0.0
+        assert (
+            await manager.heartbeat("owned", user_id="user-1", organization_id="org-1")
+            is session
+        )
+        assert session.last_used > 0.0
+
+    asyncio.run(_run())
@@ -0,0 +1,67 @@
+from __future__ import annotations
+
+import pytest
+
+from atomsAgent.services.session_tokens import (
+    InvalidResumeTokenError,
+    SessionResumeCodec,
+    SessionResumeState,
+    check_resume_state,
+)
+
+
+def _state() -> SessionResumeState:
+    return SessionResumeState(
+        session_id="session-1",
+        sdk_session_id="cli-session-1",
+        model="claude-sonnet",
+        user_id="user-1",
+        organization_id="org-1",
+    )
+
+
+def test_resume_token_round_trip():
+    codec = SessionResumeCodec("secret")
+    token = codec.issue(_state())
+
+    assert "cli-session-1" not in token
+    state = codec.decode(token)
+    assert state.session_id == "session-1"
+    assert state.sdk_session_id == "cli-session-1"
+    assert state.user_id == "user-1"
+    assert state.issued_at > 0
+
+
+def test_resume_token_rejects_other_keys_and_tampering():
+    token = SessionResumeCodec("secret").issue(_state())
+
+    with pytest.raises(InvalidResumeTokenError):
+        SessionResumeCodec("other-secret").decode(token)
+    with pytest.raises(InvalidResumeTokenError):
+        SessionResumeCodec("secret").decode(token[:-4] + "AAAA")
+    with pytest.raises(InvalidResumeTokenError):
+        SessionResumeCodec("secret").decode("not-a-token")
+
+
+def test_resume_token_expires():
+    codec = SessionResumeCodec("secret", ttl_seconds=-1)
+    token = codec.issue(_state())
+
+    with pytest.raises(InvalidResumeTokenError):
+        codec.decode(token)
+
+
+def test_resume_state_must_match_the_session_user_and_organization():
+    state = _state()
+
+    check_resume_state(state, session_id="session-1", user_id="user-1", organization_id="org-1")
+    with pytest.raises(InvalidResumeTokenError):
+        check_resume_state(state, session_id="session-2", user_id="user-1", organization_id="org-1")
+    with pytest.raises(InvalidResumeTokenError):
+        check_resume_state(state, session_id="session-1", user_id=None, organization_id="org-1")
+    with pytest.raises(InvalidResumeTokenError):
+        check_resume_state(state, session_id="session-1", user_id="user-1", organization_id=None)
+    with pytest.raises(InvalidResumeTokenError):
+        check_resume_state(
+            state, session_id="session-1", user_id="user-1", organization_id="org-2"
+        )